@@ -0,0 +1,303 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/policy"
+	"goprojects/simplebank/util"
+)
+
+type createAccountRequest struct {
+	Currency string `json:"currency"`
+	//one of the products customers open themselves, checking by default
+	Product string `json:"product"`
+}
+
+func (server *Server) createAccount(w http.ResponseWriter, r *http.Request) {
+	var req createAccountRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !util.IsSupportedCurrency(req.Currency) {
+		writeError(w, http.StatusBadRequest, errors.New("unsupported currency"))
+		return
+	}
+	if req.Product == "" {
+		req.Product = db.ProductChecking
+	}
+	product, err := tenantStore(r).GetAccountProduct(r.Context(), req.Product)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusBadRequest, errors.New("unknown product"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !product.SelfService() {
+		writeError(w, http.StatusForbidden, fmt.Errorf("%s accounts are opened by the bank", product.Code))
+		return
+	}
+
+	payload := authPayload(r)
+	user, err := tenantStore(r).GetUser(r.Context(), payload.Username)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !user.IsEmailVerified {
+		writeError(w, http.StatusForbidden, errors.New("email address must be verified before opening an account"))
+		return
+	}
+
+	account, err := tenantStore(r).CreateAccount(r.Context(), db.CreateAccountParams{
+		Owner:    payload.Username,
+		Balance:  0,
+		Currency: req.Currency,
+		Product:  sql.NullString{String: product.Code, Valid: true},
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+func (server *Server) getAccount(w http.ResponseWriter, r *http.Request) {
+	id, err := readID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	account, ok := server.readableAccount(w, r, id)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+// listAccounts lists the caller's own accounts, or every account for roles allowed to read any
+func (server *Server) listAccounts(w http.ResponseWriter, r *http.Request) {
+	page, err := readPage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	payload := authPayload(r)
+	var accounts []db.Account
+	if policy.Can(policy.Role(payload.Role), policy.PermReadAnyAccount) {
+		accounts, err = tenantStore(r).ListAccounts(r.Context(), db.ListAccountsParams{
+			Limit:  page.Limit,
+			Offset: page.Offset,
+		})
+	} else {
+		accounts, err = tenantStore(r).ListAccountsByOwner(r.Context(), db.ListAccountsByOwnerParams{
+			Owner:  payload.Username,
+			Limit:  page.Limit,
+			Offset: page.Offset,
+		})
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, accounts)
+}
+
+// getAccountBalance returns the account's balance at the time given by the at query parameter, now by default
+func (server *Server) getAccountBalance(w http.ResponseWriter, r *http.Request) {
+	id, err := readID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	at, err := readTime(r, "at")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, ok := server.readableAccount(w, r, id); !ok {
+		return
+	}
+
+	balance, err := tenantStore(r).GetBalanceAt(r.Context(), id, at)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, errors.New("account was not open at that time"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, balance)
+}
+
+// getAccountStatement lists the account's entries from since up to until, now by default, with the balances at either end
+func (server *Server) getAccountStatement(w http.ResponseWriter, r *http.Request) {
+	id, err := readID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if r.URL.Query().Get("since") == "" {
+		writeError(w, http.StatusBadRequest, errors.New("since is required"))
+		return
+	}
+	since, err := readTime(r, "since")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	until, err := readTime(r, "until")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !since.Before(until) {
+		writeError(w, http.StatusBadRequest, errors.New("since must be before until"))
+		return
+	}
+
+	if _, ok := server.readableAccount(w, r, id); !ok {
+		return
+	}
+
+	statement, err := tenantStore(r).GetStatement(r.Context(), db.GetStatementParams{
+		AccountID: id,
+		Since:     since,
+		Until:     until,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrStatementTooLarge) {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, statement)
+}
+
+// listBalances returns the balances of the accounts given by repeated account_id parameters at one point in time.
+// Accounts the caller may not see, or that were not open yet, are left out.
+func (server *Server) listBalances(w http.ResponseWriter, r *http.Request) {
+	ids, err := readIDs(r, "account_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	at, err := readTime(r, "at")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	balances, err := tenantStore(r).ListBalancesAt(r.Context(), ids, at)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	payload := authPayload(r)
+	readable := make([]db.AccountBalance, 0, len(balances))
+	for _, balance := range balances {
+		if policy.CanReadAccount(policy.Role(payload.Role), payload.Username, balance.Owner) {
+			readable = append(readable, balance)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, readable)
+}
+
+type freezeAccountRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (server *Server) freezeAccount(w http.ResponseWriter, r *http.Request) {
+	server.setAccountFrozen(w, r, true)
+}
+
+func (server *Server) unfreezeAccount(w http.ResponseWriter, r *http.Request) {
+	server.setAccountFrozen(w, r, false)
+}
+
+func (server *Server) setAccountFrozen(w http.ResponseWriter, r *http.Request, frozen bool) {
+	id, err := readID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req freezeAccountRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Reason == "" {
+		writeError(w, http.StatusBadRequest, errors.New("reason is required"))
+		return
+	}
+
+	account, err := tenantStore(r).SetAccountFrozenTx(r.Context(), db.SetAccountFrozenTxParams{
+		AccountID: id,
+		Frozen:    frozen,
+		Actor:     authPayload(r).Username,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+// readableAccount loads an account the caller is allowed to see, writing the error response otherwise.
+// Accounts the caller may not see are reported as not found so their existence is not revealed.
+func (server *Server) readableAccount(w http.ResponseWriter, r *http.Request, id int64) (db.Account, bool) {
+	account, err := tenantStore(r).GetAccount(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, errors.New("account not found"))
+			return account, false
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return account, false
+	}
+
+	payload := authPayload(r)
+	if !policy.CanReadAccount(policy.Role(payload.Role), payload.Username, account.Owner) {
+		writeError(w, http.StatusNotFound, errors.New("account not found"))
+		return account, false
+	}
+
+	return account, true
+}
+
+// listAccountProducts returns the chart of accounts: every product an account can be opened as
+func (server *Server) listAccountProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := tenantStore(r).ListAccountProducts(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, products)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"goprojects/simplebank/db/migration"
+)
+
+// healthCheckTimeout bounds each dependency check so a hung database fails the probe instead of blocking it
+const healthCheckTimeout = 2 * time.Second
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+	Schema *migration.Status `json:"schema,omitempty"`
+}
+
+// healthz is the liveness probe: it fails only when the database cannot be reached
+func (server *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	resp := healthResponse{Status: "ok", Checks: map[string]string{"database": "ok"}}
+	if err := server.store.Ping(ctx); err != nil {
+		resp.Status = "unavailable"
+		resp.Checks["database"] = err.Error()
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// readyz is the readiness probe: the server takes traffic only when the database is reachable,
+// every migration is applied and it is not shutting down
+func (server *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), healthCheckTimeout)
+	defer cancel()
+
+	resp := healthResponse{Status: "ok", Checks: map[string]string{}}
+	fail := func(check string, err error) {
+		resp.Status = "unavailable"
+		resp.Checks[check] = err.Error()
+	}
+
+	if server.draining.Load() {
+		fail("server", errors.New("shutting down"))
+	} else {
+		resp.Checks["server"] = "ok"
+	}
+
+	if err := server.store.Ping(ctx); err != nil {
+		fail("database", err)
+	} else {
+		resp.Checks["database"] = "ok"
+
+		status, err := server.store.SchemaStatus(ctx)
+		switch {
+		case err != nil:
+			fail("migrations", err)
+		case !status.UpToDate():
+			resp.Schema = &status
+			fail("migrations", errors.New("database schema is not at the latest migration"))
+		default:
+			resp.Schema = &status
+			resp.Checks["migrations"] = "ok"
+		}
+	}
+
+	if resp.Status != "ok" {
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// maxRequestBodySize caps the JSON bodies the handlers are willing to decode
+const maxRequestBodySize = 1 << 20
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse(err))
+}
+
+func errorResponse(err error) map[string]string {
+	return map[string]string{"error": err.Error()}
+}
+
+// decodeJSON reads the request body into req, rejecting unknown fields
+func decodeJSON(w http.ResponseWriter, r *http.Request, req any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(req); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+// listEntries lists ledger entries, optionally only those of one account
+func (server *Server) listEntries(w http.ResponseWriter, r *http.Request) {
+	page, err := readPage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	accountID, filtered, err := readAccountFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var entries []db.Entry
+	if filtered {
+		entries, err = tenantStore(r).ListAccountEntries(r.Context(), db.ListAccountEntriesParams{
+			AccountID: accountID,
+			Limit:     page.Limit,
+			Offset:    page.Offset,
+		})
+	} else {
+		entries, err = tenantStore(r).ListEntries(r.Context(), db.ListEntriesParams{
+			Limit:  page.Limit,
+			Offset: page.Offset,
+		})
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// listAuditLogs lists the audit trail, newest first, optionally only for one account
+func (server *Server) listAuditLogs(w http.ResponseWriter, r *http.Request) {
+	page, err := readPage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	accountID, filtered, err := readAccountFilter(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var logs []db.AuditLog
+	if filtered {
+		logs, err = tenantStore(r).ListAccountAuditLogs(r.Context(), db.ListAccountAuditLogsParams{
+			AccountID: sql.NullInt64{Int64: accountID, Valid: true},
+			Limit:     page.Limit,
+			Offset:    page.Offset,
+		})
+	} else {
+		logs, err = tenantStore(r).ListAuditLogs(r.Context(), db.ListAuditLogsParams{
+			Limit:  page.Limit,
+			Offset: page.Offset,
+		})
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, logs)
+}
+
+type adjustmentRequest struct {
+	AccountID int64  `json:"account_id"`
+	Amount    int64  `json:"amount"`
+	Reason    string `json:"reason"`
+}
+
+// postAdjustment posts a manual correction to an account's balance
+func (server *Server) postAdjustment(w http.ResponseWriter, r *http.Request) {
+	var req adjustmentRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Amount == 0 || req.Reason == "" {
+		writeError(w, http.StatusBadRequest, errors.New("amount and reason are required"))
+		return
+	}
+
+	result, err := tenantStore(r).AdjustmentTx(r.Context(), db.AdjustmentTxParams{
+		AccountID: req.AccountID,
+		Amount:    req.Amount,
+		Actor:     authPayload(r).Username,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.ForeignKeyViolation {
+			writeError(w, http.StatusNotFound, errors.New("account not found"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// readAccountFilter reads the optional account_id query parameter
+func readAccountFilter(r *http.Request) (int64, bool, error) {
+	value := r.URL.Query().Get("account_id")
+	if value == "" {
+		return 0, false, nil
+	}
+	accountID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || accountID < 1 {
+		return 0, false, errors.New("invalid account_id")
+	}
+	return accountID, true, nil
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/policy"
+	"goprojects/simplebank/util"
+)
+
+var testDB *sql.DB
+
+func TestMain(m *testing.M) {
+	os.Exit(dbtest.Main(m, func(conn *sql.DB) {
+		testDB = conn
+	}))
+}
+
+// newTestServer creates a server on store
+func newTestServer(t *testing.T, store *db.Store) *Server {
+	config := util.Config{
+		TokenSymmetricKey:    util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+		PublicBaseURL:        "http://bank.test",
+		WebhookRequireHTTPS:  true,
+	}
+	server, err := NewServer(config, store)
+	require.NoError(t, err)
+	return server
+}
+
+// newRequest builds a request with a JSON body, when body is not nil, and a bearer token, when it is not empty
+func newRequest(t *testing.T, method, target string, body any, bearer string) *http.Request {
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		require.NoError(t, err)
+	}
+	request := httptest.NewRequest(method, target, bytes.NewReader(data))
+	if bearer != "" {
+		request.Header.Set(authorizationHeaderKey, "Bearer "+bearer)
+	}
+	return request
+}
+
+// serve sends a request built by newRequest to server
+func serve(t *testing.T, server *Server, method, target string, body any, bearer string) *httptest.ResponseRecorder {
+	return serveRequest(server, newRequest(t, method, target, body, bearer))
+}
+
+// serveRequest sends request to server
+func serveRequest(server *Server, request *http.Request) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+// signUp creates a depositor through the API and logs them in
+func signUp(t *testing.T, server *Server) loginUserResponse {
+	return signUpAs(t, server, policy.RoleDepositor)
+}
+
+// signUpAs creates a user through the API, gives them role and logs them in
+func signUpAs(t *testing.T, server *Server, role policy.Role) loginUserResponse {
+	username := util.RandomOwner() + util.RandomString(4)
+	password := util.RandomString(8)
+	rsp := serve(t, server, http.MethodPost, "/users", createUserRequest{
+		Username: username,
+		Password: password,
+		FullName: util.RandomOwner(),
+		Email:    util.RandomEmail(),
+	}, "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+
+	if role != policy.RoleDepositor {
+		_, err := server.store.UpdateUserRoleTx(context.Background(), db.UpdateUserRoleTxParams{
+			Username: username,
+			Role:     string(role),
+			Actor:    "test",
+		})
+		require.NoError(t, err)
+	}
+
+	rsp = serve(t, server, http.MethodPost, "/users/login", loginUserRequest{
+		Username: username,
+		Password: password,
+	}, "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+
+	var login loginUserResponse
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &login))
+	return login
+}
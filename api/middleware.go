@@ -0,0 +1,171 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"goprojects/simplebank/logging"
+	"goprojects/simplebank/policy"
+	"goprojects/simplebank/token"
+)
+
+const (
+	authorizationHeaderKey  = "Authorization"
+	authorizationTypeBearer = "bearer"
+)
+
+type contextKey string
+
+const authorizationPayloadKey contextKey = "authorization_payload"
+
+// errTokenTenant rejects a token issued by another tenant, whose role must not carry over
+var errTokenTenant = errors.New("token was issued for another tenant")
+
+// errAccessTokenType rejects a refresh token sent as a bearer token: it outlives access tokens
+// and is only checked against its session, which may have been revoked, when it is renewed
+var errAccessTokenType = errors.New("token is not an access token")
+
+// authenticated only lets requests carrying a valid access token of the request's tenant through to next
+func (server *Server) authenticated(next http.HandlerFunc) http.Handler {
+	return server.tenanted(func(w http.ResponseWriter, r *http.Request) {
+		authorizationHeader := r.Header.Get(authorizationHeaderKey)
+		if len(authorizationHeader) == 0 {
+			writeError(w, http.StatusUnauthorized, errors.New("authorization header is not provided"))
+			return
+		}
+
+		fields := strings.Fields(authorizationHeader)
+		if len(fields) != 2 {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid authorization header format"))
+			return
+		}
+
+		authorizationType := strings.ToLower(fields[0])
+		if authorizationType != authorizationTypeBearer {
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("unsupported authorization type %s", authorizationType))
+			return
+		}
+
+		payload, err := server.tokenMaker.VerifyToken(fields[1])
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+		if payload.Type != token.TokenTypeAccess {
+			writeError(w, http.StatusUnauthorized, errAccessTokenType)
+			return
+		}
+		if payload.Tenant != requestTenant(r).ID {
+			writeError(w, http.StatusUnauthorized, errTokenTenant)
+			return
+		}
+
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("enduser.id", payload.Username))
+		ctx := logging.With(r.Context(), "user", payload.Username)
+		ctx = context.WithValue(ctx, authorizationPayloadKey, payload)
+		next(w, r.WithContext(ctx))
+	})
+}
+
+// authPayload returns the access token payload stored by the authenticated middleware
+func authPayload(r *http.Request) *token.Payload {
+	return r.Context().Value(authorizationPayloadKey).(*token.Payload)
+}
+
+// authorized only lets authenticated requests whose role holds perm through to next
+func (server *Server) authorized(perm policy.Permission, next http.HandlerFunc) http.Handler {
+	return server.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		if err := policy.Authorize(policy.Role(authPayload(r).Role), perm); err != nil {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// traced starts a server span for every request, continuing the caller's trace when it sent a traceparent header.
+// The span is named after the matched route pattern so IDs in the path do not explode the number of span names.
+func (server *Server) traced(next http.Handler) http.Handler {
+	tracer := otel.Tracer("goprojects/simplebank/api")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		_, pattern := server.router.Handler(r)
+		if pattern == "" {
+			pattern = "unmatched"
+		}
+
+		ctx, span := tracer.Start(ctx, pattern,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", pattern),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// requestIDHeader carries the request ID, taken from the caller when it sends one
+const requestIDHeader = "X-Request-ID"
+
+// logged tags the request's context with a request ID and writes one log record per request
+func (server *Server) logged(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+		ctx := logging.With(r.Context(), "request_id", requestID)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		_, pattern := server.router.Handler(r)
+		server.logger.Log(ctx, level, "http request",
+			"method", r.Method,
+			"route", pattern,
+			"status", rec.status,
+			"duration", time.Since(start),
+			"client_ip", clientIP(r),
+		)
+	})
+}
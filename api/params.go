@@ -0,0 +1,75 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// pageParams are the page_id and page_size query parameters of list endpoints
+type pageParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func readPage(r *http.Request) (pageParams, error) {
+	pageID, err := strconv.ParseInt(r.URL.Query().Get("page_id"), 10, 32)
+	if err != nil || pageID < 1 {
+		return pageParams{}, errors.New("page_id must be a positive integer")
+	}
+
+	pageSize, err := strconv.ParseInt(r.URL.Query().Get("page_size"), 10, 32)
+	if err != nil || pageSize < 5 || pageSize > 100 {
+		return pageParams{}, errors.New("page_size must be between 5 and 100")
+	}
+
+	return pageParams{
+		Limit:  int32(pageSize),
+		Offset: int32((pageID - 1) * pageSize),
+	}, nil
+}
+
+// readID parses a positive int64 path parameter
+func readID(r *http.Request, name string) (int64, error) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || id < 1 {
+		return 0, errors.New("invalid " + name)
+	}
+	return id, nil
+}
+
+// readTime parses an optional RFC 3339 query parameter, defaulting to the current time
+func readTime(r *http.Request, name string) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return time.Now(), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, errors.New(name + " must be an RFC 3339 timestamp")
+	}
+	return t, nil
+}
+
+// maxIDs bounds how many IDs a request may pass in a repeated query parameter
+const maxIDs = 100
+
+// readIDs parses a repeated positive int64 query parameter such as ?account_id=1&account_id=2
+func readIDs(r *http.Request, name string) ([]int64, error) {
+	values := r.URL.Query()[name]
+	if len(values) == 0 || len(values) > maxIDs {
+		return nil, fmt.Errorf("between 1 and %d %s parameters are required", maxIDs, name)
+	}
+
+	ids := make([]int64, len(values))
+	for i, value := range values {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id < 1 {
+			return nil, errors.New("invalid " + name)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
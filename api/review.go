@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+// listFlaggedTransfers lists the transfers held by risk screening, oldest first;
+// the status query parameter picks cleared or rejected ones instead of those pending review
+func (server *Server) listFlaggedTransfers(w http.ResponseWriter, r *http.Request) {
+	page, err := readPage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = db.FlagStatusPending
+	case db.FlagStatusPending, db.FlagStatusCleared, db.FlagStatusRejected:
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("status must be pending, cleared or rejected"))
+		return
+	}
+
+	flags, err := tenantStore(r).ListFlaggedTransfers(r.Context(), db.ListFlaggedTransfersParams{
+		Status: status,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, flags)
+}
+
+type reviewFlaggedTransferRequest struct {
+	Note string `json:"note"`
+}
+
+// clearFlaggedTransfer releases a held transfer to its recipient
+func (server *Server) clearFlaggedTransfer(w http.ResponseWriter, r *http.Request) {
+	server.reviewFlaggedTransfer(w, r, tenantStore(r).ClearFlaggedTransferTx)
+}
+
+// rejectFlaggedTransfer returns a held transfer's funds to its sender
+func (server *Server) rejectFlaggedTransfer(w http.ResponseWriter, r *http.Request) {
+	server.reviewFlaggedTransfer(w, r, tenantStore(r).RejectFlaggedTransferTx)
+}
+
+func (server *Server) reviewFlaggedTransfer(
+	w http.ResponseWriter,
+	r *http.Request,
+	resolve func(ctx context.Context, arg db.ReviewFlaggedTransferParams) (db.ReviewFlaggedTransferResult, error),
+) {
+	id, err := readID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var req reviewFlaggedTransferRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	//the resolution cannot tell a missing hold from a settled one, so look it up first
+	if _, err := tenantStore(r).GetFlaggedTransfer(r.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, errors.New("flagged transfer not found"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	result, err := resolve(r.Context(), db.ReviewFlaggedTransferParams{
+		FlagID:     id,
+		ReviewedBy: authPayload(r).Username,
+		Note:       req.Note,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrFlagNotPending) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
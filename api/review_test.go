@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/policy"
+	"goprojects/simplebank/risk"
+	"goprojects/simplebank/util"
+)
+
+// holdEverything is a rule that sends every transfer to review
+type holdEverything struct{}
+
+func (holdEverything) Name() string          { return "hold-everything" }
+func (holdEverything) Outcome() risk.Outcome { return risk.OutcomeReview }
+func (holdEverything) Match(context.Context, risk.Transfer, risk.History) (bool, string, error) {
+	return true, "held by the test", nil
+}
+
+func TestReviewFlaggedTransfer(t *testing.T) {
+	store := db.NewStore(testDB, db.WithScreener(risk.NewEngineFromRules(holdEverything{})))
+	server := newTestServer(t, store)
+	depositor := signUp(t, server)
+	banker := signUpAs(t, server, policy.RoleBanker)
+
+	from, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    depositor.User.Username,
+		Balance:  1000,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+	to, err := store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    banker.User.Username,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+
+	transfer := func() db.FlaggedTransfer {
+		rsp := serve(t, server, http.MethodPost, "/transfers", transferRequest{
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        10,
+			Currency:      util.USD,
+		}, depositor.AccessToken)
+		require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+		var result db.TransferTxResult
+		require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &result))
+		require.NotNil(t, result.Flag)
+		require.Equal(t, db.FlagStatusPending, result.Flag.Status)
+		return *result.Flag
+	}
+	cleared, rejected := transfer(), transfer()
+
+	rsp := serve(t, server, http.MethodGet, "/flagged_transfers?page_id=1&page_size=100", nil, depositor.AccessToken)
+	require.Equal(t, http.StatusForbidden, rsp.Code, rsp.Body.String())
+	rsp = serve(t, server, http.MethodPost, fmt.Sprintf("/flagged_transfers/%d/clear", cleared.ID), reviewFlaggedTransferRequest{}, depositor.AccessToken)
+	require.Equal(t, http.StatusForbidden, rsp.Code, rsp.Body.String())
+
+	rsp = serve(t, server, http.MethodGet, "/flagged_transfers?page_id=1&page_size=100", nil, banker.AccessToken)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	var pending []db.FlaggedTransfer
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &pending))
+	ids := make([]int64, 0, len(pending))
+	for _, flag := range pending {
+		ids = append(ids, flag.ID)
+	}
+	require.Contains(t, ids, cleared.ID)
+	require.Contains(t, ids, rejected.ID)
+
+	rsp = serve(t, server, http.MethodPost, fmt.Sprintf("/flagged_transfers/%d/clear", cleared.ID), reviewFlaggedTransferRequest{Note: "known customer"}, banker.AccessToken)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	var result db.ReviewFlaggedTransferResult
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &result))
+	require.Equal(t, db.FlagStatusCleared, result.Flag.Status)
+	require.Equal(t, banker.User.Username, result.Flag.ReviewedBy.String)
+	require.Equal(t, "known customer", result.Flag.ReviewNote.String)
+	require.Equal(t, to.ID, result.Account.ID)
+	require.Equal(t, to.Balance+10, result.Account.Balance)
+
+	rsp = serve(t, server, http.MethodPost, fmt.Sprintf("/flagged_transfers/%d/reject", rejected.ID), reviewFlaggedTransferRequest{}, banker.AccessToken)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &result))
+	require.Equal(t, db.FlagStatusRejected, result.Flag.Status)
+	require.Equal(t, from.ID, result.Account.ID)
+
+	//a settled flag cannot be reviewed again, and an unknown one is not found
+	rsp = serve(t, server, http.MethodPost, fmt.Sprintf("/flagged_transfers/%d/reject", cleared.ID), reviewFlaggedTransferRequest{}, banker.AccessToken)
+	require.Equal(t, http.StatusConflict, rsp.Code, rsp.Body.String())
+	rsp = serve(t, server, http.MethodPost, "/flagged_transfers/999999999/clear", reviewFlaggedTransferRequest{}, banker.AccessToken)
+	require.Equal(t, http.StatusNotFound, rsp.Code, rsp.Body.String())
+
+	rsp = serve(t, server, http.MethodGet, "/flagged_transfers?status=cleared&page_id=1&page_size=100", nil, banker.AccessToken)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	rsp = serve(t, server, http.MethodGet, "/flagged_transfers?status=unknown&page_id=1&page_size=100", nil, banker.AccessToken)
+	require.Equal(t, http.StatusBadRequest, rsp.Code, rsp.Body.String())
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/policy"
+	"goprojects/simplebank/token"
+	"goprojects/simplebank/util"
+)
+
+// Server serves HTTP requests for our banking service
+type Server struct {
+	config     util.Config
+	store      *db.Store
+	tokenMaker token.Maker
+	router     *http.ServeMux
+	//handler is the router wrapped in the middleware every request passes through
+	handler http.Handler
+	//metrics serves GET /metrics when set
+	metrics prometheus.Gatherer
+	logger  *slog.Logger
+
+	mu         sync.Mutex
+	httpServer *http.Server
+	//draining is set once shutdown starts, so readiness fails while in-flight requests finish
+	draining atomic.Bool
+	//cancel aborts the requests still running when the shutdown deadline passes
+	cancel context.CancelFunc
+}
+
+// ServerOption configures optional Server behaviour
+type ServerOption func(*Server)
+
+// WithMetrics exposes the metrics gathered by gatherer on GET /metrics
+func WithMetrics(gatherer prometheus.Gatherer) ServerOption {
+	return func(server *Server) {
+		server.metrics = gatherer
+	}
+}
+
+// WithLogger replaces slog.Default as the logger of the request log
+func WithLogger(logger *slog.Logger) ServerOption {
+	return func(server *Server) {
+		server.logger = logger
+	}
+}
+
+// NewServer creates a new HTTP server and sets up routing
+func NewServer(config util.Config, store *db.Store, opts ...ServerOption) (*Server, error) {
+	tokenMaker, err := token.NewJWTMaker(config.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	server := &Server{
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+		logger:     slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(server)
+	}
+	server.setupRouter()
+	return server, nil
+}
+
+func (server *Server) setupRouter() {
+	router := http.NewServeMux()
+
+	router.HandleFunc("GET /healthz", server.healthz)
+	router.HandleFunc("GET /readyz", server.readyz)
+
+	router.HandleFunc("POST /users", server.tenanted(server.createUser))
+	router.HandleFunc("POST /users/login", server.tenanted(server.loginUser))
+	router.HandleFunc("POST /tokens/renew_access", server.tenanted(server.renewAccessToken))
+	router.HandleFunc("GET /verify_email", server.tenanted(server.verifyEmail))
+	router.Handle("POST /verify_email/resend", server.authenticated(server.resendVerifyEmail))
+
+	router.Handle("GET /sessions", server.authenticated(server.listSessions))
+	router.Handle("DELETE /sessions", server.authenticated(server.revokeAllSessions))
+	router.Handle("DELETE /sessions/{id}", server.authenticated(server.revokeSession))
+
+	router.Handle("POST /accounts", server.authorized(policy.PermCreateAccount, server.createAccount))
+	router.Handle("GET /accounts", server.authenticated(server.listAccounts))
+	router.Handle("GET /accounts/{id}", server.authenticated(server.getAccount))
+	router.Handle("GET /accounts/{id}/balance", server.authenticated(server.getAccountBalance))
+	router.Handle("GET /accounts/{id}/statement", server.authenticated(server.getAccountStatement))
+	router.Handle("GET /balances", server.authenticated(server.listBalances))
+	router.Handle("GET /products", server.authenticated(server.listAccountProducts))
+	router.Handle("POST /accounts/{id}/freeze", server.authorized(policy.PermFreezeAccount, server.freezeAccount))
+	router.Handle("POST /accounts/{id}/unfreeze", server.authorized(policy.PermFreezeAccount, server.unfreezeAccount))
+
+	router.Handle("POST /transfers", server.authorized(policy.PermTransfer, server.createTransfer))
+	router.Handle("GET /transfers", server.authenticated(server.listTransfersByReference))
+	router.Handle("GET /flagged_transfers", server.authorized(policy.PermReviewTransfers, server.listFlaggedTransfers))
+	router.Handle("POST /flagged_transfers/{id}/clear", server.authorized(policy.PermReviewTransfers, server.clearFlaggedTransfer))
+	router.Handle("POST /flagged_transfers/{id}/reject", server.authorized(policy.PermReviewTransfers, server.rejectFlaggedTransfer))
+
+	router.Handle("POST /webhooks", server.authenticated(server.createWebhookSubscription))
+	router.Handle("GET /webhooks", server.authenticated(server.listWebhookSubscriptions))
+	router.Handle("DELETE /webhooks/{id}", server.authenticated(server.deleteWebhookSubscription))
+	router.Handle("POST /webhooks/{id}/enable", server.authenticated(server.enableWebhookSubscription))
+	router.Handle("GET /webhooks/{id}/deliveries", server.authenticated(server.listWebhookDeliveries))
+	router.Handle("POST /webhooks/{id}/deliveries/{delivery_id}/replay", server.authenticated(server.replayWebhookDelivery))
+
+	router.Handle("GET /entries", server.authorized(policy.PermReadEntries, server.listEntries))
+	router.Handle("GET /audit_logs", server.authorized(policy.PermReadAuditLogs, server.listAuditLogs))
+	router.Handle("POST /adjustments", server.authorized(policy.PermPostAdjustment, server.postAdjustment))
+	router.Handle("PUT /users/{username}/role", server.authorized(policy.PermAssignRole, server.updateUserRole))
+
+	if server.metrics != nil {
+		router.Handle("GET /metrics", promhttp.HandlerFor(server.metrics, promhttp.HandlerOpts{}))
+	}
+
+	server.router = router
+	server.handler = server.traced(server.logged(router))
+}
+
+// ServeHTTP lets the server be used as an http.Handler, which is how the tests drive it
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.handler.ServeHTTP(w, r)
+}
+
+// Start runs the HTTP server on a specific address until Shutdown is called
+func (server *Server) Start(address string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	httpServer := &http.Server{
+		Addr:              address,
+		Handler:           server,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	server.mu.Lock()
+	if server.draining.Load() {
+		server.mu.Unlock()
+		cancel()
+		return nil
+	}
+	server.httpServer = httpServer
+	server.cancel = cancel
+	server.mu.Unlock()
+
+	err := httpServer.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests, such as running transfers, to finish.
+// Requests still running when ctx ends are cancelled, which rolls back their transactions.
+func (server *Server) Shutdown(ctx context.Context) error {
+	server.mu.Lock()
+	server.draining.Store(true)
+	httpServer, cancel := server.httpServer, server.cancel
+	server.mu.Unlock()
+
+	if httpServer == nil {
+		return nil
+	}
+
+	err := httpServer.Shutdown(ctx)
+	cancel()
+	if err != nil {
+		httpServer.Close()
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+// sessionResponse is a session without its refresh token
+type sessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newSessionResponse(session db.Session) sessionResponse {
+	return sessionResponse{
+		ID:        session.ID,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		IsBlocked: session.IsBlocked,
+		ExpiresAt: session.ExpiresAt,
+		CreatedAt: session.CreatedAt,
+	}
+}
+
+func (server *Server) listSessions(w http.ResponseWriter, r *http.Request) {
+	payload := authPayload(r)
+
+	sessions, err := tenantStore(r).ListUserSessions(r.Context(), payload.Username)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := make([]sessionResponse, len(sessions))
+	for i, session := range sessions {
+		rsp[i] = newSessionResponse(session)
+	}
+	writeJSON(w, http.StatusOK, rsp)
+}
+
+// revokeSession blocks one of the caller's own sessions
+func (server *Server) revokeSession(w http.ResponseWriter, r *http.Request) {
+	payload := authPayload(r)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid session id"))
+		return
+	}
+
+	session, err := tenantStore(r).BlockSession(r.Context(), db.BlockSessionParams{
+		ID:       id,
+		Username: payload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, errors.New("session not found"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newSessionResponse(session))
+}
+
+type revokeAllSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+// revokeAllSessions blocks every session of the caller, logging them out everywhere
+func (server *Server) revokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	payload := authPayload(r)
+
+	revoked, err := tenantStore(r).BlockUserSessions(r.Context(), payload.Username)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, revokeAllSessionsResponse{Revoked: revoked})
+}
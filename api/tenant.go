@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/logging"
+)
+
+// tenantHeader names the tenant explicitly, for clients that do not call the API on the tenant's own host
+const tenantHeader = "X-Tenant-ID"
+
+const tenantScopeKey contextKey = "tenant_scope"
+
+// tenantScope is the tenant a request was made for and the store acting for it
+type tenantScope struct {
+	tenant db.Tenant
+	store  *db.Store
+}
+
+// tenanted resolves the tenant a request is made for and runs next with a store acting for that tenant,
+// so Postgres' row-level security keeps every query of the request inside the tenant
+func (server *Server) tenanted(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tenant, err := server.resolveTenant(r)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeError(w, http.StatusNotFound, errors.New("unknown tenant"))
+				return
+			}
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		store, release, err := server.store.ForTenant(r.Context(), tenant.ID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		defer release()
+
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("tenant.id", tenant.ID))
+		ctx := logging.With(r.Context(), "tenant", tenant.ID)
+		ctx = context.WithValue(ctx, tenantScopeKey, &tenantScope{tenant: tenant, store: store})
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// tenantParam names the tenant in links sent by email, which cannot carry a header
+const tenantParam = "tenant_id"
+
+// resolveTenant picks the tenant named by the X-Tenant-ID header or the tenant_id query parameter,
+// else the one owning the requested host, else the default tenant, so a deployment serving a single bank needs no configuration
+func (server *Server) resolveTenant(r *http.Request) (db.Tenant, error) {
+	if id := r.Header.Get(tenantHeader); id != "" {
+		return server.store.GetTenant(r.Context(), id)
+	}
+	if id := r.URL.Query().Get(tenantParam); id != "" {
+		return server.store.GetTenant(r.Context(), id)
+	}
+
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	tenant, err := server.store.GetTenantByHost(r.Context(), sql.NullString{String: host, Valid: host != ""})
+	if errors.Is(err, sql.ErrNoRows) {
+		return server.store.GetTenant(r.Context(), db.DefaultTenant)
+	}
+	return tenant, err
+}
+
+// requestTenant returns the tenant resolved by the tenanted middleware
+func requestTenant(r *http.Request) db.Tenant {
+	return r.Context().Value(tenantScopeKey).(*tenantScope).tenant
+}
+
+// tenantStore returns the store acting for the request's tenant
+func tenantStore(r *http.Request) *db.Store {
+	return r.Context().Value(tenantScopeKey).(*tenantScope).store
+}
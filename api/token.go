@@ -0,0 +1,93 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"goprojects/simplebank/token"
+)
+
+// errRefreshTokenType rejects an access token sent for renewal, which would otherwise extend itself without a session
+var errRefreshTokenType = errors.New("token is not a refresh token")
+
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type renewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+func (server *Server) renewAccessToken(w http.ResponseWriter, r *http.Request) {
+	var req renewAccessTokenRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+	if refreshPayload.Type != token.TokenTypeRefresh {
+		writeError(w, http.StatusUnauthorized, errRefreshTokenType)
+		return
+	}
+	if refreshPayload.Tenant != requestTenant(r).ID {
+		writeError(w, http.StatusUnauthorized, errTokenTenant)
+		return
+	}
+
+	session, err := tenantStore(r).GetSession(r.Context(), refreshPayload.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusUnauthorized, errors.New("session not found"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	//a revoked session must stop producing access tokens even though its refresh token is still signed
+	if session.IsBlocked {
+		writeError(w, http.StatusUnauthorized, errors.New("blocked session"))
+		return
+	}
+
+	if session.Username != refreshPayload.Username {
+		writeError(w, http.StatusUnauthorized, errors.New("incorrect session user"))
+		return
+	}
+
+	if session.RefreshToken != req.RefreshToken {
+		writeError(w, http.StatusUnauthorized, errors.New("mismatched session token"))
+		return
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		writeError(w, http.StatusUnauthorized, errors.New("expired session"))
+		return
+	}
+
+	//read the role again so a changed role takes effect at the next renewal
+	user, err := tenantStore(r).GetUser(r.Context(), session.Username)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(token.TokenTypeAccess, user.Username, user.Role, user.TenantID, server.config.AccessTokenDuration)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, renewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+	})
+}
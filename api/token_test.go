@@ -0,0 +1,43 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+func TestRefreshTokenIsNotABearerToken(t *testing.T) {
+	server := newTestServer(t, db.NewStore(testDB))
+	login := signUp(t, server)
+
+	rsp := serve(t, server, http.MethodGet, "/sessions", nil, login.AccessToken)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+
+	rsp = serve(t, server, http.MethodGet, "/sessions", nil, login.RefreshToken)
+	require.Equal(t, http.StatusUnauthorized, rsp.Code)
+	require.Contains(t, rsp.Body.String(), errAccessTokenType.Error())
+}
+
+func TestAccessTokenCannotBeRenewed(t *testing.T) {
+	server := newTestServer(t, db.NewStore(testDB))
+	login := signUp(t, server)
+
+	rsp := serve(t, server, http.MethodPost, "/tokens/renew_access", renewAccessTokenRequest{RefreshToken: login.AccessToken}, "")
+	require.Equal(t, http.StatusUnauthorized, rsp.Code)
+	require.Contains(t, rsp.Body.String(), errRefreshTokenType.Error())
+
+	rsp = serve(t, server, http.MethodPost, "/tokens/renew_access", renewAccessTokenRequest{RefreshToken: login.RefreshToken}, "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+
+	// The renewed token is an access token, so it is accepted as a bearer token but cannot be renewed in turn.
+	var renewed renewAccessTokenResponse
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &renewed))
+	rsp = serve(t, server, http.MethodGet, "/sessions", nil, renewed.AccessToken)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+	rsp = serve(t, server, http.MethodPost, "/tokens/renew_access", renewAccessTokenRequest{RefreshToken: renewed.AccessToken}, "")
+	require.Equal(t, http.StatusUnauthorized, rsp.Code)
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/policy"
+)
+
+type transferRequest struct {
+	FromAccountID     int64           `json:"from_account_id"`
+	ToAccountID       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	Currency          string          `json:"currency"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+}
+
+func (server *Server) createTransfer(w http.ResponseWriter, r *http.Request) {
+	var req transferRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Amount <= 0 {
+		writeError(w, http.StatusBadRequest, errors.New("amount must be positive"))
+		return
+	}
+	if req.FromAccountID == req.ToAccountID {
+		writeError(w, http.StatusBadRequest, errors.New("cannot transfer to the same account"))
+		return
+	}
+
+	fromAccount, ok := server.validAccount(w, r, req.FromAccountID, req.Currency)
+	if !ok {
+		return
+	}
+
+	payload := authPayload(r)
+	if !policy.CanMoveMoneyFrom(policy.Role(payload.Role), payload.Username, fromAccount.Owner) {
+		writeError(w, http.StatusForbidden, errors.New("from account doesn't belong to the authenticated user"))
+		return
+	}
+
+	if _, ok := server.validAccount(w, r, req.ToAccountID, req.Currency); !ok {
+		return
+	}
+
+	result, err := tenantStore(r).TransferTx(r.Context(), db.TransferTxParams{
+		FromAccountID:     req.FromAccountID,
+		ToAccountID:       req.ToAccountID,
+		Amount:            req.Amount,
+		Description:       req.Description,
+		ExternalReference: req.ExternalReference,
+		Metadata:          req.Metadata,
+	})
+	if err != nil {
+		writeError(w, transferErrorStatus(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// maxTransfersByReference bounds how many transfers a lookup by reference returns
+const maxTransfersByReference = 100
+
+// listTransfersByReference finds the transfers with an external reference, oldest first.
+// Callers who may read any account see them all, others only those from or to their own accounts.
+func (server *Server) listTransfersByReference(w http.ResponseWriter, r *http.Request) {
+	reference := r.URL.Query().Get("external_reference")
+	if reference == "" {
+		writeError(w, http.StatusBadRequest, errors.New("external_reference is required"))
+		return
+	}
+
+	payload := authPayload(r)
+	owner := payload.Username
+	if policy.Can(policy.Role(payload.Role), policy.PermReadAnyAccount) {
+		owner = ""
+	}
+
+	transfers, err := tenantStore(r).ListTransfersByReference(r.Context(), db.ListTransfersByReferenceParams{
+		ExternalReference: reference,
+		Owner:             owner,
+		MaxTransfers:      maxTransfersByReference,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transfers)
+}
+
+// validAccount checks that the account exists and holds the expected currency
+func (server *Server) validAccount(w http.ResponseWriter, r *http.Request, accountID int64, currency string) (db.Account, bool) {
+	account, err := tenantStore(r).GetAccount(r.Context(), accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, errors.New("account not found"))
+			return account, false
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return account, false
+	}
+
+	if account.Currency != currency {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency))
+		return account, false
+	}
+
+	return account, true
+}
+
+// transferErrorStatus maps the errors TransferTx can return onto HTTP statuses
+func transferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, db.ErrInvalidDetails):
+		return http.StatusBadRequest
+	case errors.Is(err, db.ErrLimitExceeded), errors.Is(err, db.ErrInsufficientFunds):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, db.ErrTransferBlocked):
+		return http.StatusForbidden
+	case errors.Is(err, db.ErrAccountFrozen), errors.Is(err, db.ErrCrossTenant):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
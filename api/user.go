@@ -0,0 +1,311 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/policy"
+	"goprojects/simplebank/token"
+	"goprojects/simplebank/util"
+	"goprojects/simplebank/worker"
+)
+
+type createUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+}
+
+func (req createUserRequest) validate() error {
+	if err := validateUsername(req.Username); err != nil {
+		return err
+	}
+	if len(req.Password) < 6 {
+		return errors.New("password must contain at least 6 characters")
+	}
+	if req.FullName == "" {
+		return errors.New("full_name is required")
+	}
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return errors.New("email is not a valid email address")
+	}
+	return nil
+}
+
+func validateUsername(username string) error {
+	if len(username) < 3 || len(username) > 100 {
+		return errors.New("username must contain from 3 to 100 characters")
+	}
+	for _, c := range username {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_') {
+			return errors.New("username must contain only lowercase letters, digits, or underscore")
+		}
+	}
+	return nil
+}
+
+// userResponse is a user without its password hash
+type userResponse struct {
+	Username          string    `json:"username"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	Role              string    `json:"role"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+func newUserResponse(user db.User) userResponse {
+	return userResponse{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		Role:              user.Role,
+		IsEmailVerified:   user.IsEmailVerified,
+		PasswordChangedAt: user.PasswordChangedAt,
+		CreatedAt:         user.CreatedAt,
+	}
+}
+
+func (server *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	var req createUserRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.Password)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	//the verification email is enqueued in the same transaction, so no user is left without one
+	user, err := tenantStore(r).CreateUserTx(r.Context(), db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.Username,
+			HashedPassword: hashedPassword,
+			FullName:       req.FullName,
+			Email:          req.Email,
+		},
+		AfterCreate: func(q *db.Queries, user db.User) error {
+			_, err := worker.TaskSendVerifyEmail.Enqueue(r.Context(), q, worker.PayloadSendVerifyEmail{
+				Username: user.Username,
+			}, worker.Priority(10))
+			return err
+		},
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			writeError(w, http.StatusConflict, errors.New("username or email already exists"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newUserResponse(user))
+}
+
+type loginUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type loginUserResponse struct {
+	SessionID             uuid.UUID    `json:"session_id"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  userResponse `json:"user"`
+}
+
+func (server *Server) loginUser(w http.ResponseWriter, r *http.Request) {
+	var req loginUserRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	user, err := tenantStore(r).GetUser(r.Context(), req.Username)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusUnauthorized, errors.New("incorrect username or password"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := util.CheckPassword(req.Password, user.HashedPassword); err != nil {
+		writeError(w, http.StatusUnauthorized, errors.New("incorrect username or password"))
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(token.TokenTypeAccess, user.Username, user.Role, user.TenantID, server.config.AccessTokenDuration)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(token.TokenTypeRefresh, user.Username, user.Role, user.TenantID, server.config.RefreshTokenDuration)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	//the session shares its ID with the refresh token so a renewal request can find it
+	session, err := tenantStore(r).CreateSession(r.Context(), db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    r.UserAgent(),
+		ClientIp:     clientIP(r),
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("cannot create session: %w", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, loginUserResponse{
+		SessionID:             session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
+		User:                  newUserResponse(user),
+	})
+}
+
+// clientIP returns the address of the peer that sent the request
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+type updateUserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// updateUserRole assigns a new role to a user; it applies from the user's next login or token renewal
+func (server *Server) updateUserRole(w http.ResponseWriter, r *http.Request) {
+	var req updateUserRoleRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !policy.Role(req.Role).Valid() {
+		writeError(w, http.StatusBadRequest, errors.New("unknown role"))
+		return
+	}
+
+	user, err := tenantStore(r).UpdateUserRoleTx(r.Context(), db.UpdateUserRoleTxParams{
+		Username: r.PathValue("username"),
+		Role:     req.Role,
+		Actor:    authPayload(r).Username,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, errors.New("user not found"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newUserResponse(user))
+}
+
+type verifyEmailResponse struct {
+	IsVerified bool `json:"is_verified"`
+}
+
+// verifyEmail is the target of the link in the verification email
+func (server *Server) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	emailID, err := strconv.ParseInt(r.URL.Query().Get("email_id"), 10, 64)
+	if err != nil || emailID < 1 {
+		writeError(w, http.StatusBadRequest, errors.New("invalid email_id"))
+		return
+	}
+	secretCode := r.URL.Query().Get("secret_code")
+	if secretCode == "" {
+		writeError(w, http.StatusBadRequest, errors.New("secret_code is required"))
+		return
+	}
+
+	_, err = tenantStore(r).VerifyEmailTx(r.Context(), db.VerifyEmailTxParams{
+		EmailID:    emailID,
+		SecretCode: secretCode,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidVerifyCode) {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, verifyEmailResponse{IsVerified: true})
+}
+
+// errVerifyEmailTooSoon is returned when a user asks for verification emails faster than the configured interval
+var errVerifyEmailTooSoon = errors.New("a verification email was sent recently, try again later")
+
+// resendVerifyEmail sends the authenticated user a new verification link, expiring the ones sent before.
+// Every send, including the one at sign up, starts an interval during which no other can be requested.
+func (server *Server) resendVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	username := authPayload(r).Username
+	payload := worker.PayloadSendVerifyEmail{Username: username}
+
+	user, err := tenantStore(r).ResendVerifyEmailTx(r.Context(), db.ResendVerifyEmailTxParams{
+		Username: username,
+		Resend: func(q *db.Queries, user db.User) error {
+			sent, err := worker.TaskSendVerifyEmail.CountSince(r.Context(), q, payload, time.Now().Add(-server.config.VerifyEmailResendInterval))
+			if err != nil {
+				return err
+			}
+			if sent > 0 {
+				return errVerifyEmailTooSoon
+			}
+			_, err = worker.TaskSendVerifyEmail.Enqueue(r.Context(), q, payload, worker.Priority(10))
+			return err
+		},
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errVerifyEmailTooSoon):
+			w.Header().Set("Retry-After", strconv.Itoa(int(server.config.VerifyEmailResendInterval.Seconds())))
+			writeError(w, http.StatusTooManyRequests, err)
+		case errors.Is(err, db.ErrEmailAlreadyVerified):
+			writeError(w, http.StatusConflict, err)
+		case errors.Is(err, sql.ErrNoRows):
+			writeError(w, http.StatusNotFound, errors.New("user not found"))
+		default:
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, newUserResponse(user))
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/mail"
+	"goprojects/simplebank/util"
+	"goprojects/simplebank/worker"
+)
+
+// runWorker processes jobs with p until condition holds
+func runWorker(t *testing.T, p *worker.Processor, condition func() bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- p.Start(ctx)
+	}()
+
+	require.Eventually(t, condition, 10*time.Second, 50*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestVerifyEmailOfOtherTenant(t *testing.T) {
+	// Superusers skip row-level security, which hid the code from a link resolved to the default tenant.
+	store := db.NewStore(dbtest.NewUnprivileged(t))
+	server := newTestServer(t, store)
+
+	id := strings.ToLower(util.RandomString(8))
+	tenant, err := store.CreateTenant(context.Background(), db.CreateTenantParams{ID: id, Name: "Bank " + id})
+	require.NoError(t, err)
+
+	email := util.RandomEmail()
+	request := newRequest(t, http.MethodPost, "/users", createUserRequest{
+		Username: util.RandomOwner() + util.RandomString(4),
+		Password: util.RandomString(8),
+		FullName: util.RandomOwner(),
+		Email:    email,
+	}, "")
+	request.Header.Set(tenantHeader, tenant.ID)
+	rsp := serveRequest(server, request)
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+
+	sender := mail.NewMemorySender()
+	p := worker.NewProcessor(store, worker.PollInterval(10*time.Millisecond))
+	worker.HandleSendVerifyEmail(p, store, sender, server.config.PublicBaseURL)
+	var body string
+	runWorker(t, p, func() bool {
+		for _, msg := range sender.Messages() {
+			if msg.To[0] == email {
+				body = msg.Body
+				return true
+			}
+		}
+		return false
+	})
+
+	// Following the link, which carries no tenant header, verifies the user.
+	match := regexp.MustCompile(`href="http://bank\.test(/verify_email\?[^"]+)"`).FindStringSubmatch(body)
+	require.Len(t, match, 2)
+	rsp = serve(t, server, http.MethodGet, html.UnescapeString(match[1]), nil, "")
+	require.Equal(t, http.StatusOK, rsp.Code, rsp.Body.String())
+
+	var verified verifyEmailResponse
+	require.NoError(t, json.Unmarshal(rsp.Body.Bytes(), &verified))
+	require.True(t, verified.IsVerified)
+}
+
+func TestResendVerifyEmail(t *testing.T) {
+	server := newTestServer(t, db.NewStore(testDB))
+	login := signUp(t, server)
+	username := login.User.Username
+	ctx := context.Background()
+
+	rsp := serve(t, server, http.MethodPost, "/verify_email/resend", nil, "")
+	require.Equal(t, http.StatusUnauthorized, rsp.Code, rsp.Body.String())
+
+	// The email enqueued at sign up starts the interval.
+	server.config.VerifyEmailResendInterval = time.Hour
+	rsp = serve(t, server, http.MethodPost, "/verify_email/resend", nil, login.AccessToken)
+	require.Equal(t, http.StatusTooManyRequests, rsp.Code, rsp.Body.String())
+	require.Equal(t, "3600", rsp.Header().Get("Retry-After"))
+
+	old, err := server.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+		Username:   username,
+		Email:      login.User.Email,
+		SecretCode: util.RandomString(32),
+	})
+	require.NoError(t, err)
+
+	server.config.VerifyEmailResendInterval = 0
+	rsp = serve(t, server, http.MethodPost, "/verify_email/resend", nil, login.AccessToken)
+	require.Equal(t, http.StatusAccepted, rsp.Code, rsp.Body.String())
+
+	sent, err := server.store.CountJobsSince(ctx, db.CountJobsSinceParams{
+		Type:    worker.TaskSendVerifyEmail.Name,
+		Payload: json.RawMessage(`{"username":"` + username + `"}`),
+		Since:   time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, sent)
+
+	// The new link replaces the old one.
+	rsp = serve(t, server, http.MethodGet, "/verify_email?"+url.Values{
+		"email_id":    {fmt.Sprint(old.ID)},
+		"secret_code": {old.SecretCode},
+	}.Encode(), nil, "")
+	require.Equal(t, http.StatusBadRequest, rsp.Code, rsp.Body.String())
+
+	server.config.VerifyEmailResendInterval = time.Hour
+	rsp = serve(t, server, http.MethodPost, "/verify_email/resend", nil, login.AccessToken)
+	require.Equal(t, http.StatusTooManyRequests, rsp.Code, rsp.Body.String())
+
+	_, err = server.store.SetUserEmailVerified(ctx, db.SetUserEmailVerifiedParams{Username: username, Email: login.User.Email})
+	require.NoError(t, err)
+	server.config.VerifyEmailResendInterval = 0
+	rsp = serve(t, server, http.MethodPost, "/verify_email/resend", nil, login.AccessToken)
+	require.Equal(t, http.StatusConflict, rsp.Code, rsp.Body.String())
+}
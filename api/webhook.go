@@ -0,0 +1,251 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/util"
+	"goprojects/simplebank/webhook"
+	"goprojects/simplebank/worker"
+)
+
+// webhookSubscriptionResponse is a subscription without its secret, which is only returned once when it is created
+type webhookSubscriptionResponse struct {
+	ID           int64      `json:"id"`
+	Url          string     `json:"url"`
+	EventTypes   []string   `json:"event_types"`
+	FailureCount int32      `json:"failure_count"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func newWebhookSubscriptionResponse(subscription db.WebhookSubscription) webhookSubscriptionResponse {
+	rsp := webhookSubscriptionResponse{
+		ID:           subscription.ID,
+		Url:          subscription.Url,
+		EventTypes:   subscription.EventTypes,
+		FailureCount: subscription.FailureCount,
+		CreatedAt:    subscription.CreatedAt,
+	}
+	if subscription.DisabledAt.Valid {
+		rsp.DisabledAt = &subscription.DisabledAt.Time
+	}
+	return rsp
+}
+
+type createWebhookSubscriptionRequest struct {
+	Url string `json:"url"`
+	//every event type when empty
+	EventTypes []string `json:"event_types"`
+}
+
+type createWebhookSubscriptionResponse struct {
+	webhookSubscriptionResponse
+	//signs every request sent to the subscription; it cannot be read again
+	Secret string `json:"secret"`
+}
+
+// createWebhookSubscription subscribes the caller to events of their accounts
+func (server *Server) createWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	var req createWebhookSubscriptionRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	target, err := webhook.CheckURL(r.Context(), req.Url, server.config.WebhookRequireHTTPS)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.EventTypes) == 0 {
+		req.EventTypes = db.EventTypes
+	}
+	for _, eventType := range req.EventTypes {
+		if !slices.Contains(db.EventTypes, eventType) {
+			writeError(w, http.StatusBadRequest, errors.New("unknown event type "+eventType))
+			return
+		}
+	}
+	eventTypes := slices.Clone(req.EventTypes)
+	slices.Sort(eventTypes)
+
+	secret, err := util.RandomSecret(32)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	subscription, err := tenantStore(r).CreateWebhookSubscription(r.Context(), db.CreateWebhookSubscriptionParams{
+		Owner:      authPayload(r).Username,
+		Url:        target.String(),
+		EventTypes: slices.Compact(eventTypes),
+		Secret:     secret,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, createWebhookSubscriptionResponse{
+		webhookSubscriptionResponse: newWebhookSubscriptionResponse(subscription),
+		Secret:                      subscription.Secret,
+	})
+}
+
+func (server *Server) listWebhookSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := tenantStore(r).ListWebhookSubscriptions(r.Context(), authPayload(r).Username)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rsp := make([]webhookSubscriptionResponse, len(subscriptions))
+	for i, subscription := range subscriptions {
+		rsp[i] = newWebhookSubscriptionResponse(subscription)
+	}
+	writeJSON(w, http.StatusOK, rsp)
+}
+
+// deleteWebhookSubscription removes one of the caller's subscriptions along with its deliveries
+func (server *Server) deleteWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := readID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	deleted, err := tenantStore(r).DeleteWebhookSubscription(r.Context(), db.DeleteWebhookSubscriptionParams{
+		ID:    id,
+		Owner: authPayload(r).Username,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if deleted == 0 {
+		writeError(w, http.StatusNotFound, errors.New("webhook subscription not found"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// enableWebhookSubscription turns a subscription disabled by repeated failures back on
+func (server *Server) enableWebhookSubscription(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := server.ownWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	subscription, err := tenantStore(r).EnableWebhookSubscription(r.Context(), subscription.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newWebhookSubscriptionResponse(subscription))
+}
+
+// listWebhookDeliveries returns a subscription's delivery log, newest first
+func (server *Server) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	page, err := readPage(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	subscription, ok := server.ownWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := tenantStore(r).ListWebhookDeliveries(r.Context(), db.ListWebhookDeliveriesParams{
+		SubscriptionID: subscription.ID,
+		Limit:          page.Limit,
+		Offset:         page.Offset,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// replayWebhookDelivery sends the event of a past delivery again as a new delivery with the same event ID
+func (server *Server) replayWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	deliveryID, err := readID(r, "delivery_id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	subscription, ok := server.ownWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+	if subscription.DisabledAt.Valid {
+		writeError(w, http.StatusConflict, errors.New("webhook subscription is disabled"))
+		return
+	}
+
+	store := tenantStore(r)
+	delivery, err := store.GetWebhookDelivery(r.Context(), deliveryID)
+	if err == nil && delivery.SubscriptionID != subscription.ID {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, errors.New("webhook delivery not found"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var replay db.WebhookDelivery
+	err = store.ExecTx(r.Context(), func(q *db.Queries) error {
+		var err error
+		replay, err = worker.EnqueueWebhookDelivery(r.Context(), q, db.CreateWebhookDeliveryParams{
+			SubscriptionID: delivery.SubscriptionID,
+			EventID:        delivery.EventID,
+			EventType:      delivery.EventType,
+			AccountID:      delivery.AccountID,
+			Payload:        delivery.Payload,
+			ReplayOf:       sql.NullInt64{Int64: delivery.ID, Valid: true},
+		})
+		return err
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, replay)
+}
+
+// ownWebhookSubscription reads the subscription named by the id path parameter, writing a 404
+// when it does not exist or belongs to someone else so other users' subscription IDs cannot be probed
+func (server *Server) ownWebhookSubscription(w http.ResponseWriter, r *http.Request) (db.WebhookSubscription, bool) {
+	id, err := readID(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return db.WebhookSubscription{}, false
+	}
+
+	subscription, err := tenantStore(r).GetWebhookSubscription(r.Context(), id)
+	if err == nil && subscription.Owner != authPayload(r).Username {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, errors.New("webhook subscription not found"))
+			return subscription, false
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return subscription, false
+	}
+	return subscription, true
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+func TestCreateWebhookSubscriptionChecksURL(t *testing.T) {
+	server := newTestServer(t, db.NewStore(testDB))
+	login := signUp(t, server)
+
+	testCases := []struct {
+		name   string
+		url    string
+		status int
+	}{
+		{"Public", "https://93.184.216.34/hooks", http.StatusOK},
+		{"PlainHTTP", "http://93.184.216.34/hooks", http.StatusBadRequest},
+		{"Loopback", "https://127.0.0.1:8080/hooks", http.StatusBadRequest},
+		{"Localhost", "https://localhost/hooks", http.StatusBadRequest},
+		{"Private", "https://10.1.2.3/hooks", http.StatusBadRequest},
+		{"Metadata", "https://169.254.169.254/latest/meta-data", http.StatusBadRequest},
+		{"Unspecified", "https://[::]/hooks", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rsp := serve(t, server, http.MethodPost, "/webhooks", createWebhookSubscriptionRequest{Url: tc.url}, login.AccessToken)
+			require.Equal(t, tc.status, rsp.Code, rsp.Body.String())
+		})
+	}
+}
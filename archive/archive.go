@@ -0,0 +1,282 @@
+// Package archive moves closed months of the ledger out of Postgres into a blob store.
+//
+// Each month of a partitioned table becomes one object: its rows as JSON lines in ID order, gzip-compressed,
+// under <table>/<year>/<month>.jsonl.gz. The object's SHA-256, row count and amount total are recorded in
+// archived_periods, and the partition is only dropped once the stored object has been read back and matched all three.
+// Reader lets the store read archived entries back, see db.WithArchive.
+package archive
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+var (
+	// ErrMonthOpen is returned when archiving a month whose last business day is not closed yet
+	ErrMonthOpen = errors.New("month is not closed yet")
+	// ErrCorrupt is returned when a stored object does not match what was recorded when it was archived
+	ErrCorrupt = errors.New("archived object is corrupt")
+)
+
+// ObjectKey returns the key a month of table is stored under
+func ObjectKey(table string, month time.Time) string {
+	return fmt.Sprintf("%s/%s.jsonl.gz", table, month.UTC().Format("2006/01"))
+}
+
+// summary is what an object holds, compared between the export, the stored object and the partition
+type summary struct {
+	rows   int64
+	amount int64
+	sha256 string
+}
+
+// write encodes every row each yields to w and summarizes what was written
+func write[T any](w io.Writer, each func(fn func(T) error) error, amount func(T) int64) (summary, error) {
+	var s summary
+	hash := sha256.New()
+	zw := gzip.NewWriter(io.MultiWriter(w, hash))
+	enc := json.NewEncoder(zw)
+
+	err := each(func(row T) error {
+		s.rows++
+		s.amount += amount(row)
+		return enc.Encode(row)
+	})
+	if err != nil {
+		return s, err
+	}
+	if err := zw.Close(); err != nil {
+		return s, err
+	}
+	s.sha256 = hex.EncodeToString(hash.Sum(nil))
+	return s, nil
+}
+
+// scan decodes the object stored under key, calling fn with each row, and summarizes what it read
+func scan[T any](ctx context.Context, blobs BlobStore, key string, amount func(T) int64, fn func(T) error) (summary, error) {
+	var s summary
+	r, err := blobs.Open(ctx, key)
+	if err != nil {
+		return s, err
+	}
+	defer r.Close()
+
+	hash := sha256.New()
+	tee := io.TeeReader(r, hash)
+	zr, err := gzip.NewReader(tee)
+	if err != nil {
+		return s, fmt.Errorf("%w: %s: %v", ErrCorrupt, key, err)
+	}
+	dec := json.NewDecoder(zr)
+	for {
+		var row T
+		err := dec.Decode(&row)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return s, fmt.Errorf("%w: %s: %v", ErrCorrupt, key, err)
+		}
+		s.rows++
+		s.amount += amount(row)
+		if err := fn(row); err != nil {
+			return s, err
+		}
+	}
+	//the hash must cover the whole object, including anything after the compressed stream
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return s, err
+	}
+	s.sha256 = hex.EncodeToString(hash.Sum(nil))
+	return s, nil
+}
+
+func entryAmount(entry db.Entry) int64 {
+	return entry.Amount
+}
+
+func transferAmount(transfer db.Transfer) int64 {
+	return transfer.Amount
+}
+
+// Reader reads archived entries back from a blob store; it implements db.ArchiveReader
+type Reader struct {
+	blobs BlobStore
+}
+
+// NewReader returns a Reader of the objects in blobs
+func NewReader(blobs BlobStore) *Reader {
+	return &Reader{blobs: blobs}
+}
+
+// EachEntry calls fn with every entry of an archived month in ID order.
+// The checksum can only be verified once the whole object is read, so a corrupted object
+// returns ErrCorrupt after fn has already seen its rows; callers must discard what they collected.
+func (reader *Reader) EachEntry(ctx context.Context, period db.ArchivedPeriod, fn func(db.Entry) error) error {
+	s, err := scan(ctx, reader.blobs, period.ObjectKey, entryAmount, fn)
+	if err != nil {
+		return err
+	}
+	return check(period.ObjectKey, s, summary{rows: period.RowCount, amount: period.AmountTotal, sha256: period.Sha256})
+}
+
+func check(key string, got summary, want summary) error {
+	if got != want {
+		return fmt.Errorf("%w: %s holds %d rows totalling %d with SHA-256 %s, expected %d totalling %d with SHA-256 %s",
+			ErrCorrupt, key, got.rows, got.amount, got.sha256, want.rows, want.amount, want.sha256)
+	}
+	return nil
+}
+
+// Archiver exports closed months of the partitioned tables to a blob store and drops them from Postgres
+type Archiver struct {
+	store *db.Store
+	blobs BlobStore
+}
+
+// NewArchiver returns an Archiver moving months from store to blobs
+func NewArchiver(store *db.Store, blobs BlobStore) *Archiver {
+	return &Archiver{store: store, blobs: blobs}
+}
+
+// ArchiveMonth archives the month containing month in every partitioned table and returns the periods it archived.
+// Tables whose month is already archived, or never had a partition, are skipped, so a failed run can simply be repeated.
+// It returns ErrMonthOpen unless the business day the month ends with is closed, since entries could still be posted into it.
+func (a *Archiver) ArchiveMonth(ctx context.Context, month time.Time) ([]db.ArchivedPeriod, error) {
+	month = db.MonthStart(month)
+	closed, err := a.store.GetLatestBusinessDay(ctx)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && month.AddDate(0, 1, 0).After(closed.ClosesAt)) {
+		return nil, fmt.Errorf("%w: %s", ErrMonthOpen, month.Format("2006-01"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest business day: %w", err)
+	}
+
+	var periods []db.ArchivedPeriod
+	for _, table := range db.PartitionedTables {
+		_, err := a.store.GetArchivedPeriod(ctx, db.GetArchivedPeriodParams{TableName: table, Month: month})
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return periods, err
+		}
+
+		partitions, err := a.store.ListPartitionTables(ctx, table)
+		if err != nil {
+			return periods, fmt.Errorf("failed to list partitions of %s: %w", table, err)
+		}
+		if !slices.Contains(partitions, db.PartitionName(table, month)) {
+			continue
+		}
+
+		period, err := a.archiveTable(ctx, table, month)
+		if err != nil {
+			return periods, fmt.Errorf("failed to archive %s: %w", db.PartitionName(table, month), err)
+		}
+		periods = append(periods, period)
+	}
+	return periods, nil
+}
+
+func (a *Archiver) archiveTable(ctx context.Context, table string, month time.Time) (db.ArchivedPeriod, error) {
+	key := ObjectKey(table, month)
+
+	var stored, verified summary
+	var err error
+	switch table {
+	case "entries":
+		stored, err = put(ctx, a.blobs, key, func(fn func(db.Entry) error) error {
+			return a.store.EachPartitionEntry(ctx, month, fn)
+		}, entryAmount)
+		if err == nil {
+			verified, err = scan(ctx, a.blobs, key, entryAmount, func(db.Entry) error { return nil })
+		}
+	case "transfers":
+		stored, err = put(ctx, a.blobs, key, func(fn func(db.Transfer) error) error {
+			return a.store.EachPartitionTransfer(ctx, month, fn)
+		}, transferAmount)
+		if err == nil {
+			verified, err = scan(ctx, a.blobs, key, transferAmount, func(db.Transfer) error { return nil })
+		}
+	default:
+		return db.ArchivedPeriod{}, fmt.Errorf("no archive format for %s", table)
+	}
+	if err != nil {
+		return db.ArchivedPeriod{}, err
+	}
+	if err := check(key, verified, stored); err != nil {
+		return db.ArchivedPeriod{}, err
+	}
+
+	return a.store.CompleteArchiveTx(ctx, db.CompleteArchiveTxParams{
+		TableName:   table,
+		Month:       month,
+		ObjectKey:   key,
+		RowCount:    stored.rows,
+		AmountTotal: stored.amount,
+		Sha256:      stored.sha256,
+	})
+}
+
+// put streams the rows each yields into the object stored under key
+func put[T any](ctx context.Context, blobs BlobStore, key string, each func(fn func(T) error) error, amount func(T) int64) (summary, error) {
+	pr, pw := io.Pipe()
+	done := make(chan summary, 1)
+	go func() {
+		s, err := write(pw, each, amount)
+		pw.CloseWithError(err)
+		done <- s
+	}()
+
+	err := blobs.Put(ctx, key, pr)
+	//unblocks the writer when Put gave up before reading everything
+	pr.CloseWithError(errors.New("blob store stopped reading"))
+	s := <-done
+	return s, err
+}
+
+// ArchiveDue archives every month that started at least afterMonths months before the one containing now,
+// oldest first, stopping at the first month that is not closed yet. It returns the periods it archived.
+func (a *Archiver) ArchiveDue(ctx context.Context, now time.Time, afterMonths int) ([]db.ArchivedPeriod, error) {
+	cutoff := db.MonthStart(now).AddDate(0, -afterMonths, 0)
+
+	var months []time.Time
+	for _, table := range db.PartitionedTables {
+		partitions, err := a.store.ListPartitionTables(ctx, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list partitions of %s: %w", table, err)
+		}
+		for _, name := range partitions {
+			month, ok := db.PartitionMonth(table, name)
+			if ok && month.Before(cutoff) && !slices.ContainsFunc(months, month.Equal) {
+				months = append(months, month)
+			}
+		}
+	}
+	slices.SortFunc(months, time.Time.Compare)
+
+	var archived []db.ArchivedPeriod
+	for _, month := range months {
+		periods, err := a.ArchiveMonth(ctx, month)
+		archived = append(archived, periods...)
+		if errors.Is(err, ErrMonthOpen) {
+			break
+		}
+		if err != nil {
+			return archived, err
+		}
+	}
+	return archived, nil
+}
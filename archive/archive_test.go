@@ -0,0 +1,181 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/util"
+)
+
+func TestObjectRoundTrip(t *testing.T) {
+	blobs, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	at := time.Date(2024, time.January, 15, 10, 30, 0, 123456000, time.UTC)
+	entries := []db.Entry{
+		{ID: 1, AccountID: 7, Amount: 100, CreatedAt: at},
+		{ID: 2, AccountID: 8, Amount: -40, CreatedAt: at.Add(time.Hour)},
+	}
+	each := func(fn func(db.Entry) error) error {
+		for _, entry := range entries {
+			if err := fn(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	stored, err := put(ctx, blobs, ObjectKey("entries", at), each, entryAmount)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), stored.rows)
+	require.Equal(t, int64(60), stored.amount)
+	require.Len(t, stored.sha256, 64)
+
+	period := db.ArchivedPeriod{
+		TableName:   "entries",
+		ObjectKey:   "entries/2024/01.jsonl.gz",
+		RowCount:    stored.rows,
+		AmountTotal: stored.amount,
+		Sha256:      stored.sha256,
+	}
+	var read []db.Entry
+	err = NewReader(blobs).EachEntry(ctx, period, func(entry db.Entry) error {
+		read = append(read, entry)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, read, len(entries))
+	for i := range entries {
+		require.Equal(t, entries[i].ID, read[i].ID)
+		require.Equal(t, entries[i].AccountID, read[i].AccountID)
+		require.Equal(t, entries[i].Amount, read[i].Amount)
+		require.True(t, entries[i].CreatedAt.Equal(read[i].CreatedAt))
+	}
+
+	// Any difference from what was recorded is reported.
+	period.Sha256 = stored.sha256[1:] + "0"
+	err = NewReader(blobs).EachEntry(ctx, period, func(db.Entry) error { return nil })
+	require.ErrorIs(t, err, ErrCorrupt)
+}
+
+func TestObjectCorrupt(t *testing.T) {
+	blobs, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, blobs.Put(ctx, "entries/2024/01.jsonl.gz", bytes.NewReader([]byte("not gzip"))))
+	_, err = scan(ctx, blobs, "entries/2024/01.jsonl.gz", entryAmount, func(db.Entry) error { return nil })
+	require.ErrorIs(t, err, ErrCorrupt)
+}
+
+func TestArchiveMonth(t *testing.T) {
+	conn := dbtest.New(t)
+	dir := t.TempDir()
+	blobs, err := NewLocalStore(dir)
+	require.NoError(t, err)
+	store := db.NewStore(conn, db.WithArchive(NewReader(blobs)))
+	archiver := NewArchiver(store, blobs)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	old := db.MonthStart(now).AddDate(0, -3, 0)
+	for _, table := range db.PartitionedTables {
+		_, err := store.CreateMonthlyPartition(ctx, db.CreateMonthlyPartitionParams{Parent: table, Month: old})
+		require.NoError(t, err)
+	}
+
+	// The account was opened before the old month and received 100 then paid 30 during it.
+	account, err := store.CreateAccount(ctx, db.CreateAccountParams{Owner: util.RandomOwner(), Currency: util.USD})
+	require.NoError(t, err)
+	other, err := store.CreateAccount(ctx, db.CreateAccountParams{Owner: util.RandomOwner(), Currency: util.USD})
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "UPDATE accounts SET created_at = $1 WHERE id IN ($2, $3)", old.AddDate(0, 0, -2), account.ID, other.ID)
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, `INSERT INTO transfers (from_account_id, to_account_id, amount, created_at) VALUES ($1, $2, 30, $3)`,
+		account.ID, other.ID, old.AddDate(0, 0, 10))
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, `INSERT INTO entries (account_id, amount, created_at) VALUES ($1, 100, $3), ($1, -30, $4), ($2, 30, $4)`,
+		account.ID, other.ID, old.AddDate(0, 0, 1), old.AddDate(0, 0, 10))
+	require.NoError(t, err)
+	_, err = store.AddAccountBalance(ctx, db.AddAccountBalanceParams{ID: account.ID, Amount: 70})
+	require.NoError(t, err)
+	_, err = store.AddAccountBalance(ctx, db.AddAccountBalanceParams{ID: other.ID, Amount: 30})
+	require.NoError(t, err)
+
+	statementParams := db.GetStatementParams{AccountID: account.ID, Since: old, Until: old.AddDate(0, 1, 0)}
+	before, err := store.GetStatement(ctx, statementParams)
+	require.NoError(t, err)
+	require.Len(t, before.Entries, 2)
+
+	// Nothing is archived while the month could still be posted into.
+	_, err = archiver.ArchiveMonth(ctx, old)
+	require.ErrorIs(t, err, ErrMonthOpen)
+
+	_, err = store.CloseBusinessDayTx(ctx, db.CloseBusinessDayTxParams{
+		Date:     db.BusinessDate(now, time.UTC).AddDate(0, 0, -1),
+		Location: time.UTC,
+		Actor:    "test",
+	})
+	require.NoError(t, err)
+
+	periods, err := archiver.ArchiveMonth(ctx, old.AddDate(0, 0, 12))
+	require.NoError(t, err)
+	require.Len(t, periods, 2)
+	for _, period := range periods {
+		_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(period.ObjectKey)))
+		require.NoError(t, err)
+
+		partitions, err := store.ListPartitionTables(ctx, period.TableName)
+		require.NoError(t, err)
+		require.False(t, slices.Contains(partitions, db.PartitionName(period.TableName, old)))
+	}
+	require.Equal(t, int64(3), periods[0].RowCount)
+	require.Equal(t, int64(100), periods[0].AmountTotal)
+
+	// A repeated run has nothing left to do.
+	periods, err = archiver.ArchiveMonth(ctx, old)
+	require.NoError(t, err)
+	require.Empty(t, periods)
+
+	// Statements and balances read the archived month transparently.
+	after, err := store.GetStatement(ctx, statementParams)
+	require.NoError(t, err)
+	require.Equal(t, before.OpeningBalance, after.OpeningBalance)
+	require.Equal(t, before.ClosingBalance, after.ClosingBalance)
+	require.Len(t, after.Entries, 2)
+	for i := range before.Entries {
+		require.Equal(t, before.Entries[i].ID, after.Entries[i].ID)
+		require.Equal(t, before.Entries[i].Amount, after.Entries[i].Amount)
+	}
+
+	balance, err := store.GetBalanceAt(ctx, account.ID, old.AddDate(0, 0, 5))
+	require.NoError(t, err)
+	require.Equal(t, int64(100), balance.Balance)
+	balance, err = store.GetBalanceAt(ctx, account.ID, old.AddDate(0, 0, -1))
+	require.NoError(t, err)
+	require.Zero(t, balance.Balance)
+
+	mismatches, err := store.ListBalanceMismatches(ctx)
+	require.NoError(t, err)
+	require.Empty(t, mismatches)
+
+	// Without the archive the statement cannot be built, rather than silently missing entries.
+	_, err = db.NewStore(conn).GetStatement(ctx, statementParams)
+	require.ErrorIs(t, err, db.ErrArchiveUnavailable)
+
+	// Nor once the archived object is damaged.
+	key := filepath.Join(dir, "entries", old.Format("2006"), old.Format("01")+".jsonl.gz")
+	require.NoError(t, os.WriteFile(key, []byte("damaged"), 0o600))
+	_, err = store.GetStatement(ctx, statementParams)
+	require.ErrorIs(t, err, ErrCorrupt)
+}
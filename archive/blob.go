@@ -0,0 +1,93 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrObjectNotFound is returned when opening an object that was never stored
+var ErrObjectNotFound = errors.New("object not found")
+
+// BlobStore keeps archived objects; keys are slash-separated paths such as entries/2024/01.jsonl.gz
+type BlobStore interface {
+	// Put stores everything read from r under key, replacing any object stored there before.
+	// The object must be complete or absent once Put returns, never partly written.
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open returns a reader of the object stored under key, or ErrObjectNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// LocalStore is a BlobStore keeping objects as files under a directory
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore returns a LocalStore rooted at dir, creating the directory if needed
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("cannot create archive directory: %w", err)
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+// path returns the file of key, refusing keys that would leave the directory
+func (s *LocalStore) path(key string) (string, error) {
+	name := filepath.FromSlash(key)
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.dir, name), nil
+}
+
+// Put writes the object to a temporary file next to its final name and renames it once it is synced
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader) (err error) {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".put-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func (s *LocalStore) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return f, err
+}
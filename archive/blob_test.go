@@ -0,0 +1,61 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	dir := t.TempDir()
+	blobs, err := NewLocalStore(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, blobs.Put(ctx, "entries/2024/01.jsonl.gz", strings.NewReader("first")))
+	require.NoError(t, blobs.Put(ctx, "entries/2024/01.jsonl.gz", strings.NewReader("second")))
+
+	r, err := blobs.Open(ctx, "entries/2024/01.jsonl.gz")
+	require.NoError(t, err)
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "second", string(content))
+
+	// No temporary file is left next to the object.
+	files, err := os.ReadDir(filepath.Join(dir, "entries", "2024"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	_, err = blobs.Open(ctx, "entries/2024/02.jsonl.gz")
+	require.ErrorIs(t, err, ErrObjectNotFound)
+
+	for _, key := range []string{"../outside", "/etc/passwd", ""} {
+		require.Error(t, blobs.Put(ctx, key, strings.NewReader("x")), key)
+		_, err := blobs.Open(ctx, key)
+		require.Error(t, err, key)
+	}
+}
+
+func TestLocalStorePutFailure(t *testing.T) {
+	dir := t.TempDir()
+	blobs, err := NewLocalStore(dir)
+	require.NoError(t, err)
+
+	r, w := io.Pipe()
+	go func() {
+		w.Write([]byte("partial"))
+		w.CloseWithError(io.ErrUnexpectedEOF)
+	}()
+	require.ErrorIs(t, blobs.Put(context.Background(), "transfers/2024/01.jsonl.gz", r), io.ErrUnexpectedEOF)
+
+	// A failed put leaves neither the object nor its temporary file.
+	files, err := os.ReadDir(filepath.Join(dir, "transfers", "2024"))
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
@@ -0,0 +1,14 @@
+package archive
+
+import (
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+
+	"goprojects/simplebank/db/dbtest"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(dbtest.Main(m, nil))
+}
@@ -0,0 +1,697 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"goprojects/simplebank/archive"
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/util"
+)
+
+// parse parses the command's flags and returns its positional arguments, which must number exactly n
+func parse(flags *flag.FlagSet, args []string, n int) ([]string, error) {
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return nil, errUsage
+	}
+	if flags.NArg() != n {
+		return nil, errUsage
+	}
+	return flags.Args(), nil
+}
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid ID %q", s)
+	}
+	return id, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func accountsTable(accounts ...db.Account) table {
+	t := table{
+		headers: []string{"ID", "OWNER", "CURRENCY", "BALANCE", "FROZEN", "CREATED"},
+		value:   accounts,
+	}
+	for _, a := range accounts {
+		t.rows = append(t.rows, []string{
+			strconv.FormatInt(a.ID, 10),
+			a.Owner,
+			a.Currency,
+			strconv.FormatInt(a.Balance, 10),
+			strconv.FormatBool(a.IsFrozen),
+			formatTime(a.CreatedAt),
+		})
+	}
+	return t
+}
+
+func (c *cli) createAccount(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("account-create", flag.ContinueOnError)
+	owner := flags.String("owner", "", "owner username, left out for the bank's internal accounts")
+	currency := flags.String("currency", "", "account currency")
+	productCode := flags.String("product", db.ProductChecking, "product the account is opened as, see products")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+	if !util.IsSupportedCurrency(*currency) {
+		return fmt.Errorf("unsupported currency %q", *currency)
+	}
+	product, err := c.store.GetAccountProduct(ctx, *productCode)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("unknown product %q", *productCode)
+	}
+	if err != nil {
+		return err
+	}
+	if product.Internal && *owner != "" {
+		return fmt.Errorf("%s accounts belong to the bank and take no -owner", product.Code)
+	}
+	if !product.Internal && *owner == "" {
+		return errors.New("-owner is required")
+	}
+
+	account, err := c.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    *owner,
+		Balance:  0,
+		Currency: *currency,
+		Product:  sql.NullString{String: product.Code, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(accountsTable(account))
+}
+
+func (c *cli) listAccounts(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("account-list", flag.ContinueOnError)
+	owner := flags.String("owner", "", "only list the accounts of this owner")
+	limit := flags.Int("limit", 50, "maximum number of accounts")
+	offset := flags.Int("offset", 0, "number of accounts to skip")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	var accounts []db.Account
+	var err error
+	if *owner != "" {
+		accounts, err = c.store.ListAccountsByOwner(ctx, db.ListAccountsByOwnerParams{
+			Owner:  *owner,
+			Limit:  int32(*limit),
+			Offset: int32(*offset),
+		})
+	} else {
+		accounts, err = c.store.ListAccounts(ctx, db.ListAccountsParams{
+			Limit:  int32(*limit),
+			Offset: int32(*offset),
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.print(accountsTable(accounts...))
+}
+
+func (c *cli) showAccount(ctx context.Context, args []string) error {
+	rest, err := parse(flag.NewFlagSet("account-show", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(rest[0])
+	if err != nil {
+		return err
+	}
+
+	account, err := c.store.GetAccount(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.print(accountsTable(account))
+}
+
+func (c *cli) freezeAccount(ctx context.Context, args []string) error {
+	return c.setFrozen(ctx, "account-freeze", args, true)
+}
+
+func (c *cli) unfreezeAccount(ctx context.Context, args []string) error {
+	return c.setFrozen(ctx, "account-unfreeze", args, false)
+}
+
+func (c *cli) setFrozen(ctx context.Context, name string, args []string, frozen bool) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	reason := flags.String("reason", "", "why the account is frozen or unfrozen, recorded in the audit log")
+	rest, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(rest[0])
+	if err != nil {
+		return err
+	}
+	if *reason == "" {
+		return errors.New("-reason is required")
+	}
+
+	account, err := c.store.SetAccountFrozenTx(ctx, db.SetAccountFrozenTxParams{
+		AccountID: id,
+		Frozen:    frozen,
+		Actor:     c.actor,
+		Reason:    *reason,
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(accountsTable(account))
+}
+
+func (c *cli) listEntries(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("entries", flag.ContinueOnError)
+	limit := flags.Int("limit", 50, "maximum number of entries")
+	offset := flags.Int("offset", 0, "number of entries to skip")
+	rest, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(rest[0])
+	if err != nil {
+		return err
+	}
+
+	entries, err := c.store.ListAccountEntries(ctx, db.ListAccountEntriesParams{
+		AccountID: id,
+		Limit:     int32(*limit),
+		Offset:    int32(*offset),
+	})
+	if err != nil {
+		return err
+	}
+
+	t := table{headers: []string{"ID", "ACCOUNT", "AMOUNT", "DESCRIPTION", "CREATED"}, value: entries}
+	for _, e := range entries {
+		t.rows = append(t.rows, []string{
+			strconv.FormatInt(e.ID, 10),
+			strconv.FormatInt(e.AccountID, 10),
+			strconv.FormatInt(e.Amount, 10),
+			e.Description,
+			formatTime(e.CreatedAt),
+		})
+	}
+	return c.print(t)
+}
+
+func transferTable(value any, transfers ...db.Transfer) table {
+	t := table{headers: []string{"ID", "FROM", "TO", "AMOUNT", "REFERENCE", "DESCRIPTION", "CREATED"}, value: value}
+	for _, tr := range transfers {
+		t.rows = append(t.rows, []string{
+			strconv.FormatInt(tr.ID, 10),
+			strconv.FormatInt(tr.FromAccountID, 10),
+			strconv.FormatInt(tr.ToAccountID, 10),
+			strconv.FormatInt(tr.Amount, 10),
+			tr.ExternalReference,
+			tr.Description,
+			formatTime(tr.CreatedAt),
+		})
+	}
+	return t
+}
+
+func (c *cli) transfer(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("transfer", flag.ContinueOnError)
+	from := flags.Int64("from", 0, "account to debit")
+	to := flags.Int64("to", 0, "account to credit")
+	amount := flags.Int64("amount", 0, "amount in the currency's minor unit")
+	description := flags.String("description", "", "what the transfer is for")
+	reference := flags.String("ref", "", "the caller's own ID of the transfer")
+	metadata := flags.String("metadata", "", "a JSON object stored with the transfer")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+	if *from < 1 || *to < 1 || *from == *to {
+		return errors.New("-from and -to must be two different account IDs")
+	}
+	if *amount <= 0 {
+		return errors.New("-amount must be positive")
+	}
+
+	fromAccount, err := c.store.GetAccount(ctx, *from)
+	if err != nil {
+		return fmt.Errorf("cannot get account %d: %w", *from, err)
+	}
+	toAccount, err := c.store.GetAccount(ctx, *to)
+	if err != nil {
+		return fmt.Errorf("cannot get account %d: %w", *to, err)
+	}
+	if fromAccount.Currency != toAccount.Currency {
+		return fmt.Errorf("currency mismatch: %s vs %s", fromAccount.Currency, toAccount.Currency)
+	}
+
+	result, err := c.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID:     *from,
+		ToAccountID:       *to,
+		Amount:            *amount,
+		Description:       *description,
+		ExternalReference: *reference,
+		Metadata:          json.RawMessage(*metadata),
+	})
+	if err != nil {
+		return err
+	}
+	if result.Flag != nil {
+		//on stderr so the JSON output stays parseable
+		fmt.Fprintf(os.Stderr, "transfer %d is held for review by rule %s: %s\n", result.Transfer.ID, result.Flag.Rule, result.Flag.Reason)
+	}
+	return c.print(transferTable(result, result.Transfer))
+}
+
+func (c *cli) findTransfers(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("transfer-find", flag.ContinueOnError)
+	limit := flags.Int("limit", 50, "maximum number of transfers")
+	rest, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	transfers, err := c.store.ListTransfersByReference(ctx, db.ListTransfersByReferenceParams{
+		ExternalReference: rest[0],
+		MaxTransfers:      int32(*limit),
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(transferTable(transfers, transfers...))
+}
+
+func (c *cli) reverse(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("reverse", flag.ContinueOnError)
+	reason := flags.String("reason", "", "why the transfer is reversed, recorded in the audit log")
+	overdraft := flags.Bool("allow-overdraft", false, "take the funds back even if the recipient already spent them")
+	rest, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(rest[0])
+	if err != nil {
+		return err
+	}
+	if *reason == "" {
+		return errors.New("-reason is required")
+	}
+
+	result, err := c.store.ReverseTransferTx(ctx, db.ReverseTransferTxParams{
+		TransferID:     id,
+		Actor:          c.actor,
+		Reason:         *reason,
+		AllowOverdraft: *overdraft,
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(transferTable(result, result.Original, result.Transfer))
+}
+
+func (c *cli) deposit(ctx context.Context, args []string) error {
+	return c.startMovement(ctx, "deposit", args, c.store.DepositTx)
+}
+
+func (c *cli) withdraw(ctx context.Context, args []string) error {
+	return c.startMovement(ctx, "withdraw", args, c.store.WithdrawTx)
+}
+
+func (c *cli) startMovement(ctx context.Context, name string, args []string, start func(context.Context, db.MovementTxParams) (db.MovementTxResult, error)) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	account := flags.Int64("account", 0, "customer account")
+	amount := flags.Int64("amount", 0, "amount in the currency's minor unit")
+	ref := flags.String("ref", "", "the payment network's reference")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+	if *account < 1 {
+		return errors.New("-account must be an account ID")
+	}
+	if *amount <= 0 {
+		return errors.New("-amount must be positive")
+	}
+	if *ref == "" {
+		return errors.New("-ref is required")
+	}
+
+	result, err := start(ctx, db.MovementTxParams{
+		AccountID:         *account,
+		Amount:            *amount,
+		ExternalReference: *ref,
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(movementsTable(result.Movement))
+}
+
+func (c *cli) settleMovement(ctx context.Context, args []string) error {
+	rest, err := parse(flag.NewFlagSet("movement-settle", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(rest[0])
+	if err != nil {
+		return err
+	}
+
+	result, err := c.store.SettleMovementTx(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.print(movementsTable(result.Movement))
+}
+
+func (c *cli) failMovement(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("movement-fail", flag.ContinueOnError)
+	reason := flags.String("reason", "", "why the payment network rejected the movement")
+	rest, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(rest[0])
+	if err != nil {
+		return err
+	}
+	if *reason == "" {
+		return errors.New("-reason is required")
+	}
+
+	result, err := c.store.FailMovementTx(ctx, db.FailMovementTxParams{MovementID: id, Reason: *reason})
+	if err != nil {
+		return err
+	}
+	return c.print(movementsTable(result.Movement))
+}
+
+func (c *cli) listPendingMovements(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("movement-pending", flag.ContinueOnError)
+	olderThan := flags.Duration("older-than", 0, "only list movements pending for longer than this")
+	limit := flags.Int("limit", 50, "maximum number of movements")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	movements, err := c.store.ListPendingExternalMovements(ctx, db.ListPendingExternalMovementsParams{
+		Before:   time.Now().Add(-*olderThan),
+		MaxCount: int32(*limit),
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(movementsTable(movements...))
+}
+
+func movementsTable(movements ...db.ExternalMovement) table {
+	t := table{headers: []string{"ID", "KIND", "ACCOUNT", "AMOUNT", "CURRENCY", "REFERENCE", "STATUS", "CREATED"}, value: movements}
+	for _, m := range movements {
+		t.rows = append(t.rows, []string{
+			strconv.FormatInt(m.ID, 10),
+			m.Kind,
+			strconv.FormatInt(m.AccountID, 10),
+			strconv.FormatInt(m.Amount, 10),
+			m.Currency,
+			m.ExternalReference,
+			m.Status,
+			formatTime(m.CreatedAt),
+		})
+	}
+	return t
+}
+
+func (c *cli) listFlags(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("flag-list", flag.ContinueOnError)
+	status := flags.String("status", db.FlagStatusPending, "pending, cleared or rejected")
+	limit := flags.Int("limit", 50, "maximum number of flagged transfers")
+	offset := flags.Int("offset", 0, "number of flagged transfers to skip")
+	if _, err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	flagged, err := c.store.ListFlaggedTransfers(ctx, db.ListFlaggedTransfersParams{
+		Status: *status,
+		Limit:  int32(*limit),
+		Offset: int32(*offset),
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(flagsTable(flagged, flagged...))
+}
+
+func flagsTable(value any, flagged ...db.FlaggedTransfer) table {
+	t := table{headers: []string{"ID", "TRANSFER", "RULE", "REASON", "STATUS", "REVIEWED BY", "CREATED"}, value: value}
+	for _, f := range flagged {
+		t.rows = append(t.rows, []string{
+			strconv.FormatInt(f.ID, 10),
+			strconv.FormatInt(f.TransferID, 10),
+			f.Rule,
+			f.Reason,
+			f.Status,
+			f.ReviewedBy.String,
+			formatTime(f.CreatedAt),
+		})
+	}
+	return t
+}
+
+func (c *cli) clearFlag(ctx context.Context, args []string) error {
+	return c.reviewFlag(ctx, "flag-clear", args, c.store.ClearFlaggedTransferTx)
+}
+
+func (c *cli) rejectFlag(ctx context.Context, args []string) error {
+	return c.reviewFlag(ctx, "flag-reject", args, c.store.RejectFlaggedTransferTx)
+}
+
+func (c *cli) reviewFlag(
+	ctx context.Context,
+	name string,
+	args []string,
+	resolve func(ctx context.Context, arg db.ReviewFlaggedTransferParams) (db.ReviewFlaggedTransferResult, error),
+) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	note := flags.String("note", "", "why the transfer was cleared or rejected, kept with the flag")
+	rest, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	id, err := parseID(rest[0])
+	if err != nil {
+		return err
+	}
+
+	result, err := resolve(ctx, db.ReviewFlaggedTransferParams{
+		FlagID:     id,
+		ReviewedBy: c.actor,
+		Note:       *note,
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(flagsTable(result, result.Flag))
+}
+
+func (c *cli) reconcile(ctx context.Context, args []string) error {
+	if _, err := parse(flag.NewFlagSet("reconcile", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	mismatches, err := c.store.ListBalanceMismatches(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{
+		headers: []string{"ACCOUNT", "OWNER", "CURRENCY", "BALANCE", "ENTRIES", "DIFFERENCE"},
+		value:   mismatches,
+	}
+	for _, m := range mismatches {
+		t.rows = append(t.rows, []string{
+			strconv.FormatInt(m.ID, 10),
+			m.Owner,
+			m.Currency,
+			strconv.FormatInt(m.Balance, 10),
+			strconv.FormatInt(m.EntriesTotal, 10),
+			strconv.FormatInt(m.Balance-m.EntriesTotal, 10),
+		})
+	}
+	if err := c.print(t); err != nil {
+		return err
+	}
+
+	//a non-zero exit status lets scripts alert on an inconsistent ledger
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d accounts do not match their entries", len(mismatches))
+	}
+	return nil
+}
+
+func (c *cli) totals(ctx context.Context, args []string) error {
+	if _, err := parse(flag.NewFlagSet("totals", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	totals, err := c.store.ListCurrencyTotals(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{headers: []string{"CURRENCY", "ACCOUNTS", "TOTAL BALANCE"}, value: totals}
+	for _, total := range totals {
+		t.rows = append(t.rows, []string{
+			total.Currency,
+			strconv.FormatInt(total.AccountCount, 10),
+			strconv.FormatInt(total.TotalBalance, 10),
+		})
+	}
+	return c.print(t)
+}
+
+func (c *cli) products(ctx context.Context, args []string) error {
+	if _, err := parse(flag.NewFlagSet("products", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	products, err := c.store.ListAccountProducts(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{headers: []string{"CODE", "NAME", "CATEGORY", "NORMAL", "INTERNAL", "MIN BALANCE", "WITHDRAWALS/MONTH"}, value: products}
+	for _, product := range products {
+		minBalance, withdrawals := "-", "-"
+		if product.MinBalance.Valid {
+			minBalance = strconv.FormatInt(product.MinBalance.Int64, 10)
+		}
+		if product.MonthlyWithdrawalLimit.Valid {
+			withdrawals = strconv.Itoa(int(product.MonthlyWithdrawalLimit.Int32))
+		}
+		t.rows = append(t.rows, []string{
+			product.Code,
+			product.Name,
+			product.Category,
+			product.NormalBalance,
+			strconv.FormatBool(product.Internal),
+			minBalance,
+			withdrawals,
+		})
+	}
+	return c.print(t)
+}
+
+// trialBalance prints what each product holds per currency, stated on its normal side
+func (c *cli) trialBalance(ctx context.Context, args []string) error {
+	if _, err := parse(flag.NewFlagSet("trial-balance", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	totals, err := c.store.ListProductTotals(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{headers: []string{"CATEGORY", "PRODUCT", "CURRENCY", "ACCOUNTS", "DEBIT", "CREDIT"}, value: totals}
+	for _, total := range totals {
+		var debit, credit string
+		if total.TotalBalance < 0 {
+			debit = strconv.FormatInt(-total.TotalBalance, 10)
+		} else {
+			credit = strconv.FormatInt(total.TotalBalance, 10)
+		}
+		t.rows = append(t.rows, []string{
+			total.Category,
+			total.Code,
+			total.Currency,
+			strconv.FormatInt(total.AccountCount, 10),
+			debit,
+			credit,
+		})
+	}
+	return c.print(t)
+}
+
+func (c *cli) archive(ctx context.Context, args []string) error {
+	rest, err := parse(flag.NewFlagSet("archive", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	month, err := time.Parse("2006-01", rest[0])
+	if err != nil {
+		return fmt.Errorf("invalid month %q, expected YYYY-MM", rest[0])
+	}
+
+	periods, err := archive.NewArchiver(c.store, c.blobs).ArchiveMonth(ctx, month)
+	if err != nil {
+		return err
+	}
+
+	t := table{headers: []string{"TABLE", "MONTH", "ROWS", "AMOUNT TOTAL", "OBJECT", "SHA256"}, value: periods}
+	for _, p := range periods {
+		t.rows = append(t.rows, []string{
+			p.TableName,
+			p.Month.Format("2006-01"),
+			strconv.FormatInt(p.RowCount, 10),
+			strconv.FormatInt(p.AmountTotal, 10),
+			p.ObjectKey,
+			p.Sha256,
+		})
+	}
+	return c.print(t)
+}
+
+func (c *cli) createTenant(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("tenant-create", flag.ContinueOnError)
+	name := flags.String("name", "", "display name of the bank")
+	host := flags.String("host", "", "host the tenant's customers reach the API on")
+	rest, err := parse(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("-name is required")
+	}
+
+	tenant, err := c.store.CreateTenant(ctx, db.CreateTenantParams{
+		ID:   rest[0],
+		Name: *name,
+		Host: sql.NullString{String: *host, Valid: *host != ""},
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(tenantsTable(tenant))
+}
+
+func (c *cli) listTenants(ctx context.Context, args []string) error {
+	if _, err := parse(flag.NewFlagSet("tenant-list", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	tenants, err := c.store.ListTenants(ctx)
+	if err != nil {
+		return err
+	}
+	return c.print(tenantsTable(tenants...))
+}
+
+func tenantsTable(tenants ...db.Tenant) table {
+	t := table{headers: []string{"ID", "NAME", "HOST", "CREATED"}, value: tenants}
+	for _, tenant := range tenants {
+		t.rows = append(t.rows, []string{tenant.ID, tenant.Name, tenant.Host.String, formatTime(tenant.CreatedAt)})
+	}
+	return t
+}
@@ -0,0 +1,182 @@
+// Command bankctl lets operators inspect and correct the ledger without psql.
+//
+//	bankctl [-output table|json] [-tenant TENANT] <command> [arguments]
+//
+// It connects to the database configured by DB_DRIVER and DB_SOURCE, like the server.
+// Without -tenant it sees every tenant, as the workers do; with it, it only sees and creates that tenant's rows.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"os/user"
+	"sort"
+
+	_ "github.com/lib/pq"
+
+	"goprojects/simplebank/archive"
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/logging"
+	"goprojects/simplebank/risk"
+	"goprojects/simplebank/util"
+	"goprojects/simplebank/worker"
+)
+
+// errUsage makes main print the usage instead of an error message
+var errUsage = errors.New("usage")
+
+// cli runs one command against the store
+type cli struct {
+	store *db.Store
+	//where archived months are stored
+	blobs archive.BlobStore
+	print printer
+	//actor is recorded in the audit log for every change made through bankctl
+	actor string
+}
+
+type command struct {
+	usage string
+	run   func(c *cli, ctx context.Context, args []string) error
+}
+
+var commands = map[string]command{
+	"account-create":   {"account-create [-owner OWNER] -currency CURRENCY [-product PRODUCT]", (*cli).createAccount},
+	"account-list":     {"account-list [-owner OWNER] [-limit N] [-offset N]", (*cli).listAccounts},
+	"account-show":     {"account-show ACCOUNT_ID", (*cli).showAccount},
+	"account-freeze":   {"account-freeze -reason REASON ACCOUNT_ID", (*cli).freezeAccount},
+	"account-unfreeze": {"account-unfreeze -reason REASON ACCOUNT_ID", (*cli).unfreezeAccount},
+	"archive":          {"archive YYYY-MM", (*cli).archive},
+	"deposit":          {"deposit -account ACCOUNT_ID -amount AMOUNT -ref REFERENCE", (*cli).deposit},
+	"entries":          {"entries [-limit N] [-offset N] ACCOUNT_ID", (*cli).listEntries},
+	"flag-clear":       {"flag-clear [-note NOTE] FLAG_ID", (*cli).clearFlag},
+	"flag-list":        {"flag-list [-status STATUS] [-limit N] [-offset N]", (*cli).listFlags},
+	"flag-reject":      {"flag-reject [-note NOTE] FLAG_ID", (*cli).rejectFlag},
+	"movement-fail":    {"movement-fail -reason REASON MOVEMENT_ID", (*cli).failMovement},
+	"movement-pending": {"movement-pending [-older-than DURATION] [-limit N]", (*cli).listPendingMovements},
+	"movement-settle":  {"movement-settle MOVEMENT_ID", (*cli).settleMovement},
+	"transfer":         {"transfer -from ACCOUNT_ID -to ACCOUNT_ID -amount AMOUNT [-description TEXT] [-ref REFERENCE] [-metadata JSON]", (*cli).transfer},
+	"transfer-find":    {"transfer-find [-limit N] REFERENCE", (*cli).findTransfers},
+	"reverse":          {"reverse -reason REASON [-allow-overdraft] TRANSFER_ID", (*cli).reverse},
+	"products":         {"products", (*cli).products},
+	"reconcile":        {"reconcile", (*cli).reconcile},
+	"tenant-create":    {"tenant-create -name NAME [-host HOST] TENANT_ID", (*cli).createTenant},
+	"tenant-list":      {"tenant-list", (*cli).listTenants},
+	"totals":           {"totals", (*cli).totals},
+	"trial-balance":    {"trial-balance", (*cli).trialBalance},
+	"withdraw":         {"withdraw -account ACCOUNT_ID -amount AMOUNT -ref REFERENCE", (*cli).withdraw},
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if errors.Is(err, errUsage) {
+			usage(os.Stderr)
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "bankctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("bankctl", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	output := flags.String("output", formatTable, "output format: table or json")
+	tenant := flags.String("tenant", "", "only act for this tenant")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	print, err := newPrinter(*output, out)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		return errUsage
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		return errUsage
+	}
+
+	config, err := util.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %w", err)
+	}
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	if err != nil {
+		return fmt.Errorf("cannot connect to db: %w", err)
+	}
+	defer conn.Close()
+
+	blobs, err := archive.NewLocalStore(config.ArchiveDir)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	storeOpts := []db.StoreOption{
+		//the store's transfer logs would mix with the command's output
+		db.WithLogger(logging.Discard()),
+		db.WithArchive(archive.NewReader(blobs)),
+		//transfers, adjustments and movements made here reach their owners' webhooks like those made through the API
+		db.WithEventPublisher(worker.PublishWebhookEvents),
+	}
+	//transfers made here are screened like those made through the API
+	screener, err := risk.DefaultEngine()
+	if config.RiskRulesFile != "" {
+		screener, err = risk.LoadEngine(config.RiskRulesFile)
+	}
+	if err != nil {
+		return err
+	}
+	storeOpts = append(storeOpts, db.WithScreener(screener))
+
+	c := &cli{
+		store: db.NewStore(conn, storeOpts...),
+		blobs: blobs,
+		print: print,
+		actor: actor(),
+	}
+	if *tenant != "" {
+		store, release, err := c.store.ForTenant(ctx, *tenant)
+		if err != nil {
+			return err
+		}
+		defer release()
+		c.store = store
+	}
+	return cmd.run(c, ctx, flags.Args()[1:])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: bankctl [-output table|json] [-tenant TENANT] <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(w, "  "+commands[name].usage)
+	}
+}
+
+// actor names the operator running bankctl in the audit log
+func actor() string {
+	if u, err := user.Current(); err == nil {
+		return "bankctl:" + u.Username
+	}
+	return "bankctl"
+}
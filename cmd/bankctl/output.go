@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// output formats of the -output flag
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+)
+
+// table is a result rendered as columns; value is what the JSON output encodes
+type table struct {
+	headers []string
+	rows    [][]string
+	value   any
+}
+
+type printer func(t table) error
+
+func newPrinter(format string, w io.Writer) (printer, error) {
+	switch format {
+	case formatTable:
+		return func(t table) error { return printTable(w, t) }, nil
+	case formatJSON:
+		return func(t table) error { return printJSON(w, t.value) }, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func printTable(w io.Writer, t table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.headers, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func printJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+func TestPrinters(t *testing.T) {
+	account := db.Account{
+		ID:        7,
+		Owner:     "alice",
+		Balance:   1250,
+		Currency:  "USD",
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	var out bytes.Buffer
+	print, err := newPrinter(formatTable, &out)
+	require.NoError(t, err)
+	require.NoError(t, print(accountsTable(account)))
+	require.Equal(t, ""+
+		"ID  OWNER  CURRENCY  BALANCE  FROZEN  CREATED\n"+
+		"7   alice  USD       1250     false   2024-05-01T12:00:00Z\n", out.String())
+
+	out.Reset()
+	print, err = newPrinter(formatJSON, &out)
+	require.NoError(t, err)
+	require.NoError(t, print(accountsTable(account)))
+
+	var accounts []db.Account
+	require.NoError(t, json.Unmarshal(out.Bytes(), &accounts))
+	require.Equal(t, []db.Account{account}, accounts)
+
+	_, err = newPrinter("yaml", &out)
+	require.Error(t, err)
+}
+
+func TestUsage(t *testing.T) {
+	var out bytes.Buffer
+	require.ErrorIs(t, run(nil, &out), errUsage)
+	require.ErrorIs(t, run([]string{"frobnicate"}, &out), errUsage)
+	require.Error(t, run([]string{"-output", "yaml", "totals"}, &out))
+
+	var help bytes.Buffer
+	usage(&help)
+	for name := range commands {
+		require.Contains(t, help.String(), name)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+// picker chooses the accounts and amount of each synthetic transfer
+type picker struct {
+	rnd      *rand.Rand
+	accounts []seededAccount
+	//byCurrency indexes accounts by currency, since money only moves between accounts of the same currency
+	byCurrency map[string][]int
+	zipf       *rand.Zipf
+}
+
+// newPicker picks senders with a Zipf distribution of exponent skew over a random ranking of the accounts,
+// so a few hot accounts send most transfers; skew 0 picks senders uniformly
+func newPicker(rnd *rand.Rand, accounts []seededAccount, skew float64) *picker {
+	p := &picker{rnd: rnd, byCurrency: make(map[string][]int)}
+
+	//only accounts with a counterpart in their currency can send
+	all := make(map[string][]seededAccount)
+	for _, a := range accounts {
+		all[a.Currency] = append(all[a.Currency], a)
+	}
+	for _, group := range all {
+		if len(group) > 1 {
+			p.accounts = append(p.accounts, group...)
+		}
+	}
+	rnd.Shuffle(len(p.accounts), func(i, j int) {
+		p.accounts[i], p.accounts[j] = p.accounts[j], p.accounts[i]
+	})
+	for i, a := range p.accounts {
+		p.byCurrency[a.Currency] = append(p.byCurrency[a.Currency], i)
+	}
+
+	if skew > 1 && len(p.accounts) > 0 {
+		p.zipf = rand.NewZipf(rnd, skew, 1, uint64(len(p.accounts)-1))
+	}
+	return p
+}
+
+func (p *picker) pick() db.TransferTxParams {
+	var from int
+	if p.zipf != nil {
+		from = int(p.zipf.Uint64())
+	} else {
+		from = p.rnd.Intn(len(p.accounts))
+	}
+	sender := p.accounts[from]
+
+	group := p.byCurrency[sender.Currency]
+	to := from
+	for to == from {
+		to = group[p.rnd.Intn(len(group))]
+	}
+
+	//amounts stay small against the opening balance so transfers are rarely refused for insufficient funds
+	maxAmount := max(sender.opening/20, 1)
+	return db.TransferTxParams{
+		FromAccountID: sender.ID,
+		ToAccountID:   p.accounts[to].ID,
+		Amount:        1 + p.rnd.Int63n(maxAmount),
+	}
+}
+
+// drive sends transfers at the target rate until the duration passes or ctx is cancelled.
+// Transfers are issued on a fixed schedule; when every worker is busy the transfer is skipped
+// rather than queued, so a slow database shows up as a throughput shortfall instead of unbounded latency.
+func drive(ctx context.Context, store *db.Store, p *picker, opts options) *stats {
+	s := newStats()
+	if len(p.accounts) == 0 {
+		return s
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.duration)
+	defer cancel()
+
+	work := make(chan db.TransferTxParams)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for arg := range work {
+				start := time.Now()
+				//in-flight transfers finish even when the run ends, so none is cut off halfway
+				result, err := store.TransferTx(context.WithoutCancel(ctx), arg)
+				s.record(time.Since(start), result, err)
+			}
+		}()
+	}
+
+	s.start = time.Now()
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.rate))
+	defer ticker.Stop()
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+			select {
+			case work <- p.pick():
+			default:
+				s.skip()
+			}
+		}
+	}
+
+	close(work)
+	wg.Wait()
+	s.elapsed = time.Since(s.start)
+	return s
+}
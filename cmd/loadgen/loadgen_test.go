@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+func testAccounts() []seededAccount {
+	var accounts []seededAccount
+	for i := int64(1); i <= 20; i++ {
+		currency := "USD"
+		if i > 15 {
+			currency = "EUR"
+		}
+		accounts = append(accounts, seededAccount{
+			Account: db.Account{ID: i, Currency: currency},
+			opening: 1000,
+		})
+	}
+	//an account alone in its currency can neither send nor receive
+	accounts = append(accounts, seededAccount{Account: db.Account{ID: 99, Currency: "CAD"}, opening: 1000})
+	return accounts
+}
+
+func TestPicker(t *testing.T) {
+	accounts := testAccounts()
+	currency := make(map[int64]string)
+	for _, a := range accounts {
+		currency[a.ID] = a.Currency
+	}
+
+	for _, skew := range []float64{0, 1.5} {
+		p := newPicker(rand.New(rand.NewSource(1)), accounts, skew)
+		senders := make(map[int64]int)
+
+		for i := 0; i < 2000; i++ {
+			arg := p.pick()
+			require.NotEqual(t, arg.FromAccountID, arg.ToAccountID)
+			require.Equal(t, currency[arg.FromAccountID], currency[arg.ToAccountID])
+			require.NotEqual(t, int64(99), arg.FromAccountID)
+			require.NotEqual(t, int64(99), arg.ToAccountID)
+			require.True(t, arg.Amount >= 1 && arg.Amount <= 50)
+			senders[arg.FromAccountID]++
+		}
+
+		hottest := 0
+		for _, n := range senders {
+			hottest = max(hottest, n)
+		}
+		if skew == 0 {
+			require.Less(t, hottest, 300)
+		} else {
+			// With skew the hottest account sends a large share of all transfers.
+			require.Greater(t, hottest, 600)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	require.Equal(t, 50*time.Millisecond, percentile(latencies, 50))
+	require.Equal(t, 99*time.Millisecond, percentile(latencies, 99))
+	require.Equal(t, 100*time.Millisecond, percentile(latencies, 100))
+	require.Zero(t, percentile(nil, 50))
+}
+
+func TestValidate(t *testing.T) {
+	opts := options{users: 10, maxAccounts: 2, rate: 10, concurrency: 2, skew: 1.1}
+	require.NoError(t, opts.validate())
+
+	bad := opts
+	bad.skew = 0.5
+	require.Error(t, bad.validate())
+
+	bad = opts
+	bad.users = 1
+	require.Error(t, bad.validate())
+}
@@ -0,0 +1,163 @@
+// Command loadgen seeds synthetic users and accounts, drives concurrent transfers against them
+// at a target rate and checks afterwards that the ledger is still consistent.
+//
+//	loadgen -users 200 -rate 100 -duration 1m -concurrency 16 -skew 1.2
+//
+// It connects to the database configured by DB_DRIVER and DB_SOURCE, like the server.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/logging"
+	"goprojects/simplebank/util"
+)
+
+type options struct {
+	users       int
+	maxAccounts int
+	rate        float64
+	duration    time.Duration
+	concurrency int
+	//skew is the Zipf exponent used to pick the sending account; zero picks uniformly
+	skew float64
+	seed int64
+}
+
+func main() {
+	var opts options
+	flag.IntVar(&opts.users, "users", 100, "number of users to seed")
+	flag.IntVar(&opts.maxAccounts, "max-accounts", 3, "each user gets between 1 and this many accounts")
+	flag.Float64Var(&opts.rate, "rate", 50, "target transfers per second")
+	flag.DurationVar(&opts.duration, "duration", 30*time.Second, "how long to send transfers")
+	flag.IntVar(&opts.concurrency, "concurrency", 8, "number of concurrent TransferTx calls")
+	flag.Float64Var(&opts.skew, "skew", 1.1, "Zipf exponent (> 1) concentrating traffic on hot accounts, 0 for uniform")
+	flag.Int64Var(&opts.seed, "seed", time.Now().UnixNano(), "random seed, to replay a run")
+	flag.Parse()
+
+	if err := run(opts); err != nil {
+		fmt.Fprintln(os.Stderr, "loadgen:", err)
+		os.Exit(1)
+	}
+}
+
+func (opts options) validate() error {
+	switch {
+	case opts.users < 2:
+		return errors.New("-users must be at least 2")
+	case opts.maxAccounts < 1:
+		return errors.New("-max-accounts must be at least 1")
+	case opts.rate <= 0:
+		return errors.New("-rate must be positive")
+	case opts.concurrency < 1:
+		return errors.New("-concurrency must be at least 1")
+	case opts.skew != 0 && opts.skew <= 1:
+		return errors.New("-skew must be 0 or greater than 1")
+	}
+	return nil
+}
+
+func run(opts options) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	config, err := util.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("cannot load config: %w", err)
+	}
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	if err != nil {
+		return fmt.Errorf("cannot connect to db: %w", err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(opts.concurrency + 2)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	//the store's metrics count the retries execTx makes, which the transfer results cannot show
+	registry := prometheus.NewRegistry()
+	store := db.NewStore(conn,
+		db.WithMetrics(db.NewMetrics(registry)),
+		db.WithLogger(logging.Discard()),
+	)
+	rnd := rand.New(rand.NewSource(opts.seed))
+
+	fmt.Printf("seeding %d users (seed %d)\n", opts.users, opts.seed)
+	accounts, err := seed(ctx, store, rnd, opts)
+	if err != nil {
+		return fmt.Errorf("cannot seed: %w", err)
+	}
+	fmt.Printf("seeded %d accounts\n", len(accounts))
+
+	deadlocksBefore, err := deadlocks(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("sending %.0f transfers/s for %s with %d workers\n", opts.rate, opts.duration, opts.concurrency)
+	stats := drive(ctx, store, newPicker(rnd, accounts, opts.skew), opts)
+
+	deadlocksAfter, err := deadlocks(context.Background(), conn)
+	if err != nil {
+		return err
+	}
+	stats.deadlocks = deadlocksAfter - deadlocksBefore
+	stats.retries = counterValue(registry, "simple_bank_store_transaction_retries_total")
+	stats.print(os.Stdout)
+
+	fmt.Println("checking ledger consistency")
+	problems, err := check(context.Background(), store, accounts)
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		fmt.Println("  " + problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("ledger is inconsistent: %d problems", len(problems))
+	}
+	fmt.Println("ledger is consistent")
+	return nil
+}
+
+// deadlocks reads how many deadlocks Postgres has detected in the database so far
+func deadlocks(ctx context.Context, conn *sql.DB) (int64, error) {
+	var n int64
+	err := conn.QueryRowContext(ctx, "SELECT deadlocks FROM pg_stat_database WHERE datname = current_database()").Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("cannot read deadlock count: %w", err)
+	}
+	return n, nil
+}
+
+func counterValue(gatherer prometheus.Gatherer, name string) float64 {
+	families, err := gatherer.Gather()
+	if err != nil {
+		return 0
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		var total float64
+		for _, metric := range family.GetMetric() {
+			total += metric.GetCounter().GetValue()
+		}
+		return total
+	}
+	return 0
+}
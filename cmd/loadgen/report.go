@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+// stats collects the outcome of every transfer of a run
+type stats struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	completed int
+	held      int
+	skipped   int
+	errors    map[string]int
+
+	start   time.Time
+	elapsed time.Duration
+	//read from Postgres and the store's metrics once the run is over
+	deadlocks int64
+	retries   float64
+}
+
+func newStats() *stats {
+	return &stats{errors: make(map[string]int)}
+}
+
+func (s *stats) record(latency time.Duration, result db.TransferTxResult, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.latencies = append(s.latencies, latency)
+	switch {
+	case err != nil:
+		s.errors[db.TransferErrorClass(err)]++
+	case result.Flag != nil:
+		s.held++
+	default:
+		s.completed++
+	}
+}
+
+func (s *stats) skip() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skipped++
+}
+
+// percentile returns the latency below which p percent of the sorted latencies fall
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted))*p/100+0.5) - 1
+	i = max(0, min(i, len(sorted)-1))
+	return sorted[i]
+}
+
+func (s *stats) print(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sorted := append([]time.Duration(nil), s.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	seconds := s.elapsed.Seconds()
+	if seconds == 0 {
+		seconds = 1
+	}
+
+	fmt.Fprintf(w, "transfers:  %d sent, %d completed, %d held, %d failed, %d skipped\n",
+		len(sorted), s.completed, s.held, len(sorted)-s.completed-s.held, s.skipped)
+	fmt.Fprintf(w, "throughput: %.1f transfers/s over %s\n", float64(s.completed+s.held)/seconds, s.elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "latency:    p50 %s  p90 %s  p99 %s  max %s\n",
+		percentile(sorted, 50).Round(time.Microsecond),
+		percentile(sorted, 90).Round(time.Microsecond),
+		percentile(sorted, 99).Round(time.Microsecond),
+		percentile(sorted, 100).Round(time.Microsecond),
+	)
+	fmt.Fprintf(w, "contention: %d deadlocks detected, %.0f transaction retries\n", s.deadlocks, s.retries)
+
+	classes := make([]string, 0, len(s.errors))
+	for class := range s.errors {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		fmt.Fprintf(w, "errors:     %s %d\n", class, s.errors[class])
+	}
+}
+
+// check verifies that every seeded account's balance is its opening balance plus its entries,
+// and that the transfers moved money around without creating or destroying any
+func check(ctx context.Context, store *db.Store, accounts []seededAccount) ([]string, error) {
+	mismatches, err := store.ListBalanceMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	difference := make(map[int64]int64, len(mismatches))
+	for _, m := range mismatches {
+		difference[m.ID] = m.Balance - m.EntriesTotal
+	}
+
+	var problems []string
+	openingTotal := make(map[string]int64)
+	finalTotal := make(map[string]int64)
+
+	for _, a := range accounts {
+		if difference[a.ID] != a.opening {
+			problems = append(problems, fmt.Sprintf("account %d: balance minus entries is %d, opened with %d", a.ID, difference[a.ID], a.opening))
+		}
+
+		account, err := store.GetAccount(ctx, a.ID)
+		if err != nil {
+			return nil, err
+		}
+		openingTotal[a.Currency] += a.opening
+		finalTotal[a.Currency] += account.Balance
+	}
+
+	for currency, total := range openingTotal {
+		if finalTotal[currency] != total {
+			problems = append(problems, fmt.Sprintf("%s: accounts opened with %d in total but now hold %d", currency, total, finalTotal[currency]))
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/util"
+)
+
+// seededAccount is an account created by the run and the balance it was opened with,
+// which has no entry and so must be remembered to check the ledger afterwards
+type seededAccount struct {
+	db.Account
+	opening int64
+}
+
+// seed creates the users and their accounts. Opening balances follow a log-normal distribution,
+// like real deposits: most accounts hold a few hundred, a few hold a lot.
+func seed(ctx context.Context, store *db.Store, rnd *rand.Rand, opts options) ([]seededAccount, error) {
+	//bcrypt is deliberately slow, and nobody logs in as a synthetic user
+	hashedPassword, err := util.HashPassword(util.RandomString(12))
+	if err != nil {
+		return nil, err
+	}
+
+	currencies := []string{util.USD, util.EUR, util.CAD}
+	run := util.RandomString(6)
+
+	var accounts []seededAccount
+	for i := 0; i < opts.users; i++ {
+		user, err := store.CreateUser(ctx, db.CreateUserParams{
+			Username:       fmt.Sprintf("load_%s_%d", run, i),
+			HashedPassword: hashedPassword,
+			FullName:       fmt.Sprintf("Load Test %d", i),
+			Email:          fmt.Sprintf("load_%s_%d@example.com", run, i),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		//most customers hold a single account in their home currency
+		n := 1 + int(math.Floor(rnd.ExpFloat64()*0.7))
+		n = min(n, opts.maxAccounts, len(currencies))
+		for _, c := range rnd.Perm(len(currencies))[:n] {
+			opening := int64(math.Exp(rnd.NormFloat64()*1.2 + 10))
+
+			account, err := store.CreateAccount(ctx, db.CreateAccountParams{
+				Owner:    user.Username,
+				Balance:  opening,
+				Currency: currencies[c],
+			})
+			if err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, seededAccount{Account: account, opening: opening})
+		}
+	}
+	return accounts, nil
+}
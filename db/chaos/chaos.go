@@ -0,0 +1,236 @@
+// Package chaos wraps a database/sql driver to inject latency and failures into chosen queries,
+// so tests can check how the store behaves when the database fails in the middle of a transaction.
+//
+//	injector := chaos.New()
+//	injector.Add(chaos.Rule{Query: "AddAccountBalance", Nth: 2, Err: chaos.ErrConnectionReset})
+//	store := db.NewStore(injector.OpenDB(connector))
+//
+// Faults are injected at the driver, below database/sql, so they reach every generated query
+// including QueryRowContext, and can target BEGIN and COMMIT as well.
+package chaos
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+// names matched by a Rule's Query besides the sqlc query names
+const (
+	Begin  = "BEGIN"
+	Commit = "COMMIT"
+)
+
+var (
+	// ErrConnectionReset is what the driver reports when the connection broke;
+	// database/sql discards the connection and, outside a transaction, retries on a new one
+	ErrConnectionReset = driver.ErrBadConn
+	// ErrSerializationFailure is the Postgres error execTx retries
+	ErrSerializationFailure = &pq.Error{Code: db.SerializationFailure, Message: "could not serialize access due to concurrent update"}
+)
+
+// Rule describes one fault
+type Rule struct {
+	// Query is the sqlc query name, Begin or Commit; empty matches every call
+	Query string
+	// Nth fires the rule on the Nth matching call only, counting from 1; zero fires it on every call
+	Nth int
+	// Latency delays the call, or fails it with the context's error if the context ends first
+	Latency time.Duration
+	// Err fails the call without running it
+	Err error
+}
+
+type rule struct {
+	Rule
+	calls int
+}
+
+// Injector holds the active rules and counts the calls they match
+type Injector struct {
+	mu    sync.Mutex
+	rules []*rule
+}
+
+// New creates an injector without rules, which passes every call through
+func New() *Injector {
+	return &Injector{}
+}
+
+// Add activates a rule
+func (in *Injector) Add(r Rule) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.rules = append(in.rules, &rule{Rule: r})
+}
+
+// Reset removes every rule
+func (in *Injector) Reset() {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.rules = nil
+}
+
+// Connector wraps base so every connection it opens is subject to the injector's rules
+func (in *Injector) Connector(base driver.Connector) driver.Connector {
+	return connector{base: base, in: in}
+}
+
+// OpenDB opens a pool whose connections are subject to the injector's rules
+func (in *Injector) OpenDB(base driver.Connector) *sql.DB {
+	return sql.OpenDB(in.Connector(base))
+}
+
+// inject applies the rules matching name to one call
+func (in *Injector) inject(ctx context.Context, name string) error {
+	latency, err := in.match(name)
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+func (in *Injector) match(name string) (latency time.Duration, err error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	for _, r := range in.rules {
+		if r.Query != "" && r.Query != name {
+			continue
+		}
+		r.calls++
+		if r.Nth != 0 && r.calls != r.Nth {
+			continue
+		}
+		latency += r.Latency
+		if err == nil {
+			err = r.Err
+		}
+	}
+	return latency, err
+}
+
+type connector struct {
+	base driver.Connector
+	in   *Injector
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: cn, in: c.in}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// conn forwards to the driver's connection once the rules let a call through
+type conn struct {
+	driver.Conn
+	in *Injector
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := c.in.inject(ctx, Begin); err != nil {
+		return nil, err
+	}
+
+	beginner, ok := c.Conn.(driver.ConnBeginTx)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	tx, err := beginner.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{Tx: tx, in: c.in, ctx: ctx}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.in.inject(ctx, db.QueryName(query)); err != nil {
+		return nil, err
+	}
+
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.in.inject(ctx, db.QueryName(query)); err != nil {
+		return nil, err
+	}
+
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.in.inject(ctx, db.QueryName(query)); err != nil {
+		return nil, err
+	}
+
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// transaction lets rules fail the commit
+type transaction struct {
+	driver.Tx
+	in  *Injector
+	ctx context.Context
+}
+
+// Commit rolls the transaction back when a rule fails it, as Postgres does when a commit fails,
+// so the connection goes back to the pool without an open transaction
+func (tx *transaction) Commit() error {
+	if err := tx.in.inject(tx.ctx, Commit); err != nil {
+		_ = tx.Tx.Rollback()
+		return err
+	}
+	return tx.Tx.Commit()
+}
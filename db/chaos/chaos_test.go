@@ -0,0 +1,43 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleMatching(t *testing.T) {
+	in := New()
+	boom := errors.New("boom")
+	in.Add(Rule{Query: "CreateEntry", Nth: 2, Err: boom})
+	in.Add(Rule{Query: Commit, Err: ErrSerializationFailure})
+
+	ctx := context.Background()
+	require.NoError(t, in.inject(ctx, "CreateEntry"))
+	require.ErrorIs(t, in.inject(ctx, "CreateEntry"), boom)
+	// A rule with Nth fires once.
+	require.NoError(t, in.inject(ctx, "CreateEntry"))
+	require.NoError(t, in.inject(ctx, "CreateTransfer"))
+
+	// A rule without Nth fires every time.
+	require.ErrorIs(t, in.inject(ctx, Commit), ErrSerializationFailure)
+	require.ErrorIs(t, in.inject(ctx, Commit), ErrSerializationFailure)
+
+	in.Reset()
+	require.NoError(t, in.inject(ctx, Commit))
+}
+
+func TestLatency(t *testing.T) {
+	in := New()
+	in.Add(Rule{Latency: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	require.ErrorIs(t, in.inject(ctx, "GetAccount"), context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+}
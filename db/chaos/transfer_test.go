@@ -0,0 +1,160 @@
+package chaos
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/util"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(dbtest.Main(m, nil))
+}
+
+// setup returns a store on a fresh database whose connections go through a new injector,
+// and plain queries to inspect the outcome
+func setup(t *testing.T) (*db.Store, *Injector, *db.Queries) {
+	dbSource := dbtest.URL(t)
+	connector, err := pq.NewConnector(dbSource)
+	require.NoError(t, err)
+
+	in := New()
+	chaosDB := in.OpenDB(connector)
+	t.Cleanup(func() { chaosDB.Close() })
+
+	plainDB, err := sql.Open("postgres", dbSource)
+	require.NoError(t, err)
+	t.Cleanup(func() { plainDB.Close() })
+
+	return db.NewStore(chaosDB), in, db.New(plainDB)
+}
+
+func createAccount(t *testing.T, q *db.Queries, balance int64) db.Account {
+	account, err := q.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Balance:  balance,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+// requireUntouched checks that no transfer, entry or balance change of the accounts survived
+func requireUntouched(t *testing.T, q *db.Queries, accounts ...db.Account) {
+	for _, account := range accounts {
+		current, err := q.GetAccount(context.Background(), account.ID)
+		require.NoError(t, err)
+		require.Equal(t, account.Balance, current.Balance)
+
+		entries, err := q.ListAccountEntries(context.Background(), db.ListAccountEntriesParams{
+			AccountID: account.ID,
+			Limit:     10,
+		})
+		require.NoError(t, err)
+		require.Empty(t, entries)
+
+		transfers, err := q.ListOutboundTransfersSince(context.Background(), db.ListOutboundTransfersSinceParams{
+			FromAccountID: account.ID,
+			CreatedAt:     time.Time{},
+		})
+		require.NoError(t, err)
+		require.Empty(t, transfers)
+	}
+}
+
+func TestTransferTxRollsBackOnFault(t *testing.T) {
+	// In the order TransferTx runs them: the transaction, the lock of the second account, the account limit lookup,
+	// every write and the commit. The first account lock and the product and owner limit lookups are not faulted;
+	// like the account limit lookup, they fail before anything is written.
+	steps := []Rule{
+		{Query: Begin},
+		{Query: "GetAccountForUpdate", Nth: 2},
+		{Query: "GetAccountTransferLimit"},
+		{Query: "CreateTransfer"},
+		{Query: "CreateEntry", Nth: 1},
+		{Query: "CreateEntry", Nth: 2},
+		{Query: "AddAccountBalance", Nth: 1},
+		{Query: "AddAccountBalance", Nth: 2},
+		{Query: Commit},
+	}
+
+	for _, step := range steps {
+		for _, fault := range []error{ErrConnectionReset, fmt.Errorf("injected failure")} {
+			step.Err = fault
+			t.Run(fmt.Sprintf("%s#%d/%v", step.Query, step.Nth, fault), func(t *testing.T) {
+				store, in, q := setup(t)
+				account1 := createAccount(t, q, 100)
+				account2 := createAccount(t, q, 100)
+
+				in.Add(step)
+				_, err := store.TransferTx(context.Background(), db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        10,
+				})
+				require.Error(t, err)
+				requireUntouched(t, q, account1, account2)
+			})
+		}
+	}
+}
+
+func TestTransferTxRetriesSerializationFailure(t *testing.T) {
+	store, in, q := setup(t)
+	account1 := createAccount(t, q, 100)
+	account2 := createAccount(t, q, 100)
+
+	// The first attempt fails after one side was already written; the retry must not double it.
+	in.Add(Rule{Query: "AddAccountBalance", Nth: 2, Err: ErrSerializationFailure})
+
+	result, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(90), result.FromAccount.Balance)
+	require.Equal(t, int64(110), result.ToAccount.Balance)
+
+	entries, err := q.ListAccountEntries(context.Background(), db.ListAccountEntriesParams{AccountID: account1.ID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// A failure that persists gives up after the last attempt and leaves nothing behind.
+	account3 := createAccount(t, q, 100)
+	in.Add(Rule{Query: Commit, Err: ErrSerializationFailure})
+	_, err = store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: account3.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.Equal(t, db.SerializationFailure, db.ErrorCode(err))
+	requireUntouched(t, q, account3)
+}
+
+func TestTransferTxTimesOutOnLatency(t *testing.T) {
+	store, in, q := setup(t)
+	account1 := createAccount(t, q, 100)
+	account2 := createAccount(t, q, 100)
+
+	in.Add(Rule{Query: "CreateEntry", Nth: 2, Latency: time.Minute})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	requireUntouched(t, q, account1, account2)
+}
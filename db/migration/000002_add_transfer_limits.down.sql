@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS transfers_from_account_id_created_at_idx;
+DROP TABLE IF EXISTS transfer_limits;
+
+ALTER TABLE "transfers" ALTER COLUMN "created_at" SET DEFAULT 'now';
+ALTER TABLE "entries" ALTER COLUMN "created_at" SET DEFAULT 'now';
+ALTER TABLE "accounts" ALTER COLUMN "created_at" SET DEFAULT 'now';
@@ -0,0 +1,31 @@
+-- 'now' as a literal default is resolved once when the table is created, so every row
+-- shared the same created_at; the velocity windows below need the real insert time
+ALTER TABLE "accounts" ALTER COLUMN "created_at" SET DEFAULT (now());
+ALTER TABLE "entries" ALTER COLUMN "created_at" SET DEFAULT (now());
+ALTER TABLE "transfers" ALTER COLUMN "created_at" SET DEFAULT (now());
+
+CREATE TABLE "transfer_limits" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint,
+  "owner" varchar,
+  "currency" varchar,
+  "max_single_amount" bigint,
+  "daily_outbound_amount" bigint,
+  "monthly_outbound_amount" bigint,
+  "hourly_transfer_count" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  -- a limit applies either to a single account or to every account an owner holds in one currency
+  CONSTRAINT "transfer_limits_scope" CHECK (
+    ("account_id" IS NOT NULL AND "owner" IS NULL AND "currency" IS NULL) OR
+    ("account_id" IS NULL AND "owner" IS NOT NULL AND "currency" IS NOT NULL)
+  )
+);
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("account_id") WHERE "account_id" IS NOT NULL;
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("owner", "currency") WHERE "owner" IS NOT NULL;
+
+-- the velocity checks scan an account's outbound transfers within a time window
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS transfers_to_account_id_created_at_idx;
+DROP TABLE IF EXISTS flagged_transfers;
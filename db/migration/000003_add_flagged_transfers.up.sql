@@ -0,0 +1,20 @@
+CREATE TABLE "flagged_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "transfer_id" bigint UNIQUE NOT NULL,
+  "rule" varchar NOT NULL,
+  "reason" varchar NOT NULL,
+  -- pending holds keep the recipient's credit back until an analyst clears or rejects them
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "reviewed_by" varchar,
+  "review_note" varchar,
+  "reviewed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "flagged_transfers_status" CHECK ("status" IN ('pending', 'cleared', 'rejected'))
+);
+
+CREATE INDEX ON "flagged_transfers" ("status");
+
+-- the screening rules read both directions of an account's recent history
+CREATE INDEX ON "transfers" ("to_account_id", "created_at");
+
+ALTER TABLE "flagged_transfers" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
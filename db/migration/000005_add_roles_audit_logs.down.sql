@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS entries_account_id_id_idx;
+DROP TABLE IF EXISTS audit_logs;
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "is_frozen";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "role";
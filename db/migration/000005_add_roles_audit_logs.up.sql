@@ -0,0 +1,21 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
+
+ALTER TABLE "users" ADD CONSTRAINT "users_role" CHECK ("role" IN ('depositor', 'banker', 'auditor', 'admin'));
+
+ALTER TABLE "accounts" ADD COLUMN "is_frozen" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "audit_logs" (
+  "id" bigserial PRIMARY KEY,
+  -- the username that performed the action
+  "actor" varchar NOT NULL,
+  "action" varchar NOT NULL,
+  "account_id" bigint,
+  "details" jsonb NOT NULL DEFAULT '{}',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "audit_logs" ("account_id");
+
+CREATE INDEX ON "entries" ("account_id", "id");
+
+ALTER TABLE "audit_logs" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
@@ -0,0 +1,22 @@
+CREATE TABLE "jobs" (
+  "id" bigserial PRIMARY KEY,
+  "type" varchar NOT NULL,
+  "payload" jsonb NOT NULL DEFAULT '{}',
+  -- higher priorities are claimed first
+  "priority" int NOT NULL DEFAULT 0,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "max_attempts" int NOT NULL DEFAULT 5,
+  "run_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_at" timestamptz,
+  "locked_by" varchar,
+  "last_error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  CONSTRAINT "jobs_status" CHECK ("status" IN ('pending', 'running', 'done', 'dead'))
+);
+
+-- claims only ever look at pending jobs that are due
+CREATE INDEX ON "jobs" ("priority" DESC, "run_at") WHERE "status" = 'pending';
+
+CREATE INDEX ON "jobs" ("locked_at") WHERE "status" = 'running';
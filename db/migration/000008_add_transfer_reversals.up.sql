@@ -0,0 +1,12 @@
+-- a transfer is reversed at most once, by a new transfer in the opposite direction
+CREATE TABLE "transfer_reversals" (
+  "transfer_id" bigint PRIMARY KEY,
+  "reversal_id" bigint UNIQUE NOT NULL,
+  "actor" varchar NOT NULL,
+  "reason" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "transfer_reversals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "transfer_reversals" ADD FOREIGN KEY ("reversal_id") REFERENCES "transfers" ("id");
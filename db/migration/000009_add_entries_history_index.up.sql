@@ -0,0 +1,6 @@
+-- point-in-time balances sum an account's entries posted after a timestamp: the composite index turns that
+-- into a range scan of the account's newest entries, and INCLUDE lets Postgres sum them from the index alone
+CREATE INDEX "entries_account_id_created_at_idx" ON "entries" ("account_id", "created_at") INCLUDE ("amount");
+
+-- every lookup by account_id alone is served by the new index
+DROP INDEX IF EXISTS "entries_account_id_idx";
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS "entries_open_day" ON "entries";
+
+DROP FUNCTION IF EXISTS "reject_closed_day_entry";
+
+DROP TABLE IF EXISTS "daily_balances";
+
+DROP TABLE IF EXISTS "business_days";
+
+DROP FUNCTION IF EXISTS "reject_history_change";
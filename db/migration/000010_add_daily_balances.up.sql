@@ -0,0 +1,50 @@
+-- a closed business day: no entry may post before closes_at any more
+CREATE TABLE "business_days" (
+  "business_date" date PRIMARY KEY,
+  -- the instant the day ended in the bank's timezone
+  "closes_at" timestamptz UNIQUE NOT NULL,
+  "closed_by" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- every account's balance at the end of a closed business day
+CREATE TABLE "daily_balances" (
+  "account_id" bigint NOT NULL,
+  "business_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "business_date")
+);
+
+CREATE INDEX ON "daily_balances" ("business_date");
+
+ALTER TABLE "daily_balances" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "daily_balances" ADD FOREIGN KEY ("business_date") REFERENCES "business_days" ("business_date");
+
+-- closed days and their snapshots are history: they are only ever inserted
+CREATE FUNCTION "reject_history_change"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION '% rows are immutable', TG_TABLE_NAME USING ERRCODE = 'SB002';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "business_days_immutable" BEFORE UPDATE OR DELETE ON "business_days"
+FOR EACH ROW EXECUTE FUNCTION "reject_history_change"();
+
+CREATE TRIGGER "daily_balances_immutable" BEFORE UPDATE OR DELETE ON "daily_balances"
+FOR EACH ROW EXECUTE FUNCTION "reject_history_change"();
+
+-- created_at is the start of the inserting transaction, so a transaction that began before a day was closed
+-- would post into it; it fails instead and is retried with a fresh timestamp
+CREATE FUNCTION "reject_closed_day_entry"() RETURNS trigger AS $$
+BEGIN
+  IF NEW.created_at < (SELECT max(closes_at) FROM business_days) THEN
+    RAISE EXCEPTION 'entry dated % falls into a closed business day', NEW.created_at USING ERRCODE = 'SB001';
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "entries_open_day" BEFORE INSERT ON "entries"
+FOR EACH ROW EXECUTE FUNCTION "reject_closed_day_entry"();
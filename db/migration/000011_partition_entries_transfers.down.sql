@@ -0,0 +1,51 @@
+-- rows in detached partitions are not moved back
+ALTER TABLE "entries" RENAME TO "entries_partitioned";
+ALTER TABLE "transfers" RENAME TO "transfers_partitioned";
+
+CREATE TABLE "entries" (
+  "id" bigint PRIMARY KEY DEFAULT nextval('entries_id_seq'),
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "transfers" (
+  "id" bigint PRIMARY KEY DEFAULT nextval('transfers_id_seq'),
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+INSERT INTO "entries" SELECT * FROM "entries_partitioned";
+INSERT INTO "transfers" SELECT * FROM "transfers_partitioned";
+
+ALTER SEQUENCE "entries_id_seq" OWNED BY "entries"."id";
+ALTER SEQUENCE "transfers_id_seq" OWNED BY "transfers"."id";
+
+DROP TABLE "entries_partitioned";
+DROP TABLE "transfers_partitioned";
+
+CREATE INDEX "entries_account_id_created_at_idx" ON "entries" ("account_id", "created_at") INCLUDE ("amount");
+CREATE INDEX ON "entries" ("account_id", "id");
+
+CREATE INDEX ON "transfers" ("from_account_id");
+CREATE INDEX ON "transfers" ("to_account_id");
+CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+CREATE INDEX ON "transfers" ("to_account_id", "created_at");
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "flagged_transfers" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+ALTER TABLE "transfer_reversals" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+ALTER TABLE "transfer_reversals" ADD FOREIGN KEY ("reversal_id") REFERENCES "transfers" ("id");
+
+CREATE TRIGGER "entries_open_day" BEFORE INSERT ON "entries"
+FOR EACH ROW EXECUTE FUNCTION "reject_closed_day_entry"();
+
+DROP FUNCTION IF EXISTS "detach_monthly_partition";
+DROP FUNCTION IF EXISTS "create_monthly_partition";
+DROP FUNCTION IF EXISTS "monthly_partition_name";
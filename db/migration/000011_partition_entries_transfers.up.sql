@@ -0,0 +1,117 @@
+-- entries and transfers are split into monthly range partitions on created_at, so queries over a time range only
+-- read the months they cover and old months can be detached whole instead of deleted row by row.
+-- A partition is named <table>_yYYYYmMM and holds one calendar month in UTC.
+CREATE FUNCTION "monthly_partition_name"(parent text, in_month date) RETURNS text AS $$
+  SELECT parent || '_' || to_char(in_month, '"y"YYYY"m"MM');
+$$ LANGUAGE sql IMMUTABLE;
+
+-- creates the partition of parent for the month containing in_month, and reports whether it did;
+-- a month whose partition was detached is not created again
+CREATE FUNCTION "create_monthly_partition"(parent text, in_month date) RETURNS boolean AS $$
+DECLARE
+  first_day date := in_month - (extract(day FROM in_month)::int - 1);
+  part text := monthly_partition_name(parent, first_day);
+BEGIN
+  IF to_regclass(part) IS NOT NULL THEN
+    RETURN false;
+  END IF;
+  EXECUTE format('CREATE TABLE %I PARTITION OF %I FOR VALUES FROM (%L) TO (%L)',
+    part, parent,
+    first_day::timestamp AT TIME ZONE 'UTC',
+    (first_day + interval '1 month')::timestamp AT TIME ZONE 'UTC');
+  RETURN true;
+END;
+$$ LANGUAGE plpgsql;
+
+-- detaches the partition of parent for the month containing in_month, and reports whether it did;
+-- the detached table keeps its rows until it is archived and dropped
+CREATE FUNCTION "detach_monthly_partition"(parent text, in_month date) RETURNS boolean AS $$
+DECLARE
+  part text := monthly_partition_name(parent, in_month - (extract(day FROM in_month)::int - 1));
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM pg_inherits
+    WHERE inhrelid = to_regclass(part) AND inhparent = to_regclass(parent)
+  ) THEN
+    RETURN false;
+  END IF;
+  EXECUTE format('ALTER TABLE %I DETACH PARTITION %I', parent, part);
+  RETURN true;
+END;
+$$ LANGUAGE plpgsql;
+
+-- a foreign key must reference a unique key containing the partition key, which a transfer ID alone is not;
+-- transfers are never updated or deleted by the application, so the references are kept by the code that writes them
+ALTER TABLE "flagged_transfers" DROP CONSTRAINT IF EXISTS "flagged_transfers_transfer_id_fkey";
+ALTER TABLE "transfer_reversals" DROP CONSTRAINT IF EXISTS "transfer_reversals_transfer_id_fkey";
+ALTER TABLE "transfer_reversals" DROP CONSTRAINT IF EXISTS "transfer_reversals_reversal_id_fkey";
+
+ALTER TABLE "entries" RENAME TO "entries_unpartitioned";
+ALTER TABLE "transfers" RENAME TO "transfers_unpartitioned";
+
+-- the IDs keep coming from the existing sequences
+CREATE TABLE "entries" (
+  "id" bigint NOT NULL DEFAULT nextval('entries_id_seq'),
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+) PARTITION BY RANGE ("created_at");
+
+CREATE TABLE "transfers" (
+  "id" bigint NOT NULL DEFAULT nextval('transfers_id_seq'),
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+) PARTITION BY RANGE ("created_at");
+
+-- there is no default partition, so every month from the oldest row, or at least last month, to three months ahead
+-- gets one; the partition maintenance job keeps creating them ahead of time from here on
+DO $$
+DECLARE
+  month date;
+BEGIN
+  FOR month IN
+    SELECT generate_series(
+      date_trunc('month', LEAST(
+        (SELECT min(created_at) FROM entries_unpartitioned),
+        (SELECT min(created_at) FROM transfers_unpartitioned),
+        now() - interval '1 month'
+      ) AT TIME ZONE 'UTC'),
+      date_trunc('month', now() AT TIME ZONE 'UTC') + interval '3 months',
+      interval '1 month'
+    )::date
+  LOOP
+    PERFORM create_monthly_partition('entries', month);
+    PERFORM create_monthly_partition('transfers', month);
+  END LOOP;
+END;
+$$;
+
+INSERT INTO "entries" SELECT * FROM "entries_unpartitioned";
+INSERT INTO "transfers" SELECT * FROM "transfers_unpartitioned";
+
+ALTER SEQUENCE "entries_id_seq" OWNED BY "entries"."id";
+ALTER SEQUENCE "transfers_id_seq" OWNED BY "transfers"."id";
+
+DROP TABLE "entries_unpartitioned";
+DROP TABLE "transfers_unpartitioned";
+
+-- a unique key on a partitioned table must contain the partition key
+ALTER TABLE "entries" ADD PRIMARY KEY ("id", "created_at");
+ALTER TABLE "transfers" ADD PRIMARY KEY ("id", "created_at");
+
+CREATE INDEX "entries_account_id_created_at_idx" ON "entries" ("account_id", "created_at") INCLUDE ("amount");
+CREATE INDEX ON "entries" ("account_id", "id");
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+CREATE INDEX ON "transfers" ("to_account_id", "created_at");
+CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+ALTER TABLE "transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+-- created after the copy, so rows of already closed days could move over
+CREATE TRIGGER "entries_open_day" BEFORE INSERT ON "entries"
+FOR EACH ROW EXECUTE FUNCTION "reject_closed_day_entry"();
@@ -0,0 +1,5 @@
+DROP FUNCTION IF EXISTS "drop_monthly_partition";
+
+DROP TABLE IF EXISTS "archived_entry_totals";
+
+DROP TABLE IF EXISTS "archived_periods";
@@ -0,0 +1,38 @@
+-- a month of a partitioned table that was exported to the blob store and removed from Postgres
+CREATE TABLE "archived_periods" (
+  "table_name" varchar NOT NULL,
+  -- the first day of the month, in UTC like the partitions
+  "month" date NOT NULL,
+  "object_key" varchar NOT NULL,
+  "row_count" bigint NOT NULL,
+  "amount_total" bigint NOT NULL,
+  -- hex SHA-256 of the compressed object, checked again whenever it is read back
+  "sha256" varchar NOT NULL,
+  "archived_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("table_name", "month")
+);
+
+-- what each account's archived entries add up to per month, so balances and reconciliation need not read the archive
+CREATE TABLE "archived_entry_totals" (
+  "account_id" bigint NOT NULL,
+  "month" date NOT NULL,
+  "entry_count" bigint NOT NULL,
+  "amount_total" bigint NOT NULL,
+  PRIMARY KEY ("account_id", "month")
+);
+
+ALTER TABLE "archived_entry_totals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+-- drops the partition of parent for the month containing in_month, detaching it first if it is still attached
+CREATE FUNCTION "drop_monthly_partition"(parent text, in_month date) RETURNS boolean AS $$
+DECLARE
+  part text := monthly_partition_name(parent, in_month - (extract(day FROM in_month)::int - 1));
+BEGIN
+  IF to_regclass(part) IS NULL THEN
+    RETURN false;
+  END IF;
+  PERFORM detach_monthly_partition(parent, in_month);
+  EXECUTE format('DROP TABLE %I', part);
+  RETURN true;
+END;
+$$ LANGUAGE plpgsql;
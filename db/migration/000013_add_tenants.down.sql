@@ -0,0 +1,43 @@
+DROP POLICY IF EXISTS "tenant_isolation" ON "transfers";
+ALTER TABLE "transfers" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "transfers" DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "tenant_isolation" ON "entries";
+ALTER TABLE "entries" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "entries" DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "tenant_isolation" ON "users";
+ALTER TABLE "users" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "users" DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "tenant_isolation" ON "accounts";
+ALTER TABLE "accounts" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "accounts" DISABLE ROW LEVEL SECURITY;
+
+DROP TRIGGER IF EXISTS "transfers_tenant" ON "transfers";
+DROP TRIGGER IF EXISTS "entries_tenant" ON "entries";
+DROP FUNCTION IF EXISTS "set_ledger_tenant";
+
+-- dropping the columns drops the composite foreign keys, the unique key must wait until they are gone
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "entries" DROP COLUMN IF EXISTS "tenant_id";
+
+DO $$
+DECLARE
+  part text;
+BEGIN
+  FOR part IN
+    SELECT relname FROM pg_class
+    WHERE relkind = 'r' AND NOT relispartition AND relname ~ '^(entries|transfers)_y[0-9]{4}m[0-9]{2}$'
+  LOOP
+    EXECUTE format('ALTER TABLE %I DROP COLUMN IF EXISTS "tenant_id"', part);
+  END LOOP;
+END;
+$$;
+
+ALTER TABLE "users" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "tenant_id";
+
+DROP FUNCTION IF EXISTS "current_tenant";
+
+DROP TABLE IF EXISTS "tenants";
@@ -0,0 +1,89 @@
+-- a bank served by this deployment; every account, user and ledger row belongs to exactly one
+CREATE TABLE "tenants" (
+  "id" varchar PRIMARY KEY,
+  "name" varchar NOT NULL,
+  -- the host the tenant's customers reach the API on, NULL when it is only selected by header
+  "host" varchar UNIQUE,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- everything that existed before tenants belongs to this one
+INSERT INTO "tenants" ("id", "name") VALUES ('default', 'Default');
+
+-- the tenant the session acts for, set with set_config('app.tenant_id', ...);
+-- NULL for system processes such as the workers and bankctl, which see every tenant
+CREATE FUNCTION "current_tenant"() RETURNS varchar AS $$
+  SELECT NULLIF(current_setting('app.tenant_id', true), '')
+$$ LANGUAGE sql STABLE;
+
+-- the constant default fills the existing rows without evaluating the function for each of them
+ALTER TABLE "accounts" ADD COLUMN "tenant_id" varchar NOT NULL DEFAULT 'default' REFERENCES "tenants" ("id");
+ALTER TABLE "accounts" ALTER COLUMN "tenant_id" SET DEFAULT COALESCE(current_tenant(), 'default');
+ALTER TABLE "users" ADD COLUMN "tenant_id" varchar NOT NULL DEFAULT 'default' REFERENCES "tenants" ("id");
+ALTER TABLE "users" ALTER COLUMN "tenant_id" SET DEFAULT COALESCE(current_tenant(), 'default');
+
+ALTER TABLE "entries" ADD COLUMN "tenant_id" varchar NOT NULL DEFAULT 'default';
+ALTER TABLE "transfers" ADD COLUMN "tenant_id" varchar NOT NULL DEFAULT 'default';
+ALTER TABLE "entries" ALTER COLUMN "tenant_id" DROP DEFAULT;
+ALTER TABLE "transfers" ALTER COLUMN "tenant_id" DROP DEFAULT;
+
+-- detached partitions waiting to be archived must keep the columns of their parent
+DO $$
+DECLARE
+  part text;
+BEGIN
+  FOR part IN
+    SELECT relname FROM pg_class
+    WHERE relkind = 'r' AND NOT relispartition AND relname ~ '^(entries|transfers)_y[0-9]{4}m[0-9]{2}$'
+  LOOP
+    EXECUTE format('ALTER TABLE %I ADD COLUMN "tenant_id" varchar NOT NULL DEFAULT %L', part, 'default');
+    EXECUTE format('ALTER TABLE %I ALTER COLUMN "tenant_id" DROP DEFAULT', part);
+  END LOOP;
+END;
+$$;
+
+CREATE INDEX ON "accounts" ("tenant_id");
+CREATE INDEX ON "users" ("tenant_id");
+
+-- a ledger row can only reference accounts of its own tenant, so no transfer moves money between two banks
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_tenant_id_id_key" UNIQUE ("tenant_id", "id");
+ALTER TABLE "entries" ADD FOREIGN KEY ("tenant_id", "account_id") REFERENCES "accounts" ("tenant_id", "id");
+ALTER TABLE "transfers" ADD FOREIGN KEY ("tenant_id", "from_account_id") REFERENCES "accounts" ("tenant_id", "id");
+ALTER TABLE "transfers" ADD FOREIGN KEY ("tenant_id", "to_account_id") REFERENCES "accounts" ("tenant_id", "id");
+
+-- ledger rows take the tenant of the account named by the trigger's argument, whatever the insert says;
+-- an account the session cannot see leaves it NULL, which the NOT NULL constraint rejects
+CREATE FUNCTION "set_ledger_tenant"() RETURNS trigger AS $$
+BEGIN
+  NEW.tenant_id := (SELECT tenant_id FROM accounts WHERE id = (to_jsonb(NEW) ->> TG_ARGV[0])::bigint);
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "entries_tenant" BEFORE INSERT ON "entries"
+FOR EACH ROW EXECUTE FUNCTION set_ledger_tenant('account_id');
+
+CREATE TRIGGER "transfers_tenant" BEFORE INSERT ON "transfers"
+FOR EACH ROW EXECUTE FUNCTION set_ledger_tenant('from_account_id');
+
+-- sessions acting for a tenant only see and write its rows. FORCE subjects the tables' owner too,
+-- but superusers and roles with BYPASSRLS skip every policy, so the application must not connect as one.
+ALTER TABLE "accounts" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "accounts" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "accounts"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
+
+ALTER TABLE "users" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "users" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "users"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
+
+ALTER TABLE "entries" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "entries" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "entries"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
+
+ALTER TABLE "transfers" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "transfers" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "transfers"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
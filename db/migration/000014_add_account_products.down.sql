@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS "accounts_owner" ON "accounts";
+DROP FUNCTION IF EXISTS "check_account_owner";
+
+ALTER TABLE "accounts" DROP COLUMN IF EXISTS "product";
+
+DROP TABLE IF EXISTS "account_products";
@@ -0,0 +1,51 @@
+-- the chart of accounts: every account is opened as one of these products.
+-- Balances keep the ledger's sign, credits positive; normal_balance says which side a category's balances sit on,
+-- so an asset or expense account normally shows a negative balance and reports it negated.
+CREATE TABLE "account_products" (
+  "code" varchar PRIMARY KEY,
+  "name" varchar NOT NULL,
+  "category" varchar NOT NULL CHECK ("category" IN ('asset', 'liability', 'equity', 'income', 'expense')),
+  "normal_balance" varchar NOT NULL GENERATED ALWAYS AS (
+    CASE WHEN "category" IN ('asset', 'expense') THEN 'debit' ELSE 'credit' END
+  ) STORED,
+  -- internal accounts belong to the bank itself and never to a customer
+  "internal" boolean NOT NULL DEFAULT false,
+  -- the lowest balance a transfer may leave behind; NULL lets the balance go negative without bound
+  "min_balance" bigint,
+  -- outbound transfers allowed per calendar month in UTC; NULL for no limit
+  "monthly_withdrawal_limit" integer CHECK ("monthly_withdrawal_limit" >= 0),
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+-- customer deposits are what the bank owes, a loan is what it is owed
+INSERT INTO "account_products" ("code", "name", "category", "internal", "min_balance", "monthly_withdrawal_limit") VALUES
+  ('checking', 'Checking account', 'liability', false, 0, NULL),
+  ('savings', 'Savings account', 'liability', false, 0, 6),
+  ('loan', 'Loan account', 'asset', false, NULL, NULL),
+  ('settlement', 'Settlement account', 'asset', true, NULL, NULL),
+  ('fee_income', 'Fee income', 'income', true, NULL, NULL),
+  ('interest_expense', 'Interest expense', 'expense', true, NULL, NULL),
+  ('suspense', 'Suspense account', 'liability', true, NULL, NULL),
+  ('equity', 'Owner''s equity', 'equity', true, NULL, NULL);
+
+-- existing accounts were all opened as plain current accounts
+ALTER TABLE "accounts" ADD COLUMN "product" varchar NOT NULL DEFAULT 'checking' REFERENCES "account_products" ("code");
+
+CREATE INDEX ON "accounts" ("product");
+
+-- a tenant keeps one internal account per product and currency, so the ledger can find it without configuration
+CREATE UNIQUE INDEX "accounts_internal_key" ON "accounts" ("tenant_id", "product", "currency") WHERE "owner" = '';
+
+-- internal accounts have no owner, customer accounts always have one
+CREATE FUNCTION "check_account_owner"() RETURNS trigger AS $$
+BEGIN
+  IF (SELECT "internal" FROM account_products WHERE code = NEW.product) <> (NEW.owner = '') THEN
+    RAISE EXCEPTION 'account of product % cannot be owned by "%"', NEW.product, NEW.owner
+      USING ERRCODE = 'check_violation';
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "accounts_owner" BEFORE INSERT OR UPDATE OF "owner", "product" ON "accounts"
+FOR EACH ROW EXECUTE FUNCTION "check_account_owner"();
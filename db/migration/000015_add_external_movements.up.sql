@@ -0,0 +1,35 @@
+-- money entering or leaving the bank through a payment network, posted against the tenant's settlement account.
+-- While pending its funds sit in the suspense account: a deposit is not spendable yet, a withdrawal can no longer be spent.
+-- Settling moves them on to their destination, failing moves them back to where they came from.
+CREATE TABLE "external_movements" (
+  "id" bigserial PRIMARY KEY,
+  "tenant_id" varchar NOT NULL REFERENCES "tenants" ("id"),
+  "kind" varchar NOT NULL CHECK ("kind" IN ('deposit', 'withdrawal')),
+  "account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL CHECK ("amount" > 0),
+  "currency" varchar NOT NULL,
+  -- the payment network's ID, so a movement reported twice is only posted once
+  "external_reference" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'settled', 'failed')),
+  "failure_reason" varchar,
+  -- the transfer into suspense, and the one out of it once the movement is settled or failed
+  "pending_transfer_id" bigint NOT NULL,
+  "final_transfer_id" bigint,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "finished_at" timestamptz,
+  CHECK (("status" = 'pending') = ("final_transfer_id" IS NULL)),
+  CHECK (("status" = 'failed') = ("failure_reason" IS NOT NULL))
+);
+
+CREATE UNIQUE INDEX ON "external_movements" ("tenant_id", "external_reference");
+
+CREATE INDEX ON "external_movements" ("account_id", "created_at");
+
+CREATE INDEX ON "external_movements" ("created_at") WHERE "status" = 'pending';
+
+ALTER TABLE "external_movements" ADD FOREIGN KEY ("tenant_id", "account_id") REFERENCES "accounts" ("tenant_id", "id");
+
+ALTER TABLE "external_movements" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "external_movements" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "external_movements"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
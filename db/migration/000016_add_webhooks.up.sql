@@ -0,0 +1,43 @@
+-- a user's request to be told about their accounts' events by an HTTP POST to url, signed with secret
+CREATE TABLE "webhook_subscriptions" (
+  "id" bigserial PRIMARY KEY,
+  "tenant_id" varchar NOT NULL DEFAULT COALESCE(current_tenant(), 'default') REFERENCES "tenants" ("id"),
+  "owner" varchar NOT NULL REFERENCES "users" ("username"),
+  "url" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL CHECK (
+    cardinality("event_types") > 0 AND "event_types" <@ ARRAY['transfer.created', 'balance.updated']::varchar[]
+  ),
+  "secret" varchar NOT NULL,
+  -- failed attempts since the last successful one; reaching the limit disables the subscription
+  "failure_count" int NOT NULL DEFAULT 0,
+  "disabled_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "webhook_subscriptions" ("owner");
+
+-- one event sent to one subscription, with the outcome of its latest attempt
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "subscription_id" bigint NOT NULL REFERENCES "webhook_subscriptions" ("id") ON DELETE CASCADE,
+  -- shared by every delivery of the same event, replays included, so receivers can discard duplicates
+  "event_id" uuid NOT NULL,
+  "event_type" varchar NOT NULL,
+  "account_id" bigint NOT NULL,
+  "payload" jsonb NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending' CHECK ("status" IN ('pending', 'succeeded', 'failed')),
+  "attempts" int NOT NULL DEFAULT 0,
+  "response_status" int,
+  "last_error" varchar,
+  -- the delivery this one sends again
+  "replay_of" bigint REFERENCES "webhook_deliveries" ("id") ON DELETE SET NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "webhook_deliveries" ("subscription_id", "id");
+
+ALTER TABLE "webhook_subscriptions" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "webhook_subscriptions" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "webhook_subscriptions"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
@@ -0,0 +1,21 @@
+ALTER TABLE "entries" DROP COLUMN IF EXISTS "metadata";
+ALTER TABLE "entries" DROP COLUMN IF EXISTS "external_reference";
+ALTER TABLE "entries" DROP COLUMN IF EXISTS "description";
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "metadata";
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "external_reference";
+ALTER TABLE "transfers" DROP COLUMN IF EXISTS "description";
+
+DO $$
+DECLARE
+  part text;
+BEGIN
+  FOR part IN
+    SELECT relname FROM pg_class
+    WHERE relkind = 'r' AND NOT relispartition AND relname ~ '^(entries|transfers)_y[0-9]{4}m[0-9]{2}$'
+  LOOP
+    EXECUTE format('ALTER TABLE %I DROP COLUMN IF EXISTS "metadata"', part);
+    EXECUTE format('ALTER TABLE %I DROP COLUMN IF EXISTS "external_reference"', part);
+    EXECUTE format('ALTER TABLE %I DROP COLUMN IF EXISTS "description"', part);
+  END LOOP;
+END;
+$$;
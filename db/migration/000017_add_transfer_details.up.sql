@@ -0,0 +1,29 @@
+-- what a payment was for: a free-text description for its owners, the reference of the system that requested it,
+-- and a JSON object of whatever else that system wants to find it by. Entries carry the details of their transfer,
+-- so statements show them without a join. TransferTx checks the limits, including the size of the metadata which the constraints leave out.
+ALTER TABLE "transfers" ADD COLUMN "description" varchar NOT NULL DEFAULT '' CHECK (char_length("description") <= 140);
+ALTER TABLE "transfers" ADD COLUMN "external_reference" varchar NOT NULL DEFAULT '' CHECK (char_length("external_reference") <= 64);
+ALTER TABLE "transfers" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}' CHECK (jsonb_typeof("metadata") = 'object');
+ALTER TABLE "entries" ADD COLUMN "description" varchar NOT NULL DEFAULT '' CHECK (char_length("description") <= 140);
+ALTER TABLE "entries" ADD COLUMN "external_reference" varchar NOT NULL DEFAULT '' CHECK (char_length("external_reference") <= 64);
+ALTER TABLE "entries" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}' CHECK (jsonb_typeof("metadata") = 'object');
+
+-- detached partitions waiting to be archived must keep the columns of their parent
+DO $$
+DECLARE
+  part text;
+BEGIN
+  FOR part IN
+    SELECT relname FROM pg_class
+    WHERE relkind = 'r' AND NOT relispartition AND relname ~ '^(entries|transfers)_y[0-9]{4}m[0-9]{2}$'
+  LOOP
+    EXECUTE format('ALTER TABLE %I ADD COLUMN "description" varchar NOT NULL DEFAULT %L', part, '');
+    EXECUTE format('ALTER TABLE %I ADD COLUMN "external_reference" varchar NOT NULL DEFAULT %L', part, '');
+    EXECUTE format('ALTER TABLE %I ADD COLUMN "metadata" jsonb NOT NULL DEFAULT %L', part, '{}');
+  END LOOP;
+END;
+$$;
+
+-- most transfers have no reference, so only those that do are indexed
+CREATE INDEX ON "transfers" ("external_reference") WHERE "external_reference" <> '';
+CREATE INDEX ON "entries" ("external_reference") WHERE "external_reference" <> '';
@@ -0,0 +1,44 @@
+DROP POLICY IF EXISTS "tenant_isolation" ON "webhook_deliveries";
+ALTER TABLE "webhook_deliveries" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "webhook_deliveries" DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "tenant_isolation" ON "verify_emails";
+ALTER TABLE "verify_emails" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "verify_emails" DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "tenant_isolation" ON "sessions";
+ALTER TABLE "sessions" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "sessions" DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "tenant_isolation" ON "daily_balances";
+ALTER TABLE "daily_balances" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "daily_balances" DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "tenant_isolation" ON "transfer_limits";
+ALTER TABLE "transfer_limits" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "transfer_limits" DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "tenant_isolation" ON "flagged_transfers";
+ALTER TABLE "flagged_transfers" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "flagged_transfers" DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS "tenant_isolation" ON "audit_logs";
+ALTER TABLE "audit_logs" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "audit_logs" DISABLE ROW LEVEL SECURITY;
+
+DROP TRIGGER IF EXISTS "webhook_deliveries_tenant" ON "webhook_deliveries";
+DROP TRIGGER IF EXISTS "verify_emails_tenant" ON "verify_emails";
+DROP TRIGGER IF EXISTS "sessions_tenant" ON "sessions";
+DROP TRIGGER IF EXISTS "daily_balances_tenant" ON "daily_balances";
+DROP TRIGGER IF EXISTS "transfer_limits_tenant" ON "transfer_limits";
+DROP TRIGGER IF EXISTS "flagged_transfers_tenant" ON "flagged_transfers";
+DROP TRIGGER IF EXISTS "audit_logs_tenant" ON "audit_logs";
+DROP FUNCTION IF EXISTS "inherit_tenant";
+
+ALTER TABLE "webhook_deliveries" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "verify_emails" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "sessions" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "daily_balances" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "transfer_limits" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "flagged_transfers" DROP COLUMN IF EXISTS "tenant_id";
+ALTER TABLE "audit_logs" DROP COLUMN IF EXISTS "tenant_id";
@@ -0,0 +1,114 @@
+-- rows that hang off an account, user, transfer or subscription take that row's tenant, whatever the insert says.
+-- The trigger's arguments come in threes: the referenced table, its key and the column of the new row holding it;
+-- the first one that is set decides. A row referencing nothing keeps the tenant it was inserted with,
+-- and a reference the session cannot see leaves it NULL, which the NOT NULL constraint rejects.
+CREATE FUNCTION "inherit_tenant"() RETURNS trigger AS $$
+DECLARE
+  has_ref boolean;
+  ref_tenant varchar;
+BEGIN
+  FOR i IN 0 .. TG_NARGS / 3 - 1 LOOP
+    EXECUTE format('SELECT ($1).%3$I IS NOT NULL, (SELECT tenant_id FROM %1$I WHERE %2$I = ($1).%3$I)',
+      TG_ARGV[i * 3], TG_ARGV[i * 3 + 1], TG_ARGV[i * 3 + 2])
+      INTO has_ref, ref_tenant USING NEW;
+    IF has_ref THEN
+      NEW.tenant_id := ref_tenant;
+      RETURN NEW;
+    END IF;
+  END LOOP;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- the constant default fills the existing rows, which are then moved to the tenant of what they reference
+ALTER TABLE "audit_logs" ADD COLUMN "tenant_id" varchar NOT NULL DEFAULT 'default' REFERENCES "tenants" ("id");
+ALTER TABLE "flagged_transfers" ADD COLUMN "tenant_id" varchar NOT NULL DEFAULT 'default' REFERENCES "tenants" ("id");
+ALTER TABLE "transfer_limits" ADD COLUMN "tenant_id" varchar NOT NULL DEFAULT 'default' REFERENCES "tenants" ("id");
+ALTER TABLE "daily_balances" ADD COLUMN "tenant_id" varchar NOT NULL DEFAULT 'default' REFERENCES "tenants" ("id");
+ALTER TABLE "sessions" ADD COLUMN "tenant_id" varchar NOT NULL DEFAULT 'default' REFERENCES "tenants" ("id");
+ALTER TABLE "verify_emails" ADD COLUMN "tenant_id" varchar NOT NULL DEFAULT 'default' REFERENCES "tenants" ("id");
+ALTER TABLE "webhook_deliveries" ADD COLUMN "tenant_id" varchar NOT NULL DEFAULT 'default' REFERENCES "tenants" ("id");
+
+-- an audit log without an account records a change to a user, such as a new role, made by a user of the same tenant
+UPDATE "audit_logs" SET "tenant_id" = a."tenant_id" FROM "accounts" a WHERE a."id" = "audit_logs"."account_id";
+UPDATE "audit_logs" SET "tenant_id" = u."tenant_id" FROM "users" u
+WHERE "audit_logs"."account_id" IS NULL AND u."username" = "audit_logs"."actor";
+UPDATE "flagged_transfers" SET "tenant_id" = t."tenant_id" FROM "transfers" t WHERE t."id" = "flagged_transfers"."transfer_id";
+UPDATE "transfer_limits" SET "tenant_id" = a."tenant_id" FROM "accounts" a WHERE a."id" = "transfer_limits"."account_id";
+UPDATE "transfer_limits" SET "tenant_id" = u."tenant_id" FROM "users" u WHERE u."username" = "transfer_limits"."owner";
+UPDATE "sessions" SET "tenant_id" = u."tenant_id" FROM "users" u WHERE u."username" = "sessions"."username";
+UPDATE "verify_emails" SET "tenant_id" = u."tenant_id" FROM "users" u WHERE u."username" = "verify_emails"."username";
+UPDATE "webhook_deliveries" SET "tenant_id" = s."tenant_id" FROM "webhook_subscriptions" s WHERE s."id" = "webhook_deliveries"."subscription_id";
+
+-- snapshots are otherwise immutable; setting their tenant changes nothing they record
+ALTER TABLE "daily_balances" DISABLE TRIGGER "daily_balances_immutable";
+UPDATE "daily_balances" SET "tenant_id" = a."tenant_id" FROM "accounts" a WHERE a."id" = "daily_balances"."account_id";
+ALTER TABLE "daily_balances" ENABLE TRIGGER "daily_balances_immutable";
+
+ALTER TABLE "audit_logs" ALTER COLUMN "tenant_id" SET DEFAULT COALESCE(current_tenant(), 'default');
+ALTER TABLE "flagged_transfers" ALTER COLUMN "tenant_id" SET DEFAULT COALESCE(current_tenant(), 'default');
+ALTER TABLE "transfer_limits" ALTER COLUMN "tenant_id" SET DEFAULT COALESCE(current_tenant(), 'default');
+ALTER TABLE "daily_balances" ALTER COLUMN "tenant_id" SET DEFAULT COALESCE(current_tenant(), 'default');
+ALTER TABLE "sessions" ALTER COLUMN "tenant_id" SET DEFAULT COALESCE(current_tenant(), 'default');
+ALTER TABLE "verify_emails" ALTER COLUMN "tenant_id" SET DEFAULT COALESCE(current_tenant(), 'default');
+ALTER TABLE "webhook_deliveries" ALTER COLUMN "tenant_id" SET DEFAULT COALESCE(current_tenant(), 'default');
+
+CREATE TRIGGER "audit_logs_tenant" BEFORE INSERT ON "audit_logs"
+FOR EACH ROW EXECUTE FUNCTION inherit_tenant('accounts', 'id', 'account_id');
+
+CREATE TRIGGER "flagged_transfers_tenant" BEFORE INSERT ON "flagged_transfers"
+FOR EACH ROW EXECUTE FUNCTION inherit_tenant('transfers', 'id', 'transfer_id');
+
+CREATE TRIGGER "transfer_limits_tenant" BEFORE INSERT ON "transfer_limits"
+FOR EACH ROW EXECUTE FUNCTION inherit_tenant('accounts', 'id', 'account_id', 'users', 'username', 'owner');
+
+CREATE TRIGGER "daily_balances_tenant" BEFORE INSERT ON "daily_balances"
+FOR EACH ROW EXECUTE FUNCTION inherit_tenant('accounts', 'id', 'account_id');
+
+CREATE TRIGGER "sessions_tenant" BEFORE INSERT ON "sessions"
+FOR EACH ROW EXECUTE FUNCTION inherit_tenant('users', 'username', 'username');
+
+CREATE TRIGGER "verify_emails_tenant" BEFORE INSERT ON "verify_emails"
+FOR EACH ROW EXECUTE FUNCTION inherit_tenant('users', 'username', 'username');
+
+CREATE TRIGGER "webhook_deliveries_tenant" BEFORE INSERT ON "webhook_deliveries"
+FOR EACH ROW EXECUTE FUNCTION inherit_tenant('webhook_subscriptions', 'id', 'subscription_id');
+
+-- the audit trail and the review queue are read a tenant at a time
+CREATE INDEX ON "audit_logs" ("tenant_id", "id");
+CREATE INDEX ON "flagged_transfers" ("tenant_id", "status");
+
+ALTER TABLE "audit_logs" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "audit_logs" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "audit_logs"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
+
+ALTER TABLE "flagged_transfers" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "flagged_transfers" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "flagged_transfers"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
+
+ALTER TABLE "transfer_limits" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "transfer_limits" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "transfer_limits"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
+
+ALTER TABLE "daily_balances" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "daily_balances" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "daily_balances"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
+
+ALTER TABLE "sessions" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "sessions" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "sessions"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
+
+ALTER TABLE "verify_emails" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "verify_emails" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "verify_emails"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
+
+ALTER TABLE "webhook_deliveries" ENABLE ROW LEVEL SECURITY;
+ALTER TABLE "webhook_deliveries" FORCE ROW LEVEL SECURITY;
+CREATE POLICY "tenant_isolation" ON "webhook_deliveries"
+  USING (current_tenant() IS NULL OR "tenant_id" = current_tenant());
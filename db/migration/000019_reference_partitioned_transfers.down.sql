@@ -0,0 +1,22 @@
+CREATE OR REPLACE FUNCTION "detach_monthly_partition"(parent text, in_month date) RETURNS boolean AS $$
+DECLARE
+  part text := monthly_partition_name(parent, in_month - (extract(day FROM in_month)::int - 1));
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM pg_inherits
+    WHERE inhrelid = to_regclass(part) AND inhparent = to_regclass(parent)
+  ) THEN
+    RETURN false;
+  END IF;
+  EXECUTE format('ALTER TABLE %I DETACH PARTITION %I', parent, part);
+  RETURN true;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE "transfer_reversals" DROP CONSTRAINT IF EXISTS "transfer_reversals_reversal_fkey";
+ALTER TABLE "transfer_reversals" DROP CONSTRAINT IF EXISTS "transfer_reversals_transfer_fkey";
+ALTER TABLE "flagged_transfers" DROP CONSTRAINT IF EXISTS "flagged_transfers_transfer_fkey";
+
+ALTER TABLE "transfer_reversals" DROP COLUMN IF EXISTS "reversal_created_at";
+ALTER TABLE "transfer_reversals" DROP COLUMN IF EXISTS "transfer_created_at";
+ALTER TABLE "flagged_transfers" DROP COLUMN IF EXISTS "transfer_created_at";
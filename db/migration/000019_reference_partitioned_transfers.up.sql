@@ -0,0 +1,48 @@
+-- a foreign key into the partitioned transfers must name their whole primary key, so the rows that reference
+-- a transfer also record when it was created and get back the foreign keys dropped when transfers were partitioned
+ALTER TABLE "flagged_transfers" ADD COLUMN "transfer_created_at" timestamptz;
+ALTER TABLE "transfer_reversals" ADD COLUMN "transfer_created_at" timestamptz;
+ALTER TABLE "transfer_reversals" ADD COLUMN "reversal_created_at" timestamptz;
+
+UPDATE "flagged_transfers" SET "transfer_created_at" = t."created_at"
+FROM "transfers" t WHERE t."id" = "flagged_transfers"."transfer_id";
+UPDATE "transfer_reversals" SET "transfer_created_at" = t."created_at"
+FROM "transfers" t WHERE t."id" = "transfer_reversals"."transfer_id";
+UPDATE "transfer_reversals" SET "reversal_created_at" = t."created_at"
+FROM "transfers" t WHERE t."id" = "transfer_reversals"."reversal_id";
+
+-- the keys match simple: a reference whose created_at is NULL, because its transfer was already detached, is not checked
+ALTER TABLE "flagged_transfers" ADD CONSTRAINT "flagged_transfers_transfer_fkey"
+FOREIGN KEY ("transfer_id", "transfer_created_at") REFERENCES "transfers" ("id", "created_at");
+ALTER TABLE "transfer_reversals" ADD CONSTRAINT "transfer_reversals_transfer_fkey"
+FOREIGN KEY ("transfer_id", "transfer_created_at") REFERENCES "transfers" ("id", "created_at");
+ALTER TABLE "transfer_reversals" ADD CONSTRAINT "transfer_reversals_reversal_fkey"
+FOREIGN KEY ("reversal_id", "reversal_created_at") REFERENCES "transfers" ("id", "created_at");
+
+-- a partition referenced by a foreign key cannot be detached, so the references into a month of transfers
+-- lose their created_at first: they keep naming the transfer, which moves to the archive, but are no longer checked
+CREATE OR REPLACE FUNCTION "detach_monthly_partition"(parent text, in_month date) RETURNS boolean AS $$
+DECLARE
+  first_day date := in_month - (extract(day FROM in_month)::int - 1);
+  part text := monthly_partition_name(parent, first_day);
+  starts_at timestamptz := first_day::timestamp AT TIME ZONE 'UTC';
+  ends_at timestamptz := (first_day + interval '1 month')::timestamp AT TIME ZONE 'UTC';
+BEGIN
+  IF NOT EXISTS (
+    SELECT 1 FROM pg_inherits
+    WHERE inhrelid = to_regclass(part) AND inhparent = to_regclass(parent)
+  ) THEN
+    RETURN false;
+  END IF;
+  IF parent = 'transfers' THEN
+    UPDATE "flagged_transfers" SET "transfer_created_at" = NULL
+    WHERE "transfer_created_at" >= starts_at AND "transfer_created_at" < ends_at;
+    UPDATE "transfer_reversals" SET "transfer_created_at" = NULL
+    WHERE "transfer_created_at" >= starts_at AND "transfer_created_at" < ends_at;
+    UPDATE "transfer_reversals" SET "reversal_created_at" = NULL
+    WHERE "reversal_created_at" >= starts_at AND "reversal_created_at" < ends_at;
+  END IF;
+  EXECUTE format('ALTER TABLE %I DETACH PARTITION %I', parent, part);
+  RETURN true;
+END;
+$$ LANGUAGE plpgsql;
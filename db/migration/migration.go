@@ -0,0 +1,81 @@
+// Package migration embeds the schema migrations applied by golang-migrate,
+// so the server can tell whether the database it talks to is up to date.
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// FS holds the up and down migrations, named like 000001_init_schema.up.sql
+//
+//go:embed *.sql
+var FS embed.FS
+
+// Latest returns the version of the newest embedded migration
+func Latest() (uint, error) {
+	return latest(FS)
+}
+
+func latest(fsys fs.FS) (uint, error) {
+	files, err := fs.Glob(fsys, "*.up.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var version uint
+	for _, file := range files {
+		prefix, _, ok := strings.Cut(file, "_")
+		if !ok {
+			return 0, fmt.Errorf("migration %s has no version prefix", file)
+		}
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s has an invalid version: %w", file, err)
+		}
+		version = max(version, uint(v))
+	}
+	return version, nil
+}
+
+// Status compares the database's schema with the embedded migrations
+type Status struct {
+	Current uint `json:"current"`
+	Latest  uint `json:"latest"`
+	// Dirty is set by golang-migrate when a migration failed halfway
+	Dirty bool `json:"dirty"`
+}
+
+// UpToDate reports whether every embedded migration was applied cleanly
+func (s Status) UpToDate() bool {
+	return !s.Dirty && s.Current >= s.Latest
+}
+
+// CurrentStatus reads the version golang-migrate recorded in schema_migrations
+func CurrentStatus(ctx context.Context, db *sql.DB) (Status, error) {
+	var status Status
+	var err error
+
+	status.Latest, err = Latest()
+	if err != nil {
+		return status, err
+	}
+
+	var version int64
+	err = db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &status.Dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		//migrate has created its table but applied nothing yet
+		return status, nil
+	}
+	if err != nil {
+		return status, fmt.Errorf("cannot read schema version: %w", err)
+	}
+	status.Current = uint(version)
+	return status, nil
+}
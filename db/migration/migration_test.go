@@ -0,0 +1,32 @@
+package migration
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatest(t *testing.T) {
+	version, err := Latest()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, version, uint(7))
+
+	version, err = latest(fstest.MapFS{
+		"000001_init.up.sql":   {},
+		"000001_init.down.sql": {},
+		"000012_next.up.sql":   {},
+		"000012_next.down.sql": {},
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint(12), version)
+
+	_, err = latest(fstest.MapFS{"init.up.sql": {}})
+	require.Error(t, err)
+}
+
+func TestStatusUpToDate(t *testing.T) {
+	require.True(t, Status{Current: 7, Latest: 7}.UpToDate())
+	require.False(t, Status{Current: 6, Latest: 7}.UpToDate())
+	require.False(t, Status{Current: 7, Latest: 7, Dirty: true}.UpToDate())
+}
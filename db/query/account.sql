@@ -2,16 +2,36 @@
 INSERT INTO accounts (
   owner, 
   balance, 
-  currency
+  currency,
+  product
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, COALESCE(sqlc.narg(product), 'checking')
 )
 RETURNING *;
 
+-- name: CreateInternalAccount :one
+-- returns no row when the tenant already holds the account, which may have been created concurrently
+INSERT INTO accounts (
+  owner,
+  balance,
+  currency,
+  product,
+  tenant_id
+) VALUES (
+  '', 0, $1, $2, $3
+)
+ON CONFLICT (tenant_id, product, currency) WHERE owner = '' DO NOTHING
+RETURNING *;
+
 -- name: GetAccount :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- name: GetInternalAccount :one
+SELECT * FROM accounts
+WHERE tenant_id = $1 AND product = $2 AND currency = $3 AND owner = ''
+LIMIT 1;
+
 -- name: GetAccountForUpdate :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1
@@ -40,4 +60,46 @@ RETURNING *;
 
 -- name: DeleteAccount :exec
 DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: ListAccountsByOwner :many
+SELECT * FROM accounts
+WHERE owner = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: SetAccountFrozen :one
+UPDATE accounts
+SET is_frozen = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: ListCurrencyTotals :many
+SELECT
+  currency,
+  count(*) AS account_count,
+  sum(balance)::bigint AS total_balance
+FROM accounts
+GROUP BY currency
+ORDER BY currency;
+
+-- name: ListBalanceMismatches :many
+-- accounts whose balance is not the sum of their entries; an account opened with a non-zero balance shows its opening balance as the difference.
+-- Archived entries count through their monthly totals.
+SELECT
+  a.id,
+  a.owner,
+  a.currency,
+  a.balance,
+  (COALESCE(sum(e.amount), 0) + COALESCE(t.amount_total, 0))::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+LEFT JOIN (
+  SELECT account_id, sum(amount_total) AS amount_total
+  FROM archived_entry_totals
+  GROUP BY account_id
+) t ON t.account_id = a.id
+GROUP BY a.id, t.amount_total
+HAVING a.balance <> COALESCE(sum(e.amount), 0) + COALESCE(t.amount_total, 0)
+ORDER BY a.id;
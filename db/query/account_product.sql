@@ -0,0 +1,21 @@
+-- name: GetAccountProduct :one
+SELECT * FROM account_products
+WHERE code = $1 LIMIT 1;
+
+-- name: ListAccountProducts :many
+SELECT * FROM account_products
+ORDER BY internal, category, code;
+
+-- name: ListProductTotals :many
+-- the trial balance: what the accounts of each product hold per currency, in the ledger's sign
+SELECT
+  p.code,
+  p.category,
+  p.normal_balance,
+  a.currency,
+  count(*) AS account_count,
+  sum(a.balance)::bigint AS total_balance
+FROM accounts a
+JOIN account_products p ON p.code = a.product
+GROUP BY p.code, a.currency
+ORDER BY p.category, p.code, a.currency;
@@ -0,0 +1,46 @@
+-- name: GetArchivedPeriod :one
+SELECT * FROM archived_periods
+WHERE table_name = $1 AND month = $2 LIMIT 1;
+
+-- name: ListArchivedPeriods :many
+-- the archived months of a table overlapping [since, until), oldest first; months are bounded in UTC like the partitions
+SELECT * FROM archived_periods
+WHERE table_name = sqlc.arg(table_name)
+  AND (month::timestamp AT TIME ZONE 'UTC') < sqlc.arg(until)::timestamptz
+  AND ((month + interval '1 month') AT TIME ZONE 'UTC') > sqlc.arg(since)::timestamptz
+ORDER BY month;
+
+-- name: CreateArchivedPeriod :one
+INSERT INTO archived_periods (
+  table_name,
+  month,
+  object_key,
+  row_count,
+  amount_total,
+  sha256
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: ListArchivedEntryTotals :many
+-- what the archived entries of whole months from from_month up to, but excluding, to_month add to each account
+SELECT account_id, sum(amount_total)::bigint AS amount_total
+FROM archived_entry_totals
+WHERE account_id = ANY(sqlc.arg(account_ids)::bigint[])
+  AND month >= sqlc.arg(from_month)::date
+  AND month < sqlc.arg(to_month)::date
+GROUP BY account_id
+ORDER BY account_id;
+
+-- name: ListPartitionTables :many
+-- the monthly partitions of parent, attached or detached, oldest first
+SELECT relname::text AS name
+FROM pg_class
+WHERE relkind = 'r'
+  AND relname ~ ('^' || sqlc.arg(parent)::text || '_y[0-9]{4}m[0-9]{2}$')
+  AND pg_table_is_visible(oid)
+ORDER BY relname;
+
+-- name: DropMonthlyPartition :one
+SELECT drop_monthly_partition(sqlc.arg(parent)::text, sqlc.arg(month)::date)::boolean AS dropped;
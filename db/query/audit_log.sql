@@ -0,0 +1,25 @@
+-- name: CreateAuditLog :one
+-- a log of an account takes the account's tenant; tenant_id places any other one, defaulting to the session's
+INSERT INTO audit_logs (
+  actor,
+  action,
+  account_id,
+  details,
+  tenant_id
+) VALUES (
+  $1, $2, $3, $4, COALESCE(sqlc.narg(tenant_id)::varchar, current_tenant(), 'default')
+)
+RETURNING *;
+
+-- name: ListAuditLogs :many
+SELECT * FROM audit_logs
+ORDER BY id DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: ListAccountAuditLogs :many
+SELECT * FROM audit_logs
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
@@ -0,0 +1,29 @@
+-- name: ListAccountBalancesAt :many
+-- the balance at a point in time is the current balance minus everything posted after it;
+-- summing the entries up to it instead would miss the opening balance, which has no entry.
+-- Accounts that did not exist yet at the given time are left out.
+SELECT
+  a.id AS account_id,
+  a.owner,
+  a.currency,
+  (a.balance - COALESCE(sum(e.amount), 0))::bigint AS balance
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id AND e.created_at > sqlc.arg(at)
+WHERE a.id = ANY(sqlc.arg(account_ids)::bigint[]) AND a.created_at <= sqlc.arg(at)
+GROUP BY a.id
+ORDER BY a.id;
+
+-- name: ListAccountBalancesFromSnapshot :many
+-- starts from the accounts' snapshots of a closed day and adds what was posted from the day's close up to at,
+-- so the entries read do not grow with the distance to the present; accounts without a snapshot that day are left out
+SELECT
+  a.id AS account_id,
+  a.owner,
+  a.currency,
+  (d.balance + COALESCE(sum(e.amount), 0))::bigint AS balance
+FROM accounts a
+JOIN daily_balances d ON d.account_id = a.id AND d.business_date = sqlc.arg(business_date)
+LEFT JOIN entries e ON e.account_id = a.id AND e.created_at >= sqlc.arg(since) AND e.created_at <= sqlc.arg(at)
+WHERE a.id = ANY(sqlc.arg(account_ids)::bigint[])
+GROUP BY a.id, d.balance
+ORDER BY a.id;
@@ -0,0 +1,34 @@
+-- name: LockEntries :exec
+-- waits for the transactions posting entries to finish and holds off new ones until the close commits
+LOCK TABLE entries IN SHARE MODE;
+
+-- name: GetLatestBusinessDay :one
+SELECT * FROM business_days
+ORDER BY business_date DESC
+LIMIT 1;
+
+-- name: GetBusinessDayBefore :one
+-- the latest day that had ended by the given time
+SELECT * FROM business_days
+WHERE closes_at <= $1
+ORDER BY business_date DESC
+LIMIT 1;
+
+-- name: CreateBusinessDay :one
+INSERT INTO business_days (
+  business_date,
+  closes_at,
+  closed_by
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: CreateDailyBalances :execrows
+-- snapshots every account opened before the day ended, with the same arithmetic as ListAccountBalancesAt
+INSERT INTO daily_balances (account_id, business_date, balance)
+SELECT a.id, sqlc.arg(business_date), (a.balance - COALESCE(sum(e.amount), 0))::bigint
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id AND e.created_at >= sqlc.arg(closes_at)
+WHERE a.created_at < sqlc.arg(closes_at)
+GROUP BY a.id;
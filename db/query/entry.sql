@@ -1,9 +1,12 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  description,
+  external_reference,
+  metadata
 ) VALUES (
-  $1, $2
+  $1, $2, $3, $4, $5
 )
 RETURNING *;
 
@@ -25,4 +28,20 @@ RETURNING *;
 
 -- name: DeleteEntry :exec
 DELETE FROM entries
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: ListAccountEntries :many
+SELECT * FROM entries
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListAccountEntriesBetween :many
+-- the created_at range lets Postgres skip every monthly partition outside it
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(since)
+  AND created_at < sqlc.arg(until)
+ORDER BY created_at, id
+LIMIT sqlc.arg(max_entries);
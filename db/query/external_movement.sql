@@ -0,0 +1,50 @@
+-- name: CreateExternalMovement :one
+INSERT INTO external_movements (
+  tenant_id,
+  kind,
+  account_id,
+  amount,
+  currency,
+  external_reference,
+  pending_transfer_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetExternalMovement :one
+SELECT * FROM external_movements
+WHERE id = $1 LIMIT 1;
+
+-- name: GetExternalMovementForUpdate :one
+SELECT * FROM external_movements
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetExternalMovementByReference :one
+SELECT * FROM external_movements
+WHERE tenant_id = $1 AND external_reference = $2 LIMIT 1;
+
+-- name: FinishExternalMovement :one
+UPDATE external_movements
+SET
+  status = $2,
+  failure_reason = $3,
+  final_transfer_id = $4,
+  finished_at = now()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: ListAccountExternalMovements :many
+SELECT * FROM external_movements
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ListPendingExternalMovements :many
+-- movements still waiting for the payment network, oldest first
+SELECT * FROM external_movements
+WHERE status = 'pending' AND created_at < sqlc.arg(before)
+ORDER BY id
+LIMIT sqlc.arg(max_count);
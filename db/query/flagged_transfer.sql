@@ -0,0 +1,36 @@
+-- name: CreateFlaggedTransfer :one
+INSERT INTO flagged_transfers (
+  transfer_id,
+  transfer_created_at,
+  rule,
+  reason
+) VALUES (
+  sqlc.arg(transfer_id), sqlc.arg(transfer_created_at)::timestamptz, sqlc.arg(rule), sqlc.arg(reason)
+)
+RETURNING *;
+
+-- name: GetFlaggedTransfer :one
+SELECT * FROM flagged_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: ListFlaggedTransfers :many
+SELECT * FROM flagged_transfers
+WHERE status = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3;
+
+-- name: ResolveFlaggedTransfer :one
+-- only a pending hold can be resolved, so two analysts cannot both settle it
+UPDATE flagged_transfers
+SET
+  status = sqlc.arg(status),
+  reviewed_by = sqlc.arg(reviewed_by),
+  review_note = sqlc.arg(review_note),
+  reviewed_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending'
+RETURNING *;
+
+-- name: GetTransferFlag :one
+SELECT * FROM flagged_transfers
+WHERE transfer_id = $1 LIMIT 1;
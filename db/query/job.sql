@@ -0,0 +1,122 @@
+-- name: EnqueueJob :one
+INSERT INTO jobs (
+  type,
+  payload,
+  priority,
+  max_attempts,
+  run_at
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs
+WHERE id = $1 LIMIT 1;
+
+-- name: ClaimJobs :many
+-- SKIP LOCKED lets many workers poll the same table without blocking on each other's claims;
+-- a worker only claims the task types it has handlers for
+UPDATE jobs
+SET
+  status = 'running',
+  attempts = attempts + 1,
+  locked_at = now(),
+  locked_by = sqlc.arg(worker)::varchar,
+  updated_at = now()
+WHERE id IN (
+  SELECT id FROM jobs
+  WHERE status = 'pending' AND run_at <= now() AND type = ANY(sqlc.arg(types)::varchar[])
+  ORDER BY priority DESC, run_at
+  LIMIT sqlc.arg(batch_size)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteJob :execrows
+-- a job is only finished by the run that holds its lease: the worker that claimed it, with the attempt count
+-- of that claim. A run whose lease expired updates nothing, as the job went back to the queue without it.
+UPDATE jobs
+SET
+  status = 'done',
+  locked_at = NULL,
+  last_error = NULL,
+  updated_at = now()
+WHERE id = sqlc.arg(id)
+  AND status = 'running'
+  AND locked_by = sqlc.arg(worker)::varchar
+  AND attempts = sqlc.arg(attempts);
+
+-- name: RetryJob :execrows
+UPDATE jobs
+SET
+  status = 'pending',
+  run_at = sqlc.arg(run_at),
+  last_error = sqlc.arg(last_error),
+  locked_at = NULL,
+  locked_by = NULL,
+  updated_at = now()
+WHERE id = sqlc.arg(id)
+  AND status = 'running'
+  AND locked_by = sqlc.arg(worker)::varchar
+  AND attempts = sqlc.arg(attempts);
+
+-- name: BuryJob :execrows
+UPDATE jobs
+SET
+  status = 'dead',
+  last_error = sqlc.arg(last_error),
+  locked_at = NULL,
+  updated_at = now()
+WHERE id = sqlc.arg(id)
+  AND status = 'running'
+  AND locked_by = sqlc.arg(worker)::varchar
+  AND attempts = sqlc.arg(attempts);
+
+-- name: RequeueStuckJobs :execrows
+-- jobs whose worker died mid-run go back to the queue once their lease has expired.
+-- The lost run used up the attempt counted when it was claimed, so a job that keeps taking its worker down
+-- is buried once it is out of attempts instead of being requeued forever.
+UPDATE jobs
+SET
+  status = CASE WHEN attempts >= max_attempts THEN 'dead' ELSE 'pending' END,
+  last_error = 'lease expired',
+  locked_at = NULL,
+  locked_by = NULL,
+  updated_at = now()
+WHERE status = 'running' AND locked_at < $1;
+
+-- name: ListDeadJobs :many
+SELECT * FROM jobs
+WHERE status = 'dead'
+ORDER BY id
+LIMIT $1
+OFFSET $2;
+
+-- name: ResurrectJob :one
+UPDATE jobs
+SET
+  status = 'pending',
+  attempts = 0,
+  run_at = now(),
+  last_error = NULL,
+  updated_at = now()
+WHERE id = $1 AND status = 'dead'
+RETURNING *;
+
+-- name: LockJobType :exec
+-- serializes the transactions that enqueue a task type until they commit
+SELECT pg_advisory_xact_lock(hashtext(sqlc.arg(job_type)::text));
+
+-- name: HasPendingJob :one
+SELECT EXISTS (
+  SELECT 1 FROM jobs
+  WHERE type = $1 AND status = 'pending'
+);
+
+-- name: CountJobsSince :one
+-- counts the jobs of a type enqueued since a time whose payload contains the given fields
+SELECT count(*) FROM jobs
+WHERE type = sqlc.arg(type)
+  AND payload @> sqlc.arg(payload)::jsonb
+  AND created_at >= sqlc.arg(since);
@@ -0,0 +1,13 @@
+-- name: CreateMonthlyPartition :one
+SELECT create_monthly_partition(sqlc.arg(parent)::text, sqlc.arg(month)::date)::boolean AS created;
+
+-- name: DetachMonthlyPartition :one
+SELECT detach_monthly_partition(sqlc.arg(parent)::text, sqlc.arg(month)::date)::boolean AS detached;
+
+-- name: ListMonthlyPartitions :many
+-- the partitions attached to parent, oldest first since their names end in the month
+SELECT c.relname::text AS name
+FROM pg_inherits i
+JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = sqlc.arg(parent)::text::regclass
+ORDER BY c.relname;
@@ -0,0 +1,21 @@
+-- name: CreateTenant :one
+INSERT INTO tenants (
+  id,
+  name,
+  host
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetTenant :one
+SELECT * FROM tenants
+WHERE id = $1 LIMIT 1;
+
+-- name: GetTenantByHost :one
+SELECT * FROM tenants
+WHERE host = $1 LIMIT 1;
+
+-- name: ListTenants :many
+SELECT * FROM tenants
+ORDER BY id;
@@ -2,9 +2,12 @@
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  description,
+  external_reference,
+  metadata
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 )
 RETURNING *;
 
@@ -26,4 +29,25 @@ RETURNING *;
 
 -- name: DeleteTransfer :exec
 DELETE FROM transfers
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: ListTransfersByReference :many
+-- an empty owner lists every transfer with the reference, any other only those from or to the owner's accounts
+SELECT t.* FROM transfers t
+WHERE t.external_reference = sqlc.arg(external_reference)
+  AND (sqlc.arg(owner)::varchar = '' OR EXISTS (
+    SELECT 1 FROM accounts a
+    WHERE a.id IN (t.from_account_id, t.to_account_id) AND a.owner = sqlc.arg(owner)
+  ))
+ORDER BY t.created_at, t.id
+LIMIT sqlc.arg(max_transfers);
+
+-- name: ListOutboundTransfersSince :many
+SELECT * FROM transfers
+WHERE from_account_id = $1 AND created_at >= $2
+ORDER BY created_at;
+
+-- name: ListInboundTransfersSince :many
+SELECT * FROM transfers
+WHERE to_account_id = $1 AND created_at >= $2
+ORDER BY created_at;
@@ -0,0 +1,47 @@
+-- name: CreateTransferLimit :one
+INSERT INTO transfer_limits (
+  account_id,
+  owner,
+  currency,
+  max_single_amount,
+  daily_outbound_amount,
+  monthly_outbound_amount,
+  hourly_transfer_count
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetAccountTransferLimit :one
+SELECT * FROM transfer_limits
+WHERE account_id = $1 LIMIT 1;
+
+-- name: GetOwnerTransferLimit :one
+SELECT * FROM transfer_limits
+WHERE owner = $1 AND currency = $2 LIMIT 1;
+
+-- name: DeleteTransferLimit :exec
+DELETE FROM transfer_limits
+WHERE id = $1;
+
+-- name: GetAccountOutboundSince :one
+SELECT
+  COALESCE(SUM(amount), 0)::bigint AS total_amount,
+  COUNT(*) AS transfer_count
+FROM transfers
+WHERE from_account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(since);
+
+-- name: GetOwnerOutboundSince :one
+SELECT
+  COALESCE(SUM(t.amount), 0)::bigint AS total_amount,
+  COUNT(*) AS transfer_count
+FROM transfers t
+JOIN accounts a ON a.id = t.from_account_id
+WHERE a.owner = sqlc.arg(owner)
+  AND a.currency = sqlc.arg(currency)
+  AND t.created_at >= sqlc.arg(since);
+
+-- name: LockOwnerTransfers :exec
+-- serialises owner-level limit checks across all of the owner's accounts
+SELECT pg_advisory_xact_lock(hashtext(sqlc.arg(owner)::text || '/' || sqlc.arg(currency)::text));
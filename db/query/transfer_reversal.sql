@@ -0,0 +1,18 @@
+-- name: CreateTransferReversal :one
+INSERT INTO transfer_reversals (
+  transfer_id,
+  transfer_created_at,
+  reversal_id,
+  reversal_created_at,
+  actor,
+  reason
+) VALUES (
+  sqlc.arg(transfer_id), sqlc.arg(transfer_created_at)::timestamptz,
+  sqlc.arg(reversal_id), sqlc.arg(reversal_created_at)::timestamptz,
+  sqlc.arg(actor), sqlc.arg(reason)
+)
+RETURNING *;
+
+-- name: GetTransferReversal :one
+SELECT * FROM transfer_reversals
+WHERE transfer_id = $1 LIMIT 1;
@@ -0,0 +1,27 @@
+-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+  username,
+  email,
+  secret_code
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: UseVerifyEmail :one
+-- a code works once and only until it expires
+UPDATE verify_emails
+SET is_used = true
+WHERE id = sqlc.arg(id)
+  AND secret_code = sqlc.arg(secret_code)
+  AND is_used = false
+  AND expired_at > now()
+RETURNING *;
+
+-- name: ExpireVerifyEmails :execrows
+-- a new code replaces the ones sent before it
+UPDATE verify_emails
+SET expired_at = now()
+WHERE username = $1
+  AND is_used = false
+  AND expired_at > now();
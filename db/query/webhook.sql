@@ -0,0 +1,86 @@
+-- name: CreateWebhookSubscription :one
+INSERT INTO webhook_subscriptions (
+  owner,
+  url,
+  event_types,
+  secret
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetWebhookSubscription :one
+SELECT * FROM webhook_subscriptions
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookSubscriptions :many
+SELECT * FROM webhook_subscriptions
+WHERE owner = $1
+ORDER BY id;
+
+-- name: ListActiveWebhookSubscriptions :many
+SELECT * FROM webhook_subscriptions
+WHERE owner = ANY(sqlc.arg(owners)::varchar[]) AND disabled_at IS NULL
+ORDER BY id;
+
+-- name: DeleteWebhookSubscription :execrows
+DELETE FROM webhook_subscriptions
+WHERE id = $1 AND owner = $2;
+
+-- name: EnableWebhookSubscription :one
+UPDATE webhook_subscriptions
+SET disabled_at = NULL, failure_count = 0
+WHERE id = $1
+RETURNING *;
+
+-- name: RecordWebhookSuccess :exec
+UPDATE webhook_subscriptions
+SET failure_count = 0
+WHERE id = $1;
+
+-- name: RecordWebhookFailure :one
+-- counts a failed attempt and disables the subscription once max_failures attempts in a row have failed
+UPDATE webhook_subscriptions
+SET
+  failure_count = failure_count + 1,
+  disabled_at = CASE
+    WHEN disabled_at IS NULL AND failure_count + 1 >= sqlc.arg(max_failures)::int THEN now()
+    ELSE disabled_at
+  END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+  subscription_id,
+  event_id,
+  event_type,
+  account_id,
+  payload,
+  replay_of
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: ListWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE subscription_id = $1
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: UpdateWebhookDelivery :one
+UPDATE webhook_deliveries
+SET
+  status = $2,
+  attempts = $3,
+  response_status = $4,
+  last_error = $5,
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
@@ -7,13 +7,14 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
 SET balance = balance + $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, is_frozen, tenant_id, product
 `
 
 type AddAccountBalanceParams struct {
@@ -30,6 +31,9 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.IsFrozen,
+		&i.TenantID,
+		&i.Product,
 	)
 	return i, err
 }
@@ -38,21 +42,65 @@ const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
   owner, 
   balance, 
-  currency
+  currency,
+  product
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, COALESCE($4, 'checking')
 )
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, is_frozen, tenant_id, product
 `
 
 type CreateAccountParams struct {
-	Owner    string `json:"owner"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	Owner    string         `json:"owner"`
+	Balance  int64          `json:"balance"`
+	Currency string         `json:"currency"`
+	Product  sql.NullString `json:"product"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRowContext(ctx, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.Product,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.IsFrozen,
+		&i.TenantID,
+		&i.Product,
+	)
+	return i, err
+}
+
+const createInternalAccount = `-- name: CreateInternalAccount :one
+INSERT INTO accounts (
+  owner,
+  balance,
+  currency,
+  product,
+  tenant_id
+) VALUES (
+  '', 0, $1, $2, $3
+)
+ON CONFLICT (tenant_id, product, currency) WHERE owner = '' DO NOTHING
+RETURNING id, owner, balance, currency, created_at, is_frozen, tenant_id, product
+`
+
+type CreateInternalAccountParams struct {
+	Currency string `json:"currency"`
+	Product  string `json:"product"`
+	TenantID string `json:"tenant_id"`
+}
+
+// returns no row when the tenant already holds the account, which may have been created concurrently
+func (q *Queries) CreateInternalAccount(ctx context.Context, arg CreateInternalAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, createInternalAccount, arg.Currency, arg.Product, arg.TenantID)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -60,6 +108,9 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.IsFrozen,
+		&i.TenantID,
+		&i.Product,
 	)
 	return i, err
 }
@@ -75,7 +126,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, is_frozen, tenant_id, product FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -88,12 +139,15 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.IsFrozen,
+		&i.TenantID,
+		&i.Product,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, is_frozen, tenant_id, product FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -107,12 +161,43 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.IsFrozen,
+		&i.TenantID,
+		&i.Product,
+	)
+	return i, err
+}
+
+const getInternalAccount = `-- name: GetInternalAccount :one
+SELECT id, owner, balance, currency, created_at, is_frozen, tenant_id, product FROM accounts
+WHERE tenant_id = $1 AND product = $2 AND currency = $3 AND owner = ''
+LIMIT 1
+`
+
+type GetInternalAccountParams struct {
+	TenantID string `json:"tenant_id"`
+	Product  string `json:"product"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetInternalAccount(ctx context.Context, arg GetInternalAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getInternalAccount, arg.TenantID, arg.Product, arg.Currency)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.IsFrozen,
+		&i.TenantID,
+		&i.Product,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, is_frozen, tenant_id, product FROM accounts
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -140,6 +225,113 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.IsFrozen,
+			&i.TenantID,
+			&i.Product,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsByOwner = `-- name: ListAccountsByOwner :many
+SELECT id, owner, balance, currency, created_at, is_frozen, tenant_id, product FROM accounts
+WHERE owner = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListAccountsByOwnerParams struct {
+	Owner  string `json:"owner"`
+	Limit  int32  `json:"limit"`
+	Offset int32  `json:"offset"`
+}
+
+func (q *Queries) ListAccountsByOwner(ctx context.Context, arg ListAccountsByOwnerParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsByOwner, arg.Owner, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Account
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.IsFrozen,
+			&i.TenantID,
+			&i.Product,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBalanceMismatches = `-- name: ListBalanceMismatches :many
+SELECT
+  a.id,
+  a.owner,
+  a.currency,
+  a.balance,
+  (COALESCE(sum(e.amount), 0) + COALESCE(t.amount_total, 0))::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+LEFT JOIN (
+  SELECT account_id, sum(amount_total) AS amount_total
+  FROM archived_entry_totals
+  GROUP BY account_id
+) t ON t.account_id = a.id
+GROUP BY a.id, t.amount_total
+HAVING a.balance <> COALESCE(sum(e.amount), 0) + COALESCE(t.amount_total, 0)
+ORDER BY a.id
+`
+
+type ListBalanceMismatchesRow struct {
+	ID           int64  `json:"id"`
+	Owner        string `json:"owner"`
+	Currency     string `json:"currency"`
+	Balance      int64  `json:"balance"`
+	EntriesTotal int64  `json:"entries_total"`
+}
+
+// accounts whose balance is not the sum of their entries; an account opened with a non-zero balance shows its opening balance as the difference.
+// Archived entries count through their monthly totals.
+func (q *Queries) ListBalanceMismatches(ctx context.Context) ([]ListBalanceMismatchesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listBalanceMismatches)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBalanceMismatchesRow
+	for rows.Next() {
+		var i ListBalanceMismatchesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Currency,
+			&i.Balance,
+			&i.EntriesTotal,
 		); err != nil {
 			return nil, err
 		}
@@ -154,11 +346,78 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 	return items, nil
 }
 
+const listCurrencyTotals = `-- name: ListCurrencyTotals :many
+SELECT
+  currency,
+  count(*) AS account_count,
+  sum(balance)::bigint AS total_balance
+FROM accounts
+GROUP BY currency
+ORDER BY currency
+`
+
+type ListCurrencyTotalsRow struct {
+	Currency     string `json:"currency"`
+	AccountCount int64  `json:"account_count"`
+	TotalBalance int64  `json:"total_balance"`
+}
+
+func (q *Queries) ListCurrencyTotals(ctx context.Context) ([]ListCurrencyTotalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCurrencyTotals)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCurrencyTotalsRow
+	for rows.Next() {
+		var i ListCurrencyTotalsRow
+		if err := rows.Scan(&i.Currency, &i.AccountCount, &i.TotalBalance); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setAccountFrozen = `-- name: SetAccountFrozen :one
+UPDATE accounts
+SET is_frozen = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, is_frozen, tenant_id, product
+`
+
+type SetAccountFrozenParams struct {
+	ID       int64 `json:"id"`
+	IsFrozen bool  `json:"is_frozen"`
+}
+
+func (q *Queries) SetAccountFrozen(ctx context.Context, arg SetAccountFrozenParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, setAccountFrozen, arg.ID, arg.IsFrozen)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.IsFrozen,
+		&i.TenantID,
+		&i.Product,
+	)
+	return i, err
+}
+
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, is_frozen, tenant_id, product
 `
 
 type UpdateAccountParams struct {
@@ -175,6 +434,9 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.IsFrozen,
+		&i.TenantID,
+		&i.Product,
 	)
 	return i, err
 }
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"goprojects/simplebank/db/dbtest"
 	"goprojects/simplebank/util"
 	"testing"
 	"time"
@@ -10,16 +11,17 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func createRandomAccount(t *testing.T) Account {
+func createRandomAccount(t *testing.T, q *Queries) Account {
 	// Initializes the parameters needed to create a new account.
+	// The balance covers the transfers the tests make, since checking accounts cannot be overdrawn.
 	arg := CreateAccountParams{
 		Owner:    util.RandomOwner(),
-		Balance:  util.RandomMoney(),
+		Balance:  util.RandomInt(1000, 2000),
 		Currency: util.RandomCurrency(),
 	}
 
 	// Interacts with the database to create an account and returns the created account object.
-	account, err := testQueries.CreateAccount(context.Background(), arg)
+	account, err := q.CreateAccount(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, account)
 
@@ -27,6 +29,7 @@ func createRandomAccount(t *testing.T) Account {
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, ProductChecking, account.Product)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -35,14 +38,16 @@ func createRandomAccount(t *testing.T) Account {
 }
 
 func TestCreateAccount(t *testing.T) {
+	q := New(dbtest.New(t))
 	// Creates a random account and checks if it’s successful.
-	createRandomAccount(t)
+	createRandomAccount(t, q)
 }
 
 func TestGetAccount(t *testing.T) {
+	q := New(dbtest.New(t))
 	// Creates an account and retrieves it by ID.
-	account1 := createRandomAccount(t)
-	account2, err := testQueries.GetAccount(context.Background(), account1.ID)
+	account1 := createRandomAccount(t, q)
+	account2, err := q.GetAccount(context.Background(), account1.ID)
 	require.NoError(t, err)
 	require.NotEmpty(t, account2)
 
@@ -55,8 +60,9 @@ func TestGetAccount(t *testing.T) {
 }
 
 func TestUpdateAccount(t *testing.T) {
+	q := New(dbtest.New(t))
 	// Creates a random account and updates its balance.
-	account1 := createRandomAccount(t)
+	account1 := createRandomAccount(t, q)
 
 	// Updates the balance of the account.
 	arg := UpdateAccountParams{
@@ -64,7 +70,7 @@ func TestUpdateAccount(t *testing.T) {
 		Balance: util.RandomMoney(),
 	}
 
-	account2, err := testQueries.UpdateAccount(context.Background(), arg)
+	account2, err := q.UpdateAccount(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, account2)
 
@@ -77,22 +83,24 @@ func TestUpdateAccount(t *testing.T) {
 }
 
 func TestDeleteAccount(t *testing.T) {
+	q := New(dbtest.New(t))
 	// Creates a random account and deletes it.
-	account1 := createRandomAccount(t)
-	err := testQueries.DeleteAccount(context.Background(), account1.ID)
+	account1 := createRandomAccount(t, q)
+	err := q.DeleteAccount(context.Background(), account1.ID)
 	require.NoError(t, err) // Ensure deletion was successful
 
 	// Tries to fetch the deleted account, expecting an error.
-	account2, err := testQueries.GetAccount(context.Background(), account1.ID)
+	account2, err := q.GetAccount(context.Background(), account1.ID)
 	require.Error(t, err)
 	require.EqualError(t, err, sql.ErrNoRows.Error()) // Expect sql.ErrNoRows after deletion
 	require.Empty(t, account2)
 }
 
 func TestListAccounts(t *testing.T) {
+	q := New(dbtest.New(t))
 	// Creates 10 random accounts.
 	for i := 0; i < 10; i++ {
-		createRandomAccount(t)
+		createRandomAccount(t, q)
 	}
 
 	// Lists accounts with a limit and offset.
@@ -101,7 +109,7 @@ func TestListAccounts(t *testing.T) {
 		Offset: 5,
 	}
 
-	accounts, err := testQueries.ListAccounts(context.Background(), arg)
+	accounts, err := q.ListAccounts(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, accounts, 5)
 
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// actions recorded in audit_logs
+const (
+	AuditFreezeAccount   = "freeze_account"
+	AuditUnfreezeAccount = "unfreeze_account"
+	AuditAdjustment      = "manual_adjustment"
+	AuditAssignRole      = "assign_role"
+)
+
+// ErrAccountFrozen is returned when money is moved into or out of a frozen account
+var ErrAccountFrozen = errors.New("account is frozen")
+
+// SetAccountFrozenTxParams contains the input parameters to freeze or unfreeze an account
+type SetAccountFrozenTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Frozen    bool   `json:"frozen"`
+	Actor     string `json:"actor"`
+	Reason    string `json:"reason"`
+}
+
+// SetAccountFrozenTx freezes or unfreezes an account and records who did it
+func (store *Store) SetAccountFrozenTx(ctx context.Context, arg SetAccountFrozenTxParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		account, err = q.SetAccountFrozen(ctx, SetAccountFrozenParams{
+			ID:       arg.AccountID,
+			IsFrozen: arg.Frozen,
+		})
+		if err != nil {
+			return err
+		}
+
+		action := AuditFreezeAccount
+		if !arg.Frozen {
+			action = AuditUnfreezeAccount
+		}
+		_, err = writeAuditLog(ctx, q, CreateAuditLogParams{
+			Actor:     arg.Actor,
+			Action:    action,
+			AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+		}, map[string]any{
+			"reason": arg.Reason,
+		})
+		return err
+	})
+
+	return account, err
+}
+
+// AdjustmentTxParams contains the input parameters of a manual ledger adjustment
+type AdjustmentTxParams struct {
+	AccountID int64 `json:"account_id"`
+	//positive amounts credit the account, negative amounts debit it
+	Amount int64  `json:"amount"`
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+// AdjustmentTxResult is the result of a manual ledger adjustment
+type AdjustmentTxResult struct {
+	Entry    Entry    `json:"entry"`
+	Account  Account  `json:"account"`
+	AuditLog AuditLog `json:"audit_log"`
+}
+
+// AdjustmentTx posts a one-sided entry to correct an account's balance.
+// It is the only way money enters or leaves the ledger without a transfer, so every adjustment is audited.
+func (store *Store) AdjustmentTx(ctx context.Context, arg AdjustmentTxParams) (AdjustmentTxResult, error) {
+	var result AdjustmentTxResult
+
+	if arg.Amount == 0 {
+		return result, errors.New("adjustment amount must not be zero")
+	}
+	if arg.Reason == "" {
+		return result, errors.New("adjustment reason is required")
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID:   arg.AccountID,
+			Amount:      arg.Amount,
+			Description: "adjustment",
+			Metadata:    noMetadata,
+		})
+		if err != nil {
+			return fmt.Errorf("AdjustmentTx - failed to create entry: %w", err)
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: arg.Amount,
+		})
+		if err != nil {
+			return fmt.Errorf("AdjustmentTx - failed to update account balance: %w", err)
+		}
+
+		result.AuditLog, err = writeAuditLog(ctx, q, CreateAuditLogParams{
+			Actor:     arg.Actor,
+			Action:    AuditAdjustment,
+			AccountID: sql.NullInt64{Int64: arg.AccountID, Valid: true},
+		}, map[string]any{
+			"entry_id": result.Entry.ID,
+			"amount":   arg.Amount,
+			"reason":   arg.Reason,
+		})
+		if err != nil {
+			return err
+		}
+		return store.publish(ctx, q, balanceEvent(result.Account))
+	})
+
+	return result, err
+}
+
+// UpdateUserRoleTxParams contains the input parameters to change a user's role
+type UpdateUserRoleTxParams struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Actor    string `json:"actor"`
+}
+
+// UpdateUserRoleTx changes a user's role and records who did it
+func (store *Store) UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		previous, err := q.GetUser(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		user, err = q.UpdateUserRole(ctx, UpdateUserRoleParams{
+			Username: arg.Username,
+			Role:     arg.Role,
+		})
+		if err != nil {
+			return err
+		}
+
+		//the change belongs to the user's tenant, also when a system process such as bankctl makes it
+		_, err = writeAuditLog(ctx, q, CreateAuditLogParams{
+			Actor:    arg.Actor,
+			Action:   AuditAssignRole,
+			TenantID: sql.NullString{String: user.TenantID, Valid: true},
+		}, map[string]any{
+			"username": arg.Username,
+			"from":     previous.Role,
+			"to":       arg.Role,
+		})
+		return err
+	})
+
+	return user, err
+}
+
+// writeAuditLog records arg with details as its JSON details
+func writeAuditLog(ctx context.Context, q *Queries, arg CreateAuditLogParams, details map[string]any) (AuditLog, error) {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return AuditLog{}, err
+	}
+
+	arg.Details = data
+	auditLog, err := q.CreateAuditLog(ctx, arg)
+	if err != nil {
+		return auditLog, fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return auditLog, nil
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+)
+
+func TestSetAccountFrozenTx(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+	require.False(t, account1.IsFrozen)
+
+	frozen, err := store.SetAccountFrozenTx(context.Background(), SetAccountFrozenTxParams{
+		AccountID: account1.ID,
+		Frozen:    true,
+		Actor:     "banker",
+		Reason:    "suspected compromise",
+	})
+	require.NoError(t, err)
+	require.True(t, frozen.IsFrozen)
+
+	// Money can neither leave nor enter a frozen account.
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	unfrozen, err := store.SetAccountFrozenTx(context.Background(), SetAccountFrozenTxParams{
+		AccountID: account1.ID,
+		Frozen:    false,
+		Actor:     "banker",
+		Reason:    "customer verified",
+	})
+	require.NoError(t, err)
+	require.False(t, unfrozen.IsFrozen)
+
+	logs, err := q.ListAccountAuditLogs(context.Background(), ListAccountAuditLogsParams{
+		AccountID: sql.NullInt64{Int64: frozen.ID, Valid: true},
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	require.Equal(t, AuditUnfreezeAccount, logs[0].Action)
+	require.Equal(t, AuditFreezeAccount, logs[1].Action)
+	require.Equal(t, "banker", logs[1].Actor)
+}
+
+func TestAdjustmentTx(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	account := createRandomAccount(t, q)
+
+	result, err := store.AdjustmentTx(context.Background(), AdjustmentTxParams{
+		AccountID: account.ID,
+		Amount:    -7,
+		Actor:     "admin",
+		Reason:    "fee refund reversed",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-7), result.Entry.Amount)
+	require.Equal(t, account.Balance-7, result.Account.Balance)
+	require.Equal(t, AuditAdjustment, result.AuditLog.Action)
+
+	var details map[string]any
+	require.NoError(t, json.Unmarshal(result.AuditLog.Details, &details))
+	require.Equal(t, "fee refund reversed", details["reason"])
+	require.EqualValues(t, result.Entry.ID, details["entry_id"])
+
+	_, err = store.AdjustmentTx(context.Background(), AdjustmentTxParams{
+		AccountID: account.ID,
+		Amount:    5,
+		Actor:     "admin",
+	})
+	require.Error(t, err)
+}
+
+func TestUpdateUserRoleTx(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	user := createRandomUser(t, q)
+	require.Equal(t, "depositor", user.Role)
+
+	updated, err := store.UpdateUserRoleTx(context.Background(), UpdateUserRoleTxParams{
+		Username: user.Username,
+		Role:     "banker",
+		Actor:    "admin",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "banker", updated.Role)
+
+	// The role check constraint rejects unknown roles.
+	_, err = store.UpdateUserRoleTx(context.Background(), UpdateUserRoleTxParams{
+		Username: user.Username,
+		Role:     "superuser",
+		Actor:    "admin",
+	})
+	require.Error(t, err)
+}
+
+func TestListAccountsByOwner(t *testing.T) {
+	q := New(dbtest.New(t))
+	account1 := createRandomAccount(t, q)
+
+	accounts, err := q.ListAccountsByOwner(context.Background(), ListAccountsByOwnerParams{
+		Owner: account1.Owner,
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, accounts)
+	for _, account := range accounts {
+		require.Equal(t, account1.Owner, account.Owner)
+	}
+}
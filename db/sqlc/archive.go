@@ -0,0 +1,261 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrArchiveUnavailable is returned when a query needs archived entries but the store was built without WithArchive
+	ErrArchiveUnavailable = errors.New("archived entries are needed but no archive is configured")
+	// ErrArchiveMismatch is returned when a partition no longer holds the rows that were exported from it
+	ErrArchiveMismatch = errors.New("archive does not match the partition")
+)
+
+// ArchiveReader reads archived months back from wherever the archiver stored them
+type ArchiveReader interface {
+	// EachEntry calls fn with every entry of an archived month of entries, stopping at the first error fn returns
+	EachEntry(ctx context.Context, period ArchivedPeriod, fn func(Entry) error) error
+}
+
+// WithArchive lets balance and statement queries read the entries of archived months through reader
+func WithArchive(reader ArchiveReader) StoreOption {
+	return func(store *Store) {
+		store.archive = reader
+	}
+}
+
+// endOfTime closes ranges that are open towards the future
+var endOfTime = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// MonthStart returns the first instant of the month containing t, in UTC like the partitions
+func MonthStart(t time.Time) time.Time {
+	year, month, _ := t.UTC().Date()
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// archivedTotals returns what the archived entries posted from since up to, but excluding, until add to each account.
+// Months lying wholly in the range are summed from their stored totals; only the months at either end are read back.
+func (store *Store) archivedTotals(ctx context.Context, q *Queries, accountIDs []int64, since, until time.Time) (map[int64]int64, error) {
+	periods, err := q.ListArchivedPeriods(ctx, ListArchivedPeriodsParams{TableName: "entries", Since: since, Until: until})
+	if err != nil || len(periods) == 0 {
+		return nil, err
+	}
+
+	totals := make(map[int64]int64, len(accountIDs))
+	fromMonth := MonthStart(since)
+	if fromMonth.Before(since) {
+		fromMonth = fromMonth.AddDate(0, 1, 0)
+	}
+	toMonth := MonthStart(until)
+	if fromMonth.Before(toMonth) {
+		rows, err := q.ListArchivedEntryTotals(ctx, ListArchivedEntryTotalsParams{
+			AccountIds: accountIDs,
+			FromMonth:  fromMonth,
+			ToMonth:    toMonth,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			totals[row.AccountID] += row.AmountTotal
+		}
+	}
+
+	wanted := make(map[int64]bool, len(accountIDs))
+	for _, id := range accountIDs {
+		wanted[id] = true
+	}
+	for _, period := range periods {
+		if month := MonthStart(period.Month); !month.Before(fromMonth) && month.Before(toMonth) {
+			continue
+		}
+		err := store.eachArchivedEntry(ctx, period, func(entry Entry) error {
+			if wanted[entry.AccountID] && !entry.CreatedAt.Before(since) && entry.CreatedAt.Before(until) {
+				totals[entry.AccountID] += entry.Amount
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return totals, nil
+}
+
+// archivedEntries returns the archived entries of an account posted from since up to, but excluding, until, oldest first
+func (store *Store) archivedEntries(ctx context.Context, accountID int64, since, until time.Time) ([]Entry, error) {
+	periods, err := store.ListArchivedPeriods(ctx, ListArchivedPeriodsParams{TableName: "entries", Since: since, Until: until})
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, period := range periods {
+		err := store.eachArchivedEntry(ctx, period, func(entry Entry) error {
+			if entry.AccountID == accountID && !entry.CreatedAt.Before(since) && entry.CreatedAt.Before(until) {
+				entries = append(entries, entry)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+func (store *Store) eachArchivedEntry(ctx context.Context, period ArchivedPeriod, fn func(Entry) error) error {
+	if store.archive == nil {
+		return ErrArchiveUnavailable
+	}
+	if err := store.archive.EachEntry(ctx, period, fn); err != nil {
+		return fmt.Errorf("failed to read archived entries of %s: %w", period.Month.Format("2006-01"), err)
+	}
+	return nil
+}
+
+// sortEntries orders entries the way they were posted
+func sortEntries(entries []Entry) {
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+}
+
+// EachPartitionEntry calls fn with every entry of the month's partition in ID order, whether or not it is still attached
+func (store *Store) EachPartitionEntry(ctx context.Context, month time.Time, fn func(Entry) error) error {
+	rows, err := store.Queries.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT id, account_id, amount, created_at, tenant_id, description, external_reference, metadata FROM %s ORDER BY id",
+		pq.QuoteIdentifier(PartitionName("entries", month)),
+	))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(&i.ID, &i.AccountID, &i.Amount, &i.CreatedAt, &i.TenantID, &i.Description, &i.ExternalReference, &i.Metadata); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+// EachPartitionTransfer calls fn with every transfer of the month's partition in ID order, whether or not it is still attached
+func (store *Store) EachPartitionTransfer(ctx context.Context, month time.Time, fn func(Transfer) error) error {
+	rows, err := store.Queries.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT id, from_account_id, to_account_id, amount, created_at, tenant_id, description, external_reference, metadata FROM %s ORDER BY id",
+		pq.QuoteIdentifier(PartitionName("transfers", month)),
+	))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(&i.ID, &i.FromAccountID, &i.ToAccountID, &i.Amount, &i.CreatedAt, &i.TenantID, &i.Description, &i.ExternalReference, &i.Metadata); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+// CompleteArchiveTxParams describes a month of a partitioned table whose export was stored and verified
+type CompleteArchiveTxParams struct {
+	TableName string
+	// Month is the first day of the month, in UTC
+	Month     time.Time
+	ObjectKey string
+	// RowCount and AmountTotal are what the stored object holds, checked against the partition once more
+	RowCount    int64
+	AmountTotal int64
+	Sha256      string
+}
+
+// CompleteArchiveTx records an archived month and drops its partition.
+// The partition is recounted first: if it no longer holds what was exported, nothing is dropped and ErrArchiveMismatch is returned.
+// For entries, the per-account totals of the month are kept so balances and reconciliation still add up without the archive.
+func (store *Store) CompleteArchiveTx(ctx context.Context, arg CompleteArchiveTxParams) (ArchivedPeriod, error) {
+	var period ArchivedPeriod
+	if !slices.Contains(PartitionedTables, arg.TableName) {
+		return period, fmt.Errorf("%s is not partitioned by month", arg.TableName)
+	}
+	month := MonthStart(arg.Month)
+	partition := pq.QuoteIdentifier(PartitionName(arg.TableName, month))
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		if _, err := q.db.ExecContext(ctx, "LOCK TABLE "+partition+" IN SHARE MODE"); err != nil {
+			return fmt.Errorf("CompleteArchiveTx - failed to lock %s: %w", partition, err)
+		}
+		var count, total int64
+		err = q.db.QueryRowContext(ctx, "SELECT count(*), COALESCE(sum(amount), 0)::bigint FROM "+partition).Scan(&count, &total)
+		if err != nil {
+			return fmt.Errorf("CompleteArchiveTx - failed to count %s: %w", partition, err)
+		}
+		if count != arg.RowCount || total != arg.AmountTotal {
+			return fmt.Errorf("%w: %s holds %d rows totalling %d, the archive %d totalling %d",
+				ErrArchiveMismatch, partition, count, total, arg.RowCount, arg.AmountTotal)
+		}
+
+		period, err = q.CreateArchivedPeriod(ctx, CreateArchivedPeriodParams{
+			TableName:   arg.TableName,
+			Month:       month,
+			ObjectKey:   arg.ObjectKey,
+			RowCount:    arg.RowCount,
+			AmountTotal: arg.AmountTotal,
+			Sha256:      arg.Sha256,
+		})
+		if err != nil {
+			return fmt.Errorf("CompleteArchiveTx - failed to record archived period: %w", err)
+		}
+
+		if arg.TableName == "entries" {
+			_, err = q.db.ExecContext(ctx, `INSERT INTO archived_entry_totals (account_id, month, entry_count, amount_total)
+SELECT account_id, $1::date, count(*), sum(amount)::bigint FROM `+partition+` GROUP BY account_id`, month)
+			if err != nil {
+				return fmt.Errorf("CompleteArchiveTx - failed to record entry totals: %w", err)
+			}
+		}
+
+		dropped, err := q.DropMonthlyPartition(ctx, DropMonthlyPartitionParams{Parent: arg.TableName, Month: month})
+		if err != nil {
+			return fmt.Errorf("CompleteArchiveTx - failed to drop %s: %w", partition, err)
+		}
+		if !dropped {
+			return fmt.Errorf("CompleteArchiveTx - %s disappeared while it was archived", partition)
+		}
+		return nil
+	})
+	if err != nil {
+		return period, err
+	}
+
+	store.logger.InfoContext(ctx, "month archived",
+		"table", arg.TableName,
+		"month", month.Format("2006-01"),
+		"rows", arg.RowCount,
+		"object", arg.ObjectKey,
+	)
+	return period, nil
+}
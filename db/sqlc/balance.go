@@ -0,0 +1,126 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"time"
+)
+
+// AccountBalance is an account's balance as it stood at a point in time
+type AccountBalance struct {
+	AccountID int64     `json:"account_id"`
+	Owner     string    `json:"owner"`
+	Currency  string    `json:"currency"`
+	Balance   int64     `json:"balance"`
+	At        time.Time `json:"at"`
+}
+
+// GetBalanceAt returns the account's balance including every entry posted up to and at the given time.
+// It returns sql.ErrNoRows when the account does not exist or was opened after that time.
+func (store *Store) GetBalanceAt(ctx context.Context, accountID int64, at time.Time) (AccountBalance, error) {
+	balances, err := store.ListBalancesAt(ctx, []int64{accountID}, at)
+	if err != nil {
+		return AccountBalance{}, err
+	}
+	if len(balances) == 0 {
+		return AccountBalance{}, sql.ErrNoRows
+	}
+	return balances[0], nil
+}
+
+// ListBalancesAt returns the balances of several accounts at the same point in time, ordered by account ID.
+// Accounts that do not exist or were opened after that time are left out.
+//
+// When a business day had closed by then, balances start from that day's snapshots and only add the entries posted since;
+// accounts opened after it, and times before the first close, fall back to subtracting the entries posted after the time
+// from the current balance. Entries of archived months count as well; see WithArchive.
+func (store *Store) ListBalancesAt(ctx context.Context, accountIDs []int64, at time.Time) ([]AccountBalance, error) {
+	var balances []AccountBalance
+
+	//the snapshot, the current balances and the archived periods must agree with each other,
+	//so a business day closing or a month being archived meanwhile cannot be counted twice or missed
+	opts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	err := store.execTxOptions(ctx, opts, func(q *Queries) error {
+		var err error
+		balances, err = store.listBalancesAt(ctx, q, accountIDs, at)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range balances {
+		balances[i].At = at
+	}
+	slices.SortFunc(balances, func(a, b AccountBalance) int {
+		return cmp.Compare(a.AccountID, b.AccountID)
+	})
+	return balances, nil
+}
+
+func (store *Store) listBalancesAt(ctx context.Context, q *Queries, accountIDs []int64, at time.Time) ([]AccountBalance, error) {
+	balances := make([]AccountBalance, 0, len(accountIDs))
+	missing := accountIDs
+
+	day, err := q.GetBusinessDayBefore(ctx, at)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil {
+		rows, err := q.ListAccountBalancesFromSnapshot(ctx, ListAccountBalancesFromSnapshotParams{
+			BusinessDate: day.BusinessDate,
+			Since:        day.ClosesAt,
+			At:           at,
+			AccountIds:   accountIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		//the entries read above may have been archived since
+		archived, err := store.archivedTotals(ctx, q, accountIDs, day.ClosesAt, at.Add(time.Microsecond))
+		if err != nil {
+			return nil, err
+		}
+
+		found := make(map[int64]bool, len(rows))
+		for _, row := range rows {
+			balances = append(balances, AccountBalance{
+				AccountID: row.AccountID,
+				Owner:     row.Owner,
+				Currency:  row.Currency,
+				Balance:   row.Balance + archived[row.AccountID],
+			})
+			found[row.AccountID] = true
+		}
+		missing = nil
+		for _, id := range accountIDs {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		rows, err := q.ListAccountBalancesAt(ctx, ListAccountBalancesAtParams{At: at, AccountIds: missing})
+		if err != nil {
+			return nil, err
+		}
+		archived, err := store.archivedTotals(ctx, q, missing, at.Add(time.Microsecond), endOfTime)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			balances = append(balances, AccountBalance{
+				AccountID: row.AccountID,
+				Owner:     row.Owner,
+				Currency:  row.Currency,
+				Balance:   row.Balance - archived[row.AccountID],
+			})
+		}
+	}
+	return balances, nil
+}
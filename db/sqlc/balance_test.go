@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+)
+
+func TestGetBalanceAt(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	first, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	second, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        20,
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		at   time.Time
+		want int64
+	}{
+		{"opening balance", first.FromEntry.CreatedAt.Add(-time.Microsecond), account1.Balance},
+		{"after first transfer", first.FromEntry.CreatedAt, account1.Balance - 10},
+		{"after second transfer", second.FromEntry.CreatedAt, account1.Balance - 30},
+		{"in the future", time.Now().Add(time.Hour), account1.Balance - 30},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			balance, err := store.GetBalanceAt(context.Background(), account1.ID, tc.at)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, balance.Balance)
+			require.Equal(t, account1.Currency, balance.Currency)
+			require.True(t, tc.at.Equal(balance.At))
+		})
+	}
+
+	// The account did not exist yet.
+	_, err = store.GetBalanceAt(context.Background(), account1.ID, account1.CreatedAt.Add(-time.Second))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListBalancesAt(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	before, err := store.ListBalancesAt(context.Background(), []int64{account1.ID, account2.ID, 0}, result.FromEntry.CreatedAt.Add(-time.Microsecond))
+	require.NoError(t, err)
+	require.Len(t, before, 2)
+	require.Equal(t, account1.Balance, before[0].Balance)
+	require.Equal(t, account2.Balance, before[1].Balance)
+
+	after, err := store.ListBalancesAt(context.Background(), []int64{account2.ID, account1.ID}, result.FromEntry.CreatedAt)
+	require.NoError(t, err)
+	require.Len(t, after, 2)
+	require.Equal(t, account1.ID, after[0].AccountID)
+	require.Equal(t, account1.Balance-10, after[0].Balance)
+	require.Equal(t, account2.Balance+10, after[1].Balance)
+}
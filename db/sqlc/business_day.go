@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrBusinessDayClosed is returned when closing a business day that is already closed
+	ErrBusinessDayClosed = errors.New("business day is already closed")
+	// ErrBusinessDayNotOver is returned when closing a business day that has not ended yet
+	ErrBusinessDayNotOver = errors.New("business day has not ended yet")
+	// ErrBusinessDayOutOfOrder is returned when closing a business day while the day before it is still open
+	ErrBusinessDayOutOfOrder = errors.New("business days must be closed in order")
+)
+
+// BusinessDate returns the calendar date t falls on in loc, as midnight UTC like the date columns
+func BusinessDate(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// BusinessDayEnd returns the instant the business date ends in loc, which is when the next one starts
+func BusinessDayEnd(date time.Time, loc *time.Location) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+}
+
+// CloseBusinessDayTxParams contains the input parameters to close a business day
+type CloseBusinessDayTxParams struct {
+	// Date is the business date; only its year, month and day are used
+	Date time.Time
+	// Location is the bank's timezone, which decides when the day ends
+	Location *time.Location
+	Actor    string
+}
+
+// CloseBusinessDayTxResult is the closed day and the number of account balances snapshotted for it
+type CloseBusinessDayTxResult struct {
+	BusinessDay BusinessDay `json:"business_day"`
+	Snapshots   int64       `json:"snapshots"`
+}
+
+// CloseBusinessDayTx snapshots every account's balance at the end of the day and closes the day,
+// after which the entries trigger rejects any entry dated before its end.
+// Days are closed one after the other: the first close may pick any day, later ones must follow the last closed day.
+// Posting entries is blocked while the snapshots are written.
+func (store *Store) CloseBusinessDayTx(ctx context.Context, arg CloseBusinessDayTxParams) (CloseBusinessDayTxResult, error) {
+	var result CloseBusinessDayTxResult
+
+	date := BusinessDate(arg.Date, time.UTC)
+	closesAt := BusinessDayEnd(date, arg.Location)
+	if closesAt.After(time.Now()) {
+		return result, ErrBusinessDayNotOver
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result = CloseBusinessDayTxResult{}
+
+		//once the lock is held every entry of the day has committed, so the snapshots see all of them
+		if err := q.LockEntries(ctx); err != nil {
+			return fmt.Errorf("CloseBusinessDayTx - failed to lock entries: %w", err)
+		}
+
+		latest, err := q.GetLatestBusinessDay(ctx)
+		if err == nil {
+			next := latest.BusinessDate.AddDate(0, 0, 1).Format(time.DateOnly)
+			switch day := date.Format(time.DateOnly); {
+			case day < next:
+				return ErrBusinessDayClosed
+			case day > next:
+				return fmt.Errorf("%w: %s is the next day to close", ErrBusinessDayOutOfOrder, next)
+			}
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("CloseBusinessDayTx - failed to get latest business day: %w", err)
+		}
+
+		result.BusinessDay, err = q.CreateBusinessDay(ctx, CreateBusinessDayParams{
+			BusinessDate: date,
+			ClosesAt:     closesAt,
+			ClosedBy:     arg.Actor,
+		})
+		if err != nil {
+			return fmt.Errorf("CloseBusinessDayTx - failed to create business day: %w", err)
+		}
+
+		result.Snapshots, err = q.CreateDailyBalances(ctx, CreateDailyBalancesParams{
+			BusinessDate: date,
+			ClosesAt:     closesAt,
+		})
+		if err != nil {
+			return fmt.Errorf("CloseBusinessDayTx - failed to snapshot balances: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	store.logger.InfoContext(ctx, "business day closed",
+		"business_date", date.Format(time.DateOnly),
+		"closes_at", closesAt,
+		"snapshots", result.Snapshots,
+	)
+	return result, nil
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	"goprojects/simplebank/util"
+)
+
+func TestCloseBusinessDayTx(t *testing.T) {
+	conn := dbtest.New(t)
+	store := NewStore(conn)
+	ctx := context.Background()
+
+	day := BusinessDate(time.Now(), time.UTC).AddDate(0, 0, -3)
+	closesAt := BusinessDayEnd(day, time.UTC)
+
+	var accounts [2]Account
+	for i := range accounts {
+		account, err := store.CreateAccount(ctx, CreateAccountParams{
+			Owner:    util.RandomOwner(),
+			Balance:  100,
+			Currency: util.USD,
+		})
+		require.NoError(t, err)
+		_, err = conn.ExecContext(ctx, "UPDATE accounts SET created_at = $1 WHERE id = $2", day, account.ID)
+		require.NoError(t, err)
+		accounts[i] = account
+	}
+
+	// A transfer during the day, and one after it ended.
+	during, err := store.TransferTx(ctx, TransferTxParams{FromAccountID: accounts[0].ID, ToAccountID: accounts[1].ID, Amount: 30})
+	require.NoError(t, err)
+	_, err = conn.ExecContext(ctx, "UPDATE entries SET created_at = $1 WHERE id IN ($2, $3)",
+		day.Add(12*time.Hour), during.FromEntry.ID, during.ToEntry.ID)
+	require.NoError(t, err)
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: accounts[0].ID, ToAccountID: accounts[1].ID, Amount: 5})
+	require.NoError(t, err)
+
+	result, err := store.CloseBusinessDayTx(ctx, CloseBusinessDayTxParams{Date: day, Location: time.UTC, Actor: "test"})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, result.Snapshots)
+	require.True(t, closesAt.Equal(result.BusinessDay.ClosesAt))
+
+	// Lookups after the close start from the snapshot and agree with the entries.
+	balances, err := store.ListBalancesAt(ctx, []int64{accounts[0].ID, accounts[1].ID}, closesAt)
+	require.NoError(t, err)
+	require.Len(t, balances, 2)
+	require.EqualValues(t, 70, balances[0].Balance)
+	require.EqualValues(t, 130, balances[1].Balance)
+
+	now, err := store.GetBalanceAt(ctx, accounts[0].ID, time.Now())
+	require.NoError(t, err)
+	require.EqualValues(t, 65, now.Balance)
+
+	// Entries can no longer post into the closed day.
+	_, err = conn.ExecContext(ctx, "INSERT INTO entries (account_id, amount, created_at) VALUES ($1, 1, $2)",
+		accounts[0].ID, closesAt.Add(-time.Hour))
+	require.Equal(t, BusinessDayClosed, ErrorCode(err))
+
+	// Snapshots are immutable.
+	_, err = conn.ExecContext(ctx, "UPDATE daily_balances SET balance = 0 WHERE account_id = $1", accounts[0].ID)
+	require.Equal(t, HistoryImmutable, ErrorCode(err))
+
+	_, err = store.CloseBusinessDayTx(ctx, CloseBusinessDayTxParams{Date: day, Location: time.UTC, Actor: "test"})
+	require.ErrorIs(t, err, ErrBusinessDayClosed)
+
+	_, err = store.CloseBusinessDayTx(ctx, CloseBusinessDayTxParams{Date: day.AddDate(0, 0, 2), Location: time.UTC, Actor: "test"})
+	require.ErrorIs(t, err, ErrBusinessDayOutOfOrder)
+
+	_, err = store.CloseBusinessDayTx(ctx, CloseBusinessDayTxParams{Date: day.AddDate(0, 0, 3), Location: time.UTC, Actor: "test"})
+	require.ErrorIs(t, err, ErrBusinessDayNotOver)
+}
+
+func TestBusinessDayEnd(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	date := time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Date(2024, time.April, 1, 4, 0, 0, 0, time.UTC), BusinessDayEnd(date, loc).UTC())
+
+	// 23:30 in New York is already the next day in UTC, but still March 31st for the bank.
+	require.Equal(t, date, BusinessDate(time.Date(2024, time.April, 1, 3, 30, 0, 0, time.UTC), loc))
+}
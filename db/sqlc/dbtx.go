@@ -0,0 +1,35 @@
+package db
+
+import (
+	"strings"
+)
+
+// DBTXMiddleware wraps the DBTX every generated query runs on, to observe or alter the calls it makes
+type DBTXMiddleware func(next DBTX) DBTX
+
+// WithDBTXMiddleware wraps both the pool and every transaction of the store with mw.
+// The first middleware is the outermost, so it sees each call first.
+func WithDBTXMiddleware(mw ...DBTXMiddleware) StoreOption {
+	return func(store *Store) {
+		store.middleware = append(store.middleware, mw...)
+	}
+}
+
+// wrap applies the store's middleware to conn
+func (store *Store) wrap(conn DBTX) DBTX {
+	for i := len(store.middleware) - 1; i >= 0; i-- {
+		conn = store.middleware[i](conn)
+	}
+	return conn
+}
+
+// QueryName returns the sqlc name of a generated query, read from its "-- name: X :kind" header,
+// or "unknown" for SQL that did not come from sqlc
+func QueryName(query string) string {
+	rest, ok := strings.CutPrefix(query, "-- name: ")
+	if !ok {
+		return "unknown"
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
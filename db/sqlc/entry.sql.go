@@ -7,31 +7,49 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  description,
+  external_reference,
+  metadata
 ) VALUES (
-  $1, $2
+  $1, $2, $3, $4, $5
 )
-RETURNING id, account_id, amount, created_at
+RETURNING id, account_id, amount, created_at, tenant_id, description, external_reference, metadata
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID         int64           `json:"account_id"`
+	Amount            int64           `json:"amount"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.Description,
+		arg.ExternalReference,
+		arg.Metadata,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TenantID,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
 	)
 	return i, err
 }
@@ -47,7 +65,7 @@ func (q *Queries) DeleteEntry(ctx context.Context, id int64) error {
 }
 
 const getAEntry = `-- name: GetAEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, tenant_id, description, external_reference, metadata FROM entries
 WHERE id = $1 LIMIT 1
 `
 
@@ -59,12 +77,116 @@ func (q *Queries) GetAEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TenantID,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
 	)
 	return i, err
 }
 
+const listAccountEntries = `-- name: ListAccountEntries :many
+SELECT id, account_id, amount, created_at, tenant_id, description, external_reference, metadata FROM entries
+WHERE account_id = $1
+ORDER BY id
+LIMIT $2
+OFFSET $3
+`
+
+type ListAccountEntriesParams struct {
+	AccountID int64 `json:"account_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntries, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Entry
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TenantID,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountEntriesBetween = `-- name: ListAccountEntriesBetween :many
+SELECT id, account_id, amount, created_at, tenant_id, description, external_reference, metadata FROM entries
+WHERE account_id = $1
+  AND created_at >= $2
+  AND created_at < $3
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListAccountEntriesBetweenParams struct {
+	AccountID  int64     `json:"account_id"`
+	Since      time.Time `json:"since"`
+	Until      time.Time `json:"until"`
+	MaxEntries int32     `json:"max_entries"`
+}
+
+// the created_at range lets Postgres skip every monthly partition outside it
+func (q *Queries) ListAccountEntriesBetween(ctx context.Context, arg ListAccountEntriesBetweenParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntriesBetween,
+		arg.AccountID,
+		arg.Since,
+		arg.Until,
+		arg.MaxEntries,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Entry
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TenantID,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, tenant_id, description, external_reference, metadata FROM entries
 ORDER BY id
 LIMIT $1
 OFFSET $2
@@ -89,6 +211,10 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TenantID,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -107,7 +233,7 @@ const updateEntry = `-- name: UpdateEntry :one
 UPDATE entries
 set amount = $2
 WHERE id = $1
-RETURNING id, account_id, amount, created_at
+RETURNING id, account_id, amount, created_at, tenant_id, description, external_reference, metadata
 `
 
 type UpdateEntryParams struct {
@@ -123,6 +249,10 @@ func (q *Queries) UpdateEntry(ctx context.Context, arg UpdateEntryParams) (Entry
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TenantID,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
 	)
 	return i, err
 }
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"goprojects/simplebank/db/dbtest"
 	"goprojects/simplebank/util"
 	"testing"
 	"time"
@@ -10,18 +11,19 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func createRandomEntry(t *testing.T) Entry {
+func createRandomEntry(t *testing.T, q *Queries) Entry {
 	// Create a random account for the entry
-	account1 := createRandomAccount(t)
+	account1 := createRandomAccount(t, q)
 
 	// Prepare the entry creation parameters
 	arg := CreateEntryParams{
 		AccountID: account1.ID,
 		Amount:    util.RandomMoney(),
+		Metadata:  noMetadata,
 	}
 
 	// Create the entry
-	entry, err := testQueries.CreateEntry(context.Background(), arg)
+	entry, err := q.CreateEntry(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, entry)
 
@@ -35,17 +37,19 @@ func createRandomEntry(t *testing.T) Entry {
 }
 
 func TestCreateEntry(t *testing.T) {
+	q := New(dbtest.New(t))
 	// Now actually call createRandomEntry to test the CreateEntry functionality
-	entry := createRandomEntry(t)
+	entry := createRandomEntry(t, q)
 	require.NotEmpty(t, entry)
 }
 
 func TestGetEntry(t *testing.T) {
+	q := New(dbtest.New(t))
 	// Create a random entry
-	entry1 := createRandomEntry(t)
+	entry1 := createRandomEntry(t, q)
 
 	// Fetch the entry by ID
-	entry2, err := testQueries.GetAEntry(context.Background(), entry1.ID)
+	entry2, err := q.GetAEntry(context.Background(), entry1.ID)
 	require.NoError(t, err)
 	require.NotEmpty(t, entry2)
 
@@ -57,8 +61,9 @@ func TestGetEntry(t *testing.T) {
 }
 
 func TestUpdateEntry(t *testing.T) {
+	q := New(dbtest.New(t))
 	// Create a random entry
-	entry1 := createRandomEntry(t)
+	entry1 := createRandomEntry(t, q)
 
 	// Prepare update parameters
 	arg := UpdateEntryParams{
@@ -67,7 +72,7 @@ func TestUpdateEntry(t *testing.T) {
 	}
 
 	// Update the entry
-	entry2, err := testQueries.UpdateEntry(context.Background(), arg)
+	entry2, err := q.UpdateEntry(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, entry2)
 
@@ -79,24 +84,26 @@ func TestUpdateEntry(t *testing.T) {
 }
 
 func TestDeleteEntry(t *testing.T) {
+	q := New(dbtest.New(t))
 	// Create a random entry
-	entry1 := createRandomEntry(t)
+	entry1 := createRandomEntry(t, q)
 
 	// Delete the entry
-	err := testQueries.DeleteEntry(context.Background(), entry1.ID)
+	err := q.DeleteEntry(context.Background(), entry1.ID)
 	require.NoError(t, err) // Check for errors during deletion
 
 	// Try to fetch the entry after deletion, should return sql.ErrNoRows
-	entry2, err := testQueries.GetAEntry(context.Background(), entry1.ID)
+	entry2, err := q.GetAEntry(context.Background(), entry1.ID)
 	require.Error(t, err)
 	require.EqualError(t, err, sql.ErrNoRows.Error())
 	require.Empty(t, entry2)
 }
 
 func TestListEntries(t *testing.T) {
+	q := New(dbtest.New(t))
 	// Create 10 random entries
 	for i := 0; i < 10; i++ {
-		createRandomEntry(t)
+		createRandomEntry(t, q)
 	}
 
 	// Prepare list parameters
@@ -106,7 +113,7 @@ func TestListEntries(t *testing.T) {
 	}
 
 	// List entries
-	entries, err := testQueries.ListEntries(context.Background(), arg)
+	entries, err := q.ListEntries(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, entries, 5)
 
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes the callers of the store react to
+const (
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+	//a transaction that fails with one of these did nothing and can simply be run again
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
+	//raised by the entries trigger when a transaction that began before a day was closed posts into it
+	BusinessDayClosed = "SB001"
+	//raised when a closed day or its snapshots are updated or deleted
+	HistoryImmutable = "SB002"
+)
+
+// ErrorCode returns the Postgres error code of err, or an empty string if err did not come from Postgres
+func ErrorCode(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	return ""
+}
+
+// isRetryable reports whether err aborted a transaction in a way a fresh attempt can succeed
+func isRetryable(err error) bool {
+	code := ErrorCode(err)
+	//a retry starts a new transaction, whose entries are dated in the open day
+	return code == SerializationFailure || code == DeadlockDetected || code == BusinessDayClosed
+}
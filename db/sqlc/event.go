@@ -0,0 +1,73 @@
+package db
+
+import "context"
+
+// types of the events account owners can subscribe to
+const (
+	EventTransferCreated = "transfer.created"
+	EventBalanceUpdated  = "balance.updated"
+)
+
+// statuses of webhook_deliveries
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// EventTypes lists every event type, in the order they are documented
+var EventTypes = []string{EventTransferCreated, EventBalanceUpdated}
+
+// Event is a change to an account, published to the account's owner.
+// Data is the Transfer of a transfer.created event and the updated Account of a balance.updated event.
+type Event struct {
+	Type    string
+	Account Account
+	Data    any
+}
+
+// EventPublisher is called with the events of a transaction before it commits.
+// It runs on the transaction's Queries, so whatever it records commits or rolls back with the change it reports;
+// returning an error fails the transaction.
+type EventPublisher func(ctx context.Context, q *Queries, events []Event) error
+
+// WithEventPublisher hands the events of every transaction that moves money to publish
+func WithEventPublisher(publish EventPublisher) StoreOption {
+	return func(store *Store) {
+		store.publisher = publish
+	}
+}
+
+// publish passes events to the store's publisher, leaving out the bank's internal accounts which have no owner to tell
+func (store *Store) publish(ctx context.Context, q *Queries, events ...Event) error {
+	if store.publisher == nil {
+		return nil
+	}
+	var owned []Event
+	for _, event := range events {
+		if event.Account.Owner != "" {
+			owned = append(owned, event)
+		}
+	}
+	if len(owned) == 0 {
+		return nil
+	}
+	return store.publisher(ctx, q, owned)
+}
+
+// transferEvents are the events of a transfer that changed the balances of accounts
+func transferEvents(transfer Transfer, accounts ...Account) []Event {
+	var events []Event
+	for _, account := range accounts {
+		events = append(events,
+			Event{Type: EventTransferCreated, Account: account, Data: transfer},
+			Event{Type: EventBalanceUpdated, Account: account, Data: account},
+		)
+	}
+	return events
+}
+
+// balanceEvent is the event of a change to the balance of account
+func balanceEvent(account Account) Event {
+	return Event{Type: EventBalanceUpdated, Account: account, Data: account}
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"goprojects/simplebank/db/dbtest"
+	"goprojects/simplebank/risk"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fixedScreener returns the same decision for every transfer
+type fixedScreener risk.Decision
+
+func (s fixedScreener) Screen(_ context.Context, _ risk.Transfer, _ risk.History) (risk.Decision, error) {
+	return risk.Decision(s), nil
+}
+
+func createHeldTransfer(t *testing.T, conn *sql.DB) (TransferTxResult, Account, Account) {
+	q := New(conn)
+	store := NewStore(conn, WithScreener(fixedScreener{
+		Outcome: risk.OutcomeReview,
+		Rule:    "test-rule",
+		Reason:  "held by test",
+	}))
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+	amount := int64(10)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, result.Flag)
+	require.Equal(t, FlagStatusPending, result.Flag.Status)
+	require.Equal(t, "test-rule", result.Flag.Rule)
+	require.Equal(t, result.Transfer.ID, result.Flag.TransferID)
+
+	// The sender is debited but the recipient is not credited while the hold is pending.
+	require.Equal(t, account1.Balance-amount, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance, result.ToAccount.Balance)
+	require.Zero(t, result.ToEntry.ID)
+
+	return result, account1, account2
+}
+
+func TestTransferTxBlocked(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn, WithScreener(fixedScreener{
+		Outcome: risk.OutcomeBlock,
+		Rule:    "test-rule",
+	}))
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrTransferBlocked)
+
+	var screeningErr *ScreeningError
+	require.ErrorAs(t, err, &screeningErr)
+	require.Equal(t, "test-rule", screeningErr.Decision.Rule)
+
+	updatedAccount1, err := q.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestClearFlaggedTransferTx(t *testing.T) {
+	conn := dbtest.New(t)
+	store := NewStore(conn)
+	held, _, account2 := createHeldTransfer(t, conn)
+
+	result, err := store.ClearFlaggedTransferTx(context.Background(), ReviewFlaggedTransferParams{
+		FlagID:     held.Flag.ID,
+		ReviewedBy: "analyst",
+		Note:       "known customer",
+	})
+	require.NoError(t, err)
+	require.Equal(t, FlagStatusCleared, result.Flag.Status)
+	require.Equal(t, "analyst", result.Flag.ReviewedBy.String)
+	require.True(t, result.Flag.ReviewedAt.Valid)
+	require.Equal(t, account2.ID, result.Entry.AccountID)
+	require.Equal(t, held.Transfer.Amount, result.Entry.Amount)
+	require.Equal(t, account2.Balance+held.Transfer.Amount, result.Account.Balance)
+
+	// A hold can only be resolved once.
+	_, err = store.RejectFlaggedTransferTx(context.Background(), ReviewFlaggedTransferParams{
+		FlagID:     held.Flag.ID,
+		ReviewedBy: "analyst",
+	})
+	require.ErrorIs(t, err, ErrFlagNotPending)
+}
+
+func TestRejectFlaggedTransferTx(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	held, account1, _ := createHeldTransfer(t, conn)
+
+	result, err := store.RejectFlaggedTransferTx(context.Background(), ReviewFlaggedTransferParams{
+		FlagID:     held.Flag.ID,
+		ReviewedBy: "analyst",
+	})
+	require.NoError(t, err)
+	require.Equal(t, FlagStatusRejected, result.Flag.Status)
+	require.False(t, result.Flag.ReviewNote.Valid)
+	require.Equal(t, account1.ID, result.Entry.AccountID)
+	require.Equal(t, account1.Balance, result.Account.Balance)
+
+	pending, err := q.ListFlaggedTransfers(context.Background(), ListFlaggedTransfersParams{
+		Status: FlagStatusPending,
+		Limit:  1000,
+	})
+	require.NoError(t, err)
+	for _, flag := range pending {
+		require.NotEqual(t, held.Flag.ID, flag.ID)
+	}
+}
+
+func TestFlaggedTransferReferencesTransfer(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	held, _, _ := createHeldTransfer(t, conn)
+	require.True(t, held.Flag.TransferCreatedAt.Valid)
+	require.WithinDuration(t, held.Transfer.CreatedAt, held.Flag.TransferCreatedAt.Time, 0)
+
+	// The flag keeps its transfer from being deleted.
+	err := q.DeleteTransfer(context.Background(), held.Transfer.ID)
+	require.Equal(t, ForeignKeyViolation, ErrorCode(err))
+}
@@ -0,0 +1,17 @@
+package db
+
+import (
+	"context"
+
+	"goprojects/simplebank/db/migration"
+)
+
+// Ping checks that the database can be reached
+func (store *Store) Ping(ctx context.Context) error {
+	return store.db.PingContext(ctx)
+}
+
+// SchemaStatus reports whether the database schema is at the latest embedded migration
+func (store *Store) SchemaStatus(ctx context.Context) (migration.Status, error) {
+	return migration.CurrentStatus(ctx, store.db)
+}
@@ -0,0 +1,258 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// QueryInstrumenter times every query run through the DBTX it wraps, records per-query metrics
+// and logs the queries slower than its threshold.
+// Its Wrap method is a DBTXMiddleware, so it composes with New and WithDBTXMiddleware:
+//
+//	instrumenter := db.NewQueryInstrumenter(registry, logger, 200*time.Millisecond)
+//	conn := instrumenter.OpenDB(connector)
+//	store := db.NewStore(conn, db.WithDBTXMiddleware(instrumenter.Wrap))
+//
+// The rows a statement changes are counted by Wrap from ExecContext. The rows a query returns,
+// RETURNING included, are read by the caller after QueryContext returns a *sql.Rows, so they are counted
+// below database/sql on the connections of OpenDB, as they are read.
+type QueryInstrumenter struct {
+	logger        *slog.Logger
+	slowThreshold time.Duration
+
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+	//only counts the statements run with ExecContext
+	rowsAffected *prometheus.CounterVec
+	//only counts the queries run on the connections of OpenDB
+	rowsReturned *prometheus.CounterVec
+}
+
+// NewQueryInstrumenter registers the query metrics with reg.
+// A zero slowThreshold disables the slow-query log.
+func NewQueryInstrumenter(reg prometheus.Registerer, logger *slog.Logger, slowThreshold time.Duration) *QueryInstrumenter {
+	qi := &QueryInstrumenter{
+		logger:        logger,
+		slowThreshold: slowThreshold,
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "simple_bank",
+			Subsystem: "db",
+			Name:      "query_duration_seconds",
+			Help:      "Latency of queries by sqlc query name.",
+			Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}, []string{"query"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "simple_bank",
+			Subsystem: "db",
+			Name:      "query_errors_total",
+			Help:      "Queries that returned an error, by sqlc query name.",
+		}, []string{"query"}),
+		rowsAffected: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "simple_bank",
+			Subsystem: "db",
+			Name:      "rows_affected_total",
+			Help:      "Rows changed by statements run with ExecContext, by sqlc query name.",
+		}, []string{"query"}),
+		rowsReturned: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "simple_bank",
+			Subsystem: "db",
+			Name:      "rows_returned_total",
+			Help:      "Rows read from queries, RETURNING included, by sqlc query name.",
+		}, []string{"query"}),
+	}
+
+	reg.MustRegister(qi.duration, qi.errors, qi.rowsAffected, qi.rowsReturned)
+	return qi
+}
+
+// Wrap returns a DBTX that instruments every call before passing it to next
+func (qi *QueryInstrumenter) Wrap(next DBTX) DBTX {
+	return instrumentedDBTX{next: next, qi: qi}
+}
+
+// Connector wraps base so the rows of every query run on the connections it opens are counted
+func (qi *QueryInstrumenter) Connector(base driver.Connector) driver.Connector {
+	return instrumentedConnector{base: base, qi: qi}
+}
+
+// OpenDB opens a pool whose connections count the rows of every query
+func (qi *QueryInstrumenter) OpenDB(base driver.Connector) *sql.DB {
+	return sql.OpenDB(qi.Connector(base))
+}
+
+// observe records one call; rows is -1 when the number of rows is unknown, which is always the case for queries,
+// whose rows are counted by the connections of OpenDB as they are read
+func (qi *QueryInstrumenter) observe(ctx context.Context, query string, start time.Time, rows int64, err error) {
+	name := QueryName(query)
+	elapsed := time.Since(start)
+
+	qi.duration.WithLabelValues(name).Observe(elapsed.Seconds())
+	if err != nil {
+		qi.errors.WithLabelValues(name).Inc()
+	}
+	if rows > 0 {
+		qi.rowsAffected.WithLabelValues(name).Add(float64(rows))
+	}
+
+	if qi.slowThreshold > 0 && elapsed >= qi.slowThreshold {
+		attrs := []any{"query", name, "duration", elapsed, "sql", statement(query)}
+		if rows >= 0 {
+			attrs = append(attrs, "rows", rows)
+		}
+		qi.logger.WarnContext(ctx, "slow query", attrs...)
+	}
+}
+
+// statement strips the sqlc header from query and collapses its whitespace onto one line
+func statement(query string) string {
+	if strings.HasPrefix(query, "-- name: ") {
+		if _, body, ok := strings.Cut(query, "\n"); ok {
+			query = body
+		}
+	}
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// instrumentedDBTX is the DBTX returned by QueryInstrumenter.Wrap.
+// Only ExecContext knows how many rows a statement touched. QueryContext and QueryRowContext
+// hand back a *sql.Rows or *sql.Row the generated code consumes after the call returns; both are concrete types
+// DBTX must return, so their rows are counted by the driver.Rows beneath them instead.
+type instrumentedDBTX struct {
+	next DBTX
+	qi   *QueryInstrumenter
+}
+
+func (i instrumentedDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := i.next.ExecContext(ctx, query, args...)
+
+	rows := int64(-1)
+	if err == nil {
+		if n, rowsErr := result.RowsAffected(); rowsErr == nil {
+			rows = n
+		}
+	}
+	i.qi.observe(ctx, query, start, rows, err)
+	return result, err
+}
+
+func (i instrumentedDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	start := time.Now()
+	stmt, err := i.next.PrepareContext(ctx, query)
+	i.qi.observe(ctx, query, start, -1, err)
+	return stmt, err
+}
+
+func (i instrumentedDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := i.next.QueryContext(ctx, query, args...)
+	//the rows are read by the caller once this returns, so only the time to the first row is known
+	i.qi.observe(ctx, query, start, -1, err)
+	return rows, err
+}
+
+func (i instrumentedDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := i.next.QueryRowContext(ctx, query, args...)
+	i.qi.observe(ctx, query, start, -1, row.Err())
+	return row
+}
+
+type instrumentedConnector struct {
+	base driver.Connector
+	qi   *QueryInstrumenter
+}
+
+func (c instrumentedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.base.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: cn, qi: c.qi}, nil
+}
+
+func (c instrumentedConnector) Driver() driver.Driver {
+	return c.base.Driver()
+}
+
+// instrumentedConn forwards to the driver's connection and counts the rows its queries return
+type instrumentedConn struct {
+	driver.Conn
+	qi *QueryInstrumenter
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	beginner, ok := c.Conn.(driver.ConnBeginTx)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return beginner.BeginTx(ctx, opts)
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &countedRows{Rows: rows, counter: c.qi.rowsReturned.WithLabelValues(QueryName(query))}, nil
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// countedRows adds every row the caller reads to its query's counter
+type countedRows struct {
+	driver.Rows
+	counter prometheus.Counter
+}
+
+func (r *countedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err == nil {
+		r.counter.Inc()
+	}
+	return err
+}
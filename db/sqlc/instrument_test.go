@@ -0,0 +1,114 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/logging"
+)
+
+// fakeDBTX answers ExecContext after a delay; the other methods are not used by these tests
+type fakeDBTX struct {
+	DBTX
+	delay time.Duration
+	rows  int64
+	err   error
+}
+
+func (f fakeDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	time.Sleep(f.delay)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return fakeResult(f.rows), nil
+}
+
+type fakeResult int64
+
+func (r fakeResult) LastInsertId() (int64, error) { return 0, errors.New("not supported") }
+func (r fakeResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func TestQueryInstrumenter(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := logging.New(&out, logging.FormatText, "info")
+	require.NoError(t, err)
+	qi := NewQueryInstrumenter(prometheus.NewRegistry(), logger, 20*time.Millisecond)
+
+	// The instrumenter is transparent to the generated queries.
+	q := New(qi.Wrap(fakeDBTX{rows: 3}))
+	require.NoError(t, q.DeleteAccount(context.Background(), 1))
+	require.Equal(t, 3.0, testutil.ToFloat64(qi.rowsAffected.WithLabelValues("DeleteAccount")))
+	require.Empty(t, out.String())
+
+	q = New(qi.Wrap(fakeDBTX{err: errors.New("boom")}))
+	require.Error(t, q.DeleteAccount(context.Background(), 1))
+	require.Equal(t, 1.0, testutil.ToFloat64(qi.errors.WithLabelValues("DeleteAccount")))
+
+	q = New(qi.Wrap(fakeDBTX{delay: 30 * time.Millisecond, rows: 1}))
+	require.NoError(t, q.DeleteEntry(context.Background(), 1))
+	require.Contains(t, out.String(), "slow query")
+	require.Contains(t, out.String(), "query=DeleteEntry")
+	require.Contains(t, out.String(), `sql="DELETE FROM entries WHERE id = $1"`)
+	require.Contains(t, out.String(), "rows=1")
+	require.Equal(t, 2, testutil.CollectAndCount(qi.duration))
+}
+
+// fakeConnector opens connections whose queries return one column holding values, one row each;
+// the other methods are not used by these tests
+type fakeConnector struct {
+	values []string
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct {
+	values []string
+}
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	return &fakeRows{values: c.values}, nil
+}
+
+type fakeRows struct {
+	values []string
+}
+
+func (r *fakeRows) Columns() []string { return []string{"name"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func TestQueryInstrumenterCountsQueryRows(t *testing.T) {
+	qi := NewQueryInstrumenter(prometheus.NewRegistry(), slog.New(slog.NewTextHandler(io.Discard, nil)), 0)
+	conn := qi.OpenDB(fakeConnector{values: []string{"transfers_2024_01", "transfers_2024_02", "transfers_2024_03"}})
+	defer conn.Close()
+
+	q := New(qi.Wrap(conn))
+	partitions, err := q.ListMonthlyPartitions(context.Background(), "transfers")
+	require.NoError(t, err)
+	require.Len(t, partitions, 3)
+	require.Equal(t, 3.0, testutil.ToFloat64(qi.rowsReturned.WithLabelValues("ListMonthlyPartitions")))
+	require.Zero(t, testutil.CollectAndCount(qi.rowsAffected))
+}
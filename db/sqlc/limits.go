@@ -0,0 +1,155 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// names of the limits a transfer can hit, reported in LimitExceededError.Limit
+const (
+	LimitMaxSingleAmount       = "max_single_amount"
+	LimitDailyOutboundAmount   = "daily_outbound_amount"
+	LimitMonthlyOutboundAmount = "monthly_outbound_amount"
+	LimitHourlyTransferCount   = "hourly_transfer_count"
+)
+
+// a limit is configured either for one account or for all of an owner's accounts in a currency
+const (
+	LimitScopeAccount = "account"
+	LimitScopeOwner   = "owner"
+)
+
+// ErrLimitExceeded is matched with errors.Is for any transfer rejected by a limit
+var ErrLimitExceeded = errors.New("transfer limit exceeded")
+
+// LimitExceededError names the limit that rejected a transfer
+type LimitExceededError struct {
+	Scope string `json:"scope"`
+	Limit string `json:"limit"`
+	//the configured maximum
+	Max int64 `json:"max"`
+	//the value the window would have reached had the transfer gone through
+	Attempted int64 `json:"attempted"`
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%s %s exceeded: %d > %d", e.Scope, e.Limit, e.Attempted, e.Max)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// outboundFunc returns the total amount and number of outbound transfers since a point in time
+type outboundFunc func(since time.Time) (total int64, count int64, err error)
+
+// checkTransferLimits evaluates the account and owner limits of the sending account against its transfer history.
+// It must run inside the transfer's transaction after the sending account row has been locked,
+// so concurrent transfers from the same account see each other's history.
+func checkTransferLimits(ctx context.Context, q *Queries, from Account, amount int64, now time.Time) error {
+	accountLimit, err := q.GetAccountTransferLimit(ctx, sql.NullInt64{Int64: from.ID, Valid: true})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil {
+		err = enforceLimit(LimitScopeAccount, accountLimit, amount, now, func(since time.Time) (int64, int64, error) {
+			row, err := q.GetAccountOutboundSince(ctx, GetAccountOutboundSinceParams{
+				AccountID: from.ID,
+				Since:     since,
+			})
+			return row.TotalAmount, row.TransferCount, err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	ownerLimit, err := q.GetOwnerTransferLimit(ctx, GetOwnerTransferLimitParams{
+		Owner:    sql.NullString{String: from.Owner, Valid: true},
+		Currency: sql.NullString{String: from.Currency, Valid: true},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	//the account row lock only covers one account, so take an owner-wide lock before reading the history
+	err = q.LockOwnerTransfers(ctx, LockOwnerTransfersParams{
+		Owner:    from.Owner,
+		Currency: from.Currency,
+	})
+	if err != nil {
+		return err
+	}
+
+	return enforceLimit(LimitScopeOwner, ownerLimit, amount, now, func(since time.Time) (int64, int64, error) {
+		row, err := q.GetOwnerOutboundSince(ctx, GetOwnerOutboundSinceParams{
+			Owner:    from.Owner,
+			Currency: from.Currency,
+			Since:    since,
+		})
+		return row.TotalAmount, row.TransferCount, err
+	})
+}
+
+// enforceLimit checks a single transfer_limits row; unset columns mean no limit
+func enforceLimit(scope string, limit TransferLimit, amount int64, now time.Time, outbound outboundFunc) error {
+	if limit.MaxSingleAmount.Valid && amount > limit.MaxSingleAmount.Int64 {
+		return &LimitExceededError{
+			Scope:     scope,
+			Limit:     LimitMaxSingleAmount,
+			Max:       limit.MaxSingleAmount.Int64,
+			Attempted: amount,
+		}
+	}
+
+	if limit.HourlyTransferCount.Valid {
+		_, count, err := outbound(now.Add(-time.Hour))
+		if err != nil {
+			return err
+		}
+		if count+1 > limit.HourlyTransferCount.Int64 {
+			return &LimitExceededError{
+				Scope:     scope,
+				Limit:     LimitHourlyTransferCount,
+				Max:       limit.HourlyTransferCount.Int64,
+				Attempted: count + 1,
+			}
+		}
+	}
+
+	//daily and monthly windows are calendar periods in UTC
+	now = now.UTC()
+	windows := []struct {
+		name  string
+		max   sql.NullInt64
+		since time.Time
+	}{
+		{LimitDailyOutboundAmount, limit.DailyOutboundAmount, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)},
+		{LimitMonthlyOutboundAmount, limit.MonthlyOutboundAmount, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, w := range windows {
+		if !w.max.Valid {
+			continue
+		}
+		total, _, err := outbound(w.since)
+		if err != nil {
+			return err
+		}
+		if total+amount > w.max.Int64 {
+			return &LimitExceededError{
+				Scope:     scope,
+				Limit:     w.name,
+				Max:       w.max.Int64,
+				Attempted: total + amount,
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	"goprojects/simplebank/logging"
+)
+
+func TestTransferTxLogging(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	var out bytes.Buffer
+	logger, err := logging.New(&out, logging.FormatJSON, "info")
+	require.NoError(t, err)
+	store := NewStore(conn, WithLogger(logger))
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	ctx := logging.With(context.Background(), "request_id", "req-1", "user", account1.Owner)
+	result, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// Only the summary is logged at info level, carrying the caller's attributes.
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	require.Equal(t, "transfer committed", record["msg"])
+	require.Equal(t, "req-1", record["request_id"])
+	require.Equal(t, account1.Owner, record["user"])
+	require.Equal(t, float64(result.Transfer.ID), record["transfer_id"])
+}
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// error classes of failed transfers, the values of the class label
+const (
+	TransferErrorNotFound      = "not_found"
+	TransferErrorFrozen        = "frozen"
+	TransferErrorFunds         = "insufficient_funds"
+	TransferErrorLimit         = "limit_exceeded"
+	TransferErrorBlocked       = "blocked"
+	TransferErrorSerialization = "serialization"
+	TransferErrorCanceled      = "canceled"
+	TransferErrorOther         = "other"
+)
+
+// Metrics are the Prometheus collectors of a Store.
+// A nil *Metrics records nothing, so a Store built without WithMetrics pays no cost.
+type Metrics struct {
+	txStarted    prometheus.Counter
+	txCommitted  prometheus.Counter
+	txRolledBack prometheus.Counter
+	txRetries    prometheus.Counter
+	txDuration   prometheus.Histogram
+
+	transfers        *prometheus.CounterVec
+	transferAmount   *prometheus.HistogramVec
+	transferErrors   *prometheus.CounterVec
+	transferDuration prometheus.Histogram
+}
+
+// NewMetrics creates the store collectors and registers them with reg
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		txStarted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "simple_bank",
+			Subsystem: "store",
+			Name:      "transactions_started_total",
+			Help:      "Database transactions begun by the store, counting every retry attempt.",
+		}),
+		txCommitted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "simple_bank",
+			Subsystem: "store",
+			Name:      "transactions_committed_total",
+			Help:      "Database transactions committed by the store.",
+		}),
+		txRolledBack: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "simple_bank",
+			Subsystem: "store",
+			Name:      "transactions_rolled_back_total",
+			Help:      "Database transactions rolled back or failed to commit.",
+		}),
+		txRetries: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "simple_bank",
+			Subsystem: "store",
+			Name:      "transaction_retries_total",
+			Help:      "Transactions run again after a serialization failure or deadlock.",
+		}),
+		txDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "simple_bank",
+			Subsystem: "store",
+			Name:      "transaction_duration_seconds",
+			Help:      "Time from begin to commit or rollback of one transaction attempt.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+		}),
+		transfers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "simple_bank",
+			Name:      "transfers_total",
+			Help:      "Committed transfers by currency and whether they completed or were held for review.",
+		}, []string{"currency", "outcome"}),
+		transferAmount: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "simple_bank",
+			Name:      "transfer_amount",
+			Help:      "Amount of committed transfers in the currency's minor unit.",
+			Buckets:   prometheus.ExponentialBuckets(100, 10, 7),
+		}, []string{"currency"}),
+		transferErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "simple_bank",
+			Name:      "transfer_errors_total",
+			Help:      "Failed transfers by error class.",
+		}, []string{"class"}),
+		transferDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "simple_bank",
+			Name:      "transfer_duration_seconds",
+			Help:      "Latency of TransferTx including retries.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 14),
+		}),
+	}
+
+	reg.MustRegister(
+		m.txStarted,
+		m.txCommitted,
+		m.txRolledBack,
+		m.txRetries,
+		m.txDuration,
+		m.transfers,
+		m.transferAmount,
+		m.transferErrors,
+		m.transferDuration,
+	)
+	return m
+}
+
+// WithMetrics records transaction and transfer metrics into m
+func WithMetrics(m *Metrics) StoreOption {
+	return func(store *Store) {
+		store.metrics = m
+	}
+}
+
+func (m *Metrics) txBegun() {
+	if m != nil {
+		m.txStarted.Inc()
+	}
+}
+
+func (m *Metrics) txFinished(start time.Time, committed bool) {
+	if m == nil {
+		return
+	}
+	m.txDuration.Observe(time.Since(start).Seconds())
+	if committed {
+		m.txCommitted.Inc()
+	} else {
+		m.txRolledBack.Inc()
+	}
+}
+
+func (m *Metrics) txRetried() {
+	if m != nil {
+		m.txRetries.Inc()
+	}
+}
+
+func (m *Metrics) transferFinished(start time.Time, result TransferTxResult, err error) {
+	if m == nil {
+		return
+	}
+	m.transferDuration.Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		m.transferErrors.WithLabelValues(TransferErrorClass(err)).Inc()
+		return
+	}
+
+	outcome := "completed"
+	if result.Flag != nil {
+		outcome = "held"
+	}
+	currency := result.FromAccount.Currency
+	m.transfers.WithLabelValues(currency, outcome).Inc()
+	m.transferAmount.WithLabelValues(currency).Observe(float64(result.Transfer.Amount))
+}
+
+// TransferErrorClass sorts a TransferTx error into one of the low-cardinality TransferError classes
+func TransferErrorClass(err error) string {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return TransferErrorNotFound
+	case errors.Is(err, ErrAccountFrozen):
+		return TransferErrorFrozen
+	case errors.Is(err, ErrInsufficientFunds):
+		return TransferErrorFunds
+	case errors.Is(err, ErrLimitExceeded):
+		return TransferErrorLimit
+	case errors.Is(err, ErrTransferBlocked):
+		return TransferErrorBlocked
+	case isRetryable(err):
+		return TransferErrorSerialization
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return TransferErrorCanceled
+	default:
+		return TransferErrorOther
+	}
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+)
+
+func TestTransferErrorClass(t *testing.T) {
+	testCases := map[error]string{
+		fmt.Errorf("TransferTx - failed to lock accounts: %w", sql.ErrNoRows):           TransferErrorNotFound,
+		fmt.Errorf("TransferTx - %w", ErrAccountFrozen):                                 TransferErrorFrozen,
+		fmt.Errorf("TransferTx - %w", ErrInsufficientFunds):                             TransferErrorFunds,
+		fmt.Errorf("TransferTx - %w", &LimitExceededError{Limit: LimitMaxSingleAmount}): TransferErrorLimit,
+		fmt.Errorf("TransferTx - %w", &ScreeningError{}):                                TransferErrorBlocked,
+		fmt.Errorf("TransferTx - %w", &pq.Error{Code: SerializationFailure}):            TransferErrorSerialization,
+		fmt.Errorf("TransferTx - %w", context.DeadlineExceeded):                         TransferErrorCanceled,
+		errors.New("connection refused"):                                                TransferErrorOther,
+	}
+
+	for err, class := range testCases {
+		require.Equal(t, class, TransferErrorClass(err), err.Error())
+	}
+}
+
+func TestTransferTxMetrics(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	metrics := NewMetrics(prometheus.NewRegistry())
+	store := NewStore(conn, WithMetrics(metrics))
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   -1,
+		Amount:        10,
+	})
+	require.Error(t, err)
+
+	require.Equal(t, 2.0, testutil.ToFloat64(metrics.txStarted))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.txCommitted))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.txRolledBack))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.transfers.WithLabelValues(result.FromAccount.Currency, "completed")))
+	require.Equal(t, 1.0, testutil.ToFloat64(metrics.transferErrors.WithLabelValues(TransferErrorNotFound)))
+}
@@ -5,7 +5,11 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Account struct {
@@ -14,19 +18,223 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	IsFrozen  bool      `json:"is_frozen"`
+	TenantID  string    `json:"tenant_id"`
+	Product   string    `json:"product"`
+}
+
+type AccountProduct struct {
+	Code                   string        `json:"code"`
+	Name                   string        `json:"name"`
+	Category               string        `json:"category"`
+	NormalBalance          string        `json:"normal_balance"`
+	Internal               bool          `json:"internal"`
+	MinBalance             sql.NullInt64 `json:"min_balance"`
+	MonthlyWithdrawalLimit sql.NullInt32 `json:"monthly_withdrawal_limit"`
+	CreatedAt              time.Time     `json:"created_at"`
+}
+
+type ArchivedEntryTotal struct {
+	AccountID   int64     `json:"account_id"`
+	Month       time.Time `json:"month"`
+	EntryCount  int64     `json:"entry_count"`
+	AmountTotal int64     `json:"amount_total"`
+}
+
+type ArchivedPeriod struct {
+	TableName   string    `json:"table_name"`
+	Month       time.Time `json:"month"`
+	ObjectKey   string    `json:"object_key"`
+	RowCount    int64     `json:"row_count"`
+	AmountTotal int64     `json:"amount_total"`
+	Sha256      string    `json:"sha256"`
+	ArchivedAt  time.Time `json:"archived_at"`
+}
+
+type AuditLog struct {
+	ID        int64           `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	AccountID sql.NullInt64   `json:"account_id"`
+	Details   json.RawMessage `json:"details"`
+	CreatedAt time.Time       `json:"created_at"`
+	TenantID  string          `json:"tenant_id"`
+}
+
+type BusinessDay struct {
+	BusinessDate time.Time `json:"business_date"`
+	ClosesAt     time.Time `json:"closes_at"`
+	ClosedBy     string    `json:"closed_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type DailyBalance struct {
+	AccountID    int64     `json:"account_id"`
+	BusinessDate time.Time `json:"business_date"`
+	Balance      int64     `json:"balance"`
+	CreatedAt    time.Time `json:"created_at"`
+	TenantID     string    `json:"tenant_id"`
 }
 
 type Entry struct {
-	ID        int64     `json:"id"`
-	AccountID int64     `json:"account_id"`
-	Amount    int64     `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	ID                int64           `json:"id"`
+	AccountID         int64           `json:"account_id"`
+	Amount            int64           `json:"amount"`
+	CreatedAt         time.Time       `json:"created_at"`
+	TenantID          string          `json:"tenant_id"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+}
+
+type ExternalMovement struct {
+	ID                int64          `json:"id"`
+	TenantID          string         `json:"tenant_id"`
+	Kind              string         `json:"kind"`
+	AccountID         int64          `json:"account_id"`
+	Amount            int64          `json:"amount"`
+	Currency          string         `json:"currency"`
+	ExternalReference string         `json:"external_reference"`
+	Status            string         `json:"status"`
+	FailureReason     sql.NullString `json:"failure_reason"`
+	PendingTransferID int64          `json:"pending_transfer_id"`
+	FinalTransferID   sql.NullInt64  `json:"final_transfer_id"`
+	CreatedAt         time.Time      `json:"created_at"`
+	FinishedAt        sql.NullTime   `json:"finished_at"`
+}
+
+type FlaggedTransfer struct {
+	ID                int64          `json:"id"`
+	TransferID        int64          `json:"transfer_id"`
+	Rule              string         `json:"rule"`
+	Reason            string         `json:"reason"`
+	Status            string         `json:"status"`
+	ReviewedBy        sql.NullString `json:"reviewed_by"`
+	ReviewNote        sql.NullString `json:"review_note"`
+	ReviewedAt        sql.NullTime   `json:"reviewed_at"`
+	CreatedAt         time.Time      `json:"created_at"`
+	TenantID          string         `json:"tenant_id"`
+	TransferCreatedAt sql.NullTime   `json:"transfer_created_at"`
+}
+
+type Job struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Priority    int32           `json:"priority"`
+	Status      string          `json:"status"`
+	Attempts    int32           `json:"attempts"`
+	MaxAttempts int32           `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedAt    sql.NullTime    `json:"locked_at"`
+	LockedBy    sql.NullString  `json:"locked_by"`
+	LastError   sql.NullString  `json:"last_error"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	TenantID     string    `json:"tenant_id"`
+}
+
+type Tenant struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Host      sql.NullString `json:"host"`
+	CreatedAt time.Time      `json:"created_at"`
 }
 
 type Transfer struct {
-	ID            int64     `json:"id"`
-	FromAccountID int64     `json:"from_account_id"`
-	ToAccountID   int64     `json:"to_account_id"`
-	Amount        int64     `json:"amount"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID                int64           `json:"id"`
+	FromAccountID     int64           `json:"from_account_id"`
+	ToAccountID       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	CreatedAt         time.Time       `json:"created_at"`
+	TenantID          string          `json:"tenant_id"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
+}
+
+type TransferLimit struct {
+	ID                    int64          `json:"id"`
+	AccountID             sql.NullInt64  `json:"account_id"`
+	Owner                 sql.NullString `json:"owner"`
+	Currency              sql.NullString `json:"currency"`
+	MaxSingleAmount       sql.NullInt64  `json:"max_single_amount"`
+	DailyOutboundAmount   sql.NullInt64  `json:"daily_outbound_amount"`
+	MonthlyOutboundAmount sql.NullInt64  `json:"monthly_outbound_amount"`
+	HourlyTransferCount   sql.NullInt64  `json:"hourly_transfer_count"`
+	CreatedAt             time.Time      `json:"created_at"`
+	TenantID              string         `json:"tenant_id"`
+}
+
+type TransferReversal struct {
+	TransferID        int64        `json:"transfer_id"`
+	ReversalID        int64        `json:"reversal_id"`
+	Actor             string       `json:"actor"`
+	Reason            string       `json:"reason"`
+	CreatedAt         time.Time    `json:"created_at"`
+	TransferCreatedAt sql.NullTime `json:"transfer_created_at"`
+	ReversalCreatedAt sql.NullTime `json:"reversal_created_at"`
+}
+
+type User struct {
+	Username          string    `json:"username"`
+	HashedPassword    string    `json:"hashed_password"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	Role              string    `json:"role"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	TenantID          string    `json:"tenant_id"`
+}
+
+type VerifyEmail struct {
+	ID         int64     `json:"id"`
+	Username   string    `json:"username"`
+	Email      string    `json:"email"`
+	SecretCode string    `json:"secret_code"`
+	IsUsed     bool      `json:"is_used"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+	TenantID   string    `json:"tenant_id"`
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	AccountID      int64           `json:"account_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	ResponseStatus sql.NullInt32   `json:"response_status"`
+	LastError      sql.NullString  `json:"last_error"`
+	ReplayOf       sql.NullInt64   `json:"replay_of"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	TenantID       string          `json:"tenant_id"`
+}
+
+type WebhookSubscription struct {
+	ID           int64        `json:"id"`
+	TenantID     string       `json:"tenant_id"`
+	Owner        string       `json:"owner"`
+	Url          string       `json:"url"`
+	EventTypes   []string     `json:"event_types"`
+	Secret       string       `json:"secret"`
+	FailureCount int32        `json:"failure_count"`
+	DisabledAt   sql.NullTime `json:"disabled_at"`
+	CreatedAt    time.Time    `json:"created_at"`
 }
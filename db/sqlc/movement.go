@@ -0,0 +1,312 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// kinds of external_movements
+const (
+	MovementDeposit    = "deposit"
+	MovementWithdrawal = "withdrawal"
+)
+
+// statuses of external_movements; pending is the only one a movement leaves
+const (
+	MovementPending = "pending"
+	MovementSettled = "settled"
+	MovementFailed  = "failed"
+)
+
+var (
+	// ErrDuplicateReference is returned when an external reference was already used for a different movement
+	ErrDuplicateReference = errors.New("external reference was already used for another movement")
+	// ErrMovementFinished is returned when settling or failing a movement that is no longer pending
+	ErrMovementFinished = errors.New("movement is no longer pending")
+	// ErrNotCustomerAccount is returned when money is deposited into or withdrawn from one of the bank's internal accounts
+	ErrNotCustomerAccount = errors.New("deposits and withdrawals need a customer account")
+)
+
+// MovementTxParams contains the input parameters of a deposit or a withdrawal
+type MovementTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+	//the payment network's ID of the movement, unique within the tenant
+	ExternalReference string `json:"external_reference"`
+}
+
+// MovementTxResult is a movement and the transfer the call posted for it
+type MovementTxResult struct {
+	Movement ExternalMovement `json:"movement"`
+	//empty when DepositTx or WithdrawTx found the movement already posted
+	Transfer Transfer `json:"transfer"`
+}
+
+// DepositTx records money arriving for an account. The funds are taken from the settlement account of the
+// account's currency into suspense; the customer is only credited once SettleMovementTx confirms them.
+// Repeating a deposit with the same external reference returns the movement posted the first time.
+func (store *Store) DepositTx(ctx context.Context, arg MovementTxParams) (MovementTxResult, error) {
+	return store.startMovement(ctx, MovementDeposit, arg)
+}
+
+// WithdrawTx records money leaving an account. The funds move into suspense at once, subject to the same
+// product rules and transfer limits as a transfer, and reach the settlement account once SettleMovementTx confirms them.
+// Repeating a withdrawal with the same external reference returns the movement posted the first time.
+func (store *Store) WithdrawTx(ctx context.Context, arg MovementTxParams) (MovementTxResult, error) {
+	return store.startMovement(ctx, MovementWithdrawal, arg)
+}
+
+func (store *Store) startMovement(ctx context.Context, kind string, arg MovementTxParams) (MovementTxResult, error) {
+	var result MovementTxResult
+	if arg.Amount <= 0 {
+		return result, errors.New("amount must be positive")
+	}
+	if arg.ExternalReference == "" {
+		return result, errors.New("external reference is required")
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = MovementTxResult{}
+
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+		if account.Owner == "" {
+			return ErrNotCustomerAccount
+		}
+
+		existing, err := q.GetExternalMovementByReference(ctx, GetExternalMovementByReferenceParams{
+			TenantID:          account.TenantID,
+			ExternalReference: arg.ExternalReference,
+		})
+		if err == nil {
+			if existing.Kind != kind || existing.AccountID != arg.AccountID || existing.Amount != arg.Amount {
+				return ErrDuplicateReference
+			}
+			result.Movement = existing
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get movement by reference: %w", err)
+		}
+
+		suspense, err := internalAccount(ctx, q, account.TenantID, ProductSuspense, account.Currency)
+		if err != nil {
+			return err
+		}
+
+		var from int64
+		switch kind {
+		case MovementDeposit:
+			//the customer is credited later, but a frozen account should not have money on its way in
+			if account.IsFrozen {
+				return ErrAccountFrozen
+			}
+			settlement, err := internalAccount(ctx, q, account.TenantID, ProductSettlement, account.Currency)
+			if err != nil {
+				return err
+			}
+			from = settlement.ID
+			if _, _, err := lockAccounts(ctx, q, settlement.ID, suspense.ID); err != nil {
+				return fmt.Errorf("failed to lock accounts: %w", err)
+			}
+		case MovementWithdrawal:
+			from = account.ID
+			account, _, err = lockAccounts(ctx, q, account.ID, suspense.ID)
+			if err != nil {
+				return fmt.Errorf("failed to lock accounts: %w", err)
+			}
+			if account.IsFrozen {
+				return ErrAccountFrozen
+			}
+			now := time.Now()
+			if err := checkProductRules(ctx, q, account, arg.Amount, now); err != nil {
+				return err
+			}
+			if err := checkTransferLimits(ctx, q, account, arg.Amount, now); err != nil {
+				return err
+			}
+		}
+
+		result.Transfer, err = store.postTransfer(ctx, q, CreateTransferParams{
+			FromAccountID:     from,
+			ToAccountID:       suspense.ID,
+			Amount:            arg.Amount,
+			Description:       kind,
+			ExternalReference: arg.ExternalReference,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Movement, err = q.CreateExternalMovement(ctx, CreateExternalMovementParams{
+			TenantID:          account.TenantID,
+			Kind:              kind,
+			AccountID:         account.ID,
+			Amount:            arg.Amount,
+			Currency:          account.Currency,
+			ExternalReference: arg.ExternalReference,
+			PendingTransferID: result.Transfer.ID,
+		})
+		if ErrorCode(err) == UniqueViolation {
+			//a concurrent call recorded the reference first
+			return ErrDuplicateReference
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record movement: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("%s of %d for account %d failed: %w", kind, arg.Amount, arg.AccountID, err)
+	}
+
+	store.logger.InfoContext(ctx, "external movement pending",
+		"movement_id", result.Movement.ID,
+		"kind", kind,
+		"account_id", arg.AccountID,
+		"amount", arg.Amount,
+		"external_reference", arg.ExternalReference,
+	)
+	return result, nil
+}
+
+// SettleMovementTx confirms a pending movement: a deposit's funds move from suspense to the customer,
+// a withdrawal's from suspense to the settlement account
+func (store *Store) SettleMovementTx(ctx context.Context, movementID int64) (MovementTxResult, error) {
+	return store.finishMovement(ctx, movementID, MovementSettled, "")
+}
+
+// FailMovementTxParams contains the input parameters to fail a pending movement
+type FailMovementTxParams struct {
+	MovementID int64  `json:"movement_id"`
+	Reason     string `json:"reason"`
+}
+
+// FailMovementTx records that the payment network rejected a pending movement and moves its funds
+// back where they came from: a deposit's to the settlement account, a withdrawal's to the customer
+func (store *Store) FailMovementTx(ctx context.Context, arg FailMovementTxParams) (MovementTxResult, error) {
+	if arg.Reason == "" {
+		return MovementTxResult{}, errors.New("failure reason is required")
+	}
+	return store.finishMovement(ctx, arg.MovementID, MovementFailed, arg.Reason)
+}
+
+func (store *Store) finishMovement(ctx context.Context, movementID int64, status string, reason string) (MovementTxResult, error) {
+	var result MovementTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		result = MovementTxResult{}
+
+		movement, err := q.GetExternalMovementForUpdate(ctx, movementID)
+		if err != nil {
+			return fmt.Errorf("failed to get movement: %w", err)
+		}
+		if movement.Status != MovementPending {
+			return ErrMovementFinished
+		}
+
+		suspense, err := internalAccount(ctx, q, movement.TenantID, ProductSuspense, movement.Currency)
+		if err != nil {
+			return err
+		}
+		settlement, err := internalAccount(ctx, q, movement.TenantID, ProductSettlement, movement.Currency)
+		if err != nil {
+			return err
+		}
+
+		//a settled deposit and a failed withdrawal end at the customer, the other two at the settlement account
+		to := settlement.ID
+		if (movement.Kind == MovementDeposit) == (status == MovementSettled) {
+			to = movement.AccountID
+		}
+		_, destination, err := lockAccounts(ctx, q, suspense.ID, to)
+		if err != nil {
+			return fmt.Errorf("failed to lock accounts: %w", err)
+		}
+		//returning a failed withdrawal's funds restores what the customer had, even while the account is frozen
+		if movement.Kind == MovementDeposit && status == MovementSettled && destination.IsFrozen {
+			return ErrAccountFrozen
+		}
+
+		result.Transfer, err = store.postTransfer(ctx, q, CreateTransferParams{
+			FromAccountID:     suspense.ID,
+			ToAccountID:       to,
+			Amount:            movement.Amount,
+			Description:       movement.Kind + " " + status,
+			ExternalReference: movement.ExternalReference,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Movement, err = q.FinishExternalMovement(ctx, FinishExternalMovementParams{
+			ID:              movement.ID,
+			Status:          status,
+			FailureReason:   sql.NullString{String: reason, Valid: reason != ""},
+			FinalTransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to finish movement: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("movement %d could not be %s: %w", movementID, status, err)
+	}
+
+	store.logger.InfoContext(ctx, "external movement "+status,
+		"movement_id", movementID,
+		"kind", result.Movement.Kind,
+		"account_id", result.Movement.AccountID,
+		"amount", result.Movement.Amount,
+	)
+	return result, nil
+}
+
+// internalAccount returns the tenant's internal account of a product and currency, opening it on first use
+func internalAccount(ctx context.Context, q *Queries, tenantID, product, currency string) (Account, error) {
+	account, err := q.CreateInternalAccount(ctx, CreateInternalAccountParams{
+		Currency: currency,
+		Product:  product,
+		TenantID: tenantID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		account, err = q.GetInternalAccount(ctx, GetInternalAccountParams{
+			TenantID: tenantID,
+			Product:  product,
+			Currency: currency,
+		})
+	}
+	if err != nil {
+		return account, fmt.Errorf("failed to get %s %s account: %w", currency, product, err)
+	}
+	return account, nil
+}
+
+// postTransfer moves arg's amount between two accounts the caller has locked, writing the transfer, both entries
+// and balances, and publishes the transfer to the owners of the accounts
+func (store *Store) postTransfer(ctx context.Context, q *Queries, arg CreateTransferParams) (Transfer, error) {
+	if arg.Metadata == nil {
+		arg.Metadata = noMetadata
+	}
+	transfer, err := q.CreateTransfer(ctx, arg)
+	if err != nil {
+		return transfer, fmt.Errorf("failed to create transfer: %w", err)
+	}
+	if _, err := q.CreateEntry(ctx, transferEntry(transfer, arg.FromAccountID, -arg.Amount)); err != nil {
+		return transfer, fmt.Errorf("failed to create from entry: %w", err)
+	}
+	if _, err := q.CreateEntry(ctx, transferEntry(transfer, arg.ToAccountID, arg.Amount)); err != nil {
+		return transfer, fmt.Errorf("failed to create to entry: %w", err)
+	}
+	from, to, err := addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	if err != nil {
+		return transfer, fmt.Errorf("failed to update account balances: %w", err)
+	}
+	return transfer, store.publish(ctx, q, transferEvents(transfer, from, to)...)
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	"goprojects/simplebank/util"
+)
+
+func requireBalance(t *testing.T, q *Queries, accountID int64, balance int64) {
+	account, err := q.GetAccount(context.Background(), accountID)
+	require.NoError(t, err)
+	require.Equal(t, balance, account.Balance)
+}
+
+func TestDepositTx(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	ctx := context.Background()
+	account := createProductAccount(t, q, util.RandomOwner(), ProductChecking, 0)
+	arg := MovementTxParams{AccountID: account.ID, Amount: 250, ExternalReference: util.RandomString(12)}
+
+	// A pending deposit is not spendable yet.
+	pending, err := store.DepositTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, MovementDeposit, pending.Movement.Kind)
+	require.Equal(t, MovementPending, pending.Movement.Status)
+	require.Equal(t, pending.Transfer.ID, pending.Movement.PendingTransferID)
+	requireBalance(t, q, account.ID, 0)
+
+	// The payment network reporting it again posts nothing, reusing its reference for something else is refused.
+	again, err := store.DepositTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, pending.Movement, again.Movement)
+	require.Zero(t, again.Transfer.ID)
+
+	_, err = store.DepositTx(ctx, MovementTxParams{AccountID: account.ID, Amount: 1, ExternalReference: arg.ExternalReference})
+	require.ErrorIs(t, err, ErrDuplicateReference)
+
+	settled, err := store.SettleMovementTx(ctx, pending.Movement.ID)
+	require.NoError(t, err)
+	require.Equal(t, MovementSettled, settled.Movement.Status)
+	require.Equal(t, account.ID, settled.Transfer.ToAccountID)
+	require.True(t, settled.Movement.FinishedAt.Valid)
+	requireBalance(t, q, account.ID, 250)
+
+	_, err = store.SettleMovementTx(ctx, pending.Movement.ID)
+	require.ErrorIs(t, err, ErrMovementFinished)
+
+	// The money came from the settlement account of the currency.
+	settlement, err := q.GetAccount(ctx, pending.Transfer.FromAccountID)
+	require.NoError(t, err)
+	require.Equal(t, ProductSettlement, settlement.Product)
+	require.Equal(t, account.Currency, settlement.Currency)
+}
+
+func TestDepositTxFailed(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	ctx := context.Background()
+	account := createProductAccount(t, q, util.RandomOwner(), ProductChecking, 0)
+
+	pending, err := store.DepositTx(ctx, MovementTxParams{AccountID: account.ID, Amount: 80, ExternalReference: util.RandomString(12)})
+	require.NoError(t, err)
+
+	_, err = store.FailMovementTx(ctx, FailMovementTxParams{MovementID: pending.Movement.ID})
+	require.Error(t, err)
+
+	failed, err := store.FailMovementTx(ctx, FailMovementTxParams{MovementID: pending.Movement.ID, Reason: "returned by the sending bank"})
+	require.NoError(t, err)
+	require.Equal(t, MovementFailed, failed.Movement.Status)
+	require.Equal(t, "returned by the sending bank", failed.Movement.FailureReason.String)
+	require.Equal(t, pending.Transfer.FromAccountID, failed.Transfer.ToAccountID)
+	requireBalance(t, q, account.ID, 0)
+
+	_, err = store.SettleMovementTx(ctx, pending.Movement.ID)
+	require.ErrorIs(t, err, ErrMovementFinished)
+}
+
+func TestWithdrawTx(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	ctx := context.Background()
+	account := createProductAccount(t, q, util.RandomOwner(), ProductChecking, 100)
+
+	_, err := store.WithdrawTx(ctx, MovementTxParams{AccountID: account.ID, Amount: 101, ExternalReference: util.RandomString(12)})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// A pending withdrawal can no longer be spent, and comes back when it fails.
+	pending, err := store.WithdrawTx(ctx, MovementTxParams{AccountID: account.ID, Amount: 60, ExternalReference: util.RandomString(12)})
+	require.NoError(t, err)
+	requireBalance(t, q, account.ID, 40)
+
+	_, err = store.FailMovementTx(ctx, FailMovementTxParams{MovementID: pending.Movement.ID, Reason: "beneficiary account closed"})
+	require.NoError(t, err)
+	requireBalance(t, q, account.ID, 100)
+
+	pending, err = store.WithdrawTx(ctx, MovementTxParams{AccountID: account.ID, Amount: 70, ExternalReference: util.RandomString(12)})
+	require.NoError(t, err)
+	settled, err := store.SettleMovementTx(ctx, pending.Movement.ID)
+	require.NoError(t, err)
+	require.Equal(t, MovementSettled, settled.Movement.Status)
+	requireBalance(t, q, account.ID, 30)
+
+	settlement, err := q.GetAccount(ctx, settled.Transfer.ToAccountID)
+	require.NoError(t, err)
+	require.Equal(t, ProductSettlement, settlement.Product)
+}
+
+func TestMovementTxNeedsCustomerAccount(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	internal := createProductAccount(t, q, "", ProductInterestExpense, 0)
+
+	_, err := store.DepositTx(context.Background(), MovementTxParams{AccountID: internal.ID, Amount: 10, ExternalReference: util.RandomString(12)})
+	require.ErrorIs(t, err, ErrNotCustomerAccount)
+}
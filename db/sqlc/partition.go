@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PartitionedTables are split into monthly partitions on created_at
+var PartitionedTables = []string{"entries", "transfers"}
+
+// partitionLayout is the suffix of a partition name, see monthly_partition_name in the migrations
+const partitionLayout = "y2006m01"
+
+// PartitionName returns the name of the partition of table holding the month containing t, in UTC
+func PartitionName(table string, t time.Time) string {
+	return table + "_" + t.UTC().Format(partitionLayout)
+}
+
+// PartitionMonth returns the first instant of the month a partition of table holds, parsed from its name
+func PartitionMonth(table string, name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, table+"_")
+	if !ok {
+		return time.Time{}, false
+	}
+	month, err := time.Parse(partitionLayout, suffix)
+	return month, err == nil
+}
+
+// MaintainPartitionsParams contains the input parameters of a partition maintenance run
+type MaintainPartitionsParams struct {
+	Now time.Time
+	// MonthsAhead is how many months after the current one must already have a partition
+	MonthsAhead int
+	// RetentionMonths is how many months before the current one stay attached; zero keeps every month attached
+	RetentionMonths int
+}
+
+// MaintainPartitionsResult names the partitions a maintenance run created and detached
+type MaintainPartitionsResult struct {
+	Created  []string `json:"created"`
+	Detached []string `json:"detached"`
+}
+
+// MaintainPartitions creates the partitions of the current and coming months and detaches those past retention.
+// A month is only detached once the business day it ends with is closed, so nothing can still post into it.
+// Detached partitions keep their rows as standalone tables until they are archived.
+func (store *Store) MaintainPartitions(ctx context.Context, arg MaintainPartitionsParams) (MaintainPartitionsResult, error) {
+	var result MaintainPartitionsResult
+	year, month, _ := arg.Now.UTC().Date()
+	current := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, table := range PartitionedTables {
+		for i := 0; i <= arg.MonthsAhead; i++ {
+			month := current.AddDate(0, i, 0)
+			created, err := store.CreateMonthlyPartition(ctx, CreateMonthlyPartitionParams{Parent: table, Month: month})
+			if err != nil {
+				return result, fmt.Errorf("failed to create partition %s: %w", PartitionName(table, month), err)
+			}
+			if created {
+				result.Created = append(result.Created, PartitionName(table, month))
+			}
+		}
+	}
+
+	if arg.RetentionMonths > 0 {
+		if err := store.detachPartitions(ctx, current.AddDate(0, -arg.RetentionMonths, 0), &result); err != nil {
+			return result, err
+		}
+	}
+
+	if len(result.Created) > 0 || len(result.Detached) > 0 {
+		store.logger.InfoContext(ctx, "partitions maintained", "created", result.Created, "detached", result.Detached)
+	}
+	return result, nil
+}
+
+// detachPartitions detaches the partitions of months starting before cutoff that lie wholly in closed business days
+func (store *Store) detachPartitions(ctx context.Context, cutoff time.Time, result *MaintainPartitionsResult) error {
+	closed, err := store.GetLatestBusinessDay(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get latest business day: %w", err)
+	}
+
+	for _, table := range PartitionedTables {
+		names, err := store.ListMonthlyPartitions(ctx, table)
+		if err != nil {
+			return fmt.Errorf("failed to list partitions of %s: %w", table, err)
+		}
+
+		for _, name := range names {
+			month, ok := PartitionMonth(table, name)
+			if !ok || !month.Before(cutoff) || month.AddDate(0, 1, 0).After(closed.ClosesAt) {
+				continue
+			}
+			detached, err := store.DetachMonthlyPartition(ctx, DetachMonthlyPartitionParams{Parent: table, Month: month})
+			if err != nil {
+				return fmt.Errorf("failed to detach partition %s: %w", name, err)
+			}
+			if detached {
+				result.Detached = append(result.Detached, name)
+			}
+		}
+	}
+	return nil
+}
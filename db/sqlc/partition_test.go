@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	"goprojects/simplebank/util"
+)
+
+func TestPartitionName(t *testing.T) {
+	at := time.Date(2024, time.March, 31, 23, 0, 0, 0, time.FixedZone("UTC-2", -2*60*60))
+	name := PartitionName("entries", at)
+	require.Equal(t, "entries_y2024m04", name)
+
+	month, ok := PartitionMonth("entries", name)
+	require.True(t, ok)
+	require.Equal(t, time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC), month)
+
+	_, ok = PartitionMonth("transfers", name)
+	require.False(t, ok)
+	_, ok = PartitionMonth("entries", "entries_unpartitioned")
+	require.False(t, ok)
+}
+
+func TestMaintainPartitions(t *testing.T) {
+	conn := dbtest.New(t)
+	store := NewStore(conn)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	// The migration prepared three months ahead, the run adds the fourth.
+	result, err := store.MaintainPartitions(ctx, MaintainPartitionsParams{Now: now, MonthsAhead: 4})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		PartitionName("entries", current.AddDate(0, 4, 0)),
+		PartitionName("transfers", current.AddDate(0, 4, 0)),
+	}, result.Created)
+
+	old := current.AddDate(0, -6, 0)
+	for _, table := range PartitionedTables {
+		created, err := store.CreateMonthlyPartition(ctx, CreateMonthlyPartitionParams{Parent: table, Month: old})
+		require.NoError(t, err)
+		require.True(t, created)
+	}
+
+	// A flag on a transfer of the old month must not keep the month from being detached.
+	q := New(conn)
+	account, err := q.CreateAccount(ctx, CreateAccountParams{Owner: util.RandomOwner(), Balance: 100, Currency: util.USD})
+	require.NoError(t, err)
+	var transferID int64
+	err = conn.QueryRowContext(ctx,
+		`INSERT INTO transfers (from_account_id, to_account_id, amount, created_at) VALUES ($1, $1, 1, $2) RETURNING id`,
+		account.ID, old.Add(time.Hour),
+	).Scan(&transferID)
+	require.NoError(t, err)
+	flag, err := q.CreateFlaggedTransfer(ctx, CreateFlaggedTransferParams{
+		TransferID:        transferID,
+		TransferCreatedAt: old.Add(time.Hour),
+		Rule:              "test-rule",
+		Reason:            "held by test",
+	})
+	require.NoError(t, err)
+	require.True(t, flag.TransferCreatedAt.Valid)
+
+	// Nothing is detached before the business days covering it are closed.
+	result, err = store.MaintainPartitions(ctx, MaintainPartitionsParams{Now: now, MonthsAhead: 4, RetentionMonths: 3})
+	require.NoError(t, err)
+	require.Empty(t, result.Created)
+	require.Empty(t, result.Detached)
+
+	_, err = store.CloseBusinessDayTx(ctx, CloseBusinessDayTxParams{
+		Date:     BusinessDate(now, time.UTC).AddDate(0, 0, -1),
+		Location: time.UTC,
+		Actor:    "test",
+	})
+	require.NoError(t, err)
+
+	result, err = store.MaintainPartitions(ctx, MaintainPartitionsParams{Now: now, MonthsAhead: 4, RetentionMonths: 3})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{PartitionName("entries", old), PartitionName("transfers", old)}, result.Detached)
+
+	// The flag still names its transfer, now in the detached month, but no longer holds it in the foreign key.
+	flag, err = q.GetFlaggedTransfer(ctx, flag.ID)
+	require.NoError(t, err)
+	require.Equal(t, transferID, flag.TransferID)
+	require.False(t, flag.TransferCreatedAt.Valid)
+
+	names, err := store.ListMonthlyPartitions(ctx, "entries")
+	require.NoError(t, err)
+	require.NotContains(t, names, PartitionName("entries", old))
+	require.Contains(t, names, PartitionName("entries", current))
+}
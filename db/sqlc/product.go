@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// products seeded by the migration; customer accounts default to ProductChecking
+const (
+	ProductChecking        = "checking"
+	ProductSavings         = "savings"
+	ProductLoan            = "loan"
+	ProductSettlement      = "settlement"
+	ProductFeeIncome       = "fee_income"
+	ProductInterestExpense = "interest_expense"
+	ProductSuspense        = "suspense"
+	ProductEquity          = "equity"
+)
+
+// categories of the chart of accounts
+const (
+	CategoryAsset     = "asset"
+	CategoryLiability = "liability"
+	CategoryEquity    = "equity"
+	CategoryIncome    = "income"
+	CategoryExpense   = "expense"
+)
+
+// the side a category's balances normally sit on; the ledger stores credits as positive amounts
+const (
+	NormalDebit  = "debit"
+	NormalCredit = "credit"
+)
+
+// LimitMonthlyWithdrawalCount names the product limit on outbound transfers per calendar month
+const LimitMonthlyWithdrawalCount = "monthly_withdrawal_count"
+
+// LimitScopeProduct reports a limit that comes with the sending account's product rather than a transfer_limits row
+const LimitScopeProduct = "product"
+
+// ErrInsufficientFunds is returned when a transfer would take an account below the minimum balance of its product
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// StatedBalance returns a balance the way the chart of accounts states it: positive while it sits on the normal side.
+// An asset such as a loan the customer drew is stored negative and stated positive.
+func StatedBalance(normalBalance string, balance int64) int64 {
+	if normalBalance == NormalDebit {
+		return -balance
+	}
+	return balance
+}
+
+// SelfService reports whether customers may open accounts of the product themselves:
+// the bank's internal accounts and products that can be overdrawn, such as loans, are opened by staff
+func (product AccountProduct) SelfService() bool {
+	return !product.Internal && product.MinBalance.Valid
+}
+
+// checkMinBalance returns ErrInsufficientFunds when taking amount from the locked account
+// would leave it below the minimum balance of its product
+func checkMinBalance(account Account, product AccountProduct, amount int64) error {
+	if product.MinBalance.Valid && account.Balance-amount < product.MinBalance.Int64 {
+		return fmt.Errorf("%w: account %d holds %d, a %s account must keep %d",
+			ErrInsufficientFunds, account.ID, account.Balance, product.Code, product.MinBalance.Int64)
+	}
+	return nil
+}
+
+// checkProductRules enforces the rules of the sending account's product. Like checkTransferLimits,
+// it must run inside the transfer's transaction after the sending account row has been locked.
+func checkProductRules(ctx context.Context, q *Queries, from Account, amount int64, now time.Time) error {
+	product, err := q.GetAccountProduct(ctx, from.Product)
+	if err != nil {
+		return fmt.Errorf("failed to get product %s: %w", from.Product, err)
+	}
+
+	if err := checkMinBalance(from, product, amount); err != nil {
+		return err
+	}
+
+	if product.MonthlyWithdrawalLimit.Valid {
+		now = now.UTC()
+		row, err := q.GetAccountOutboundSince(ctx, GetAccountOutboundSinceParams{
+			AccountID: from.ID,
+			Since:     time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+		})
+		if err != nil {
+			return err
+		}
+		max := int64(product.MonthlyWithdrawalLimit.Int32)
+		if row.TransferCount+1 > max {
+			return &LimitExceededError{
+				Scope:     LimitScopeProduct,
+				Limit:     LimitMonthlyWithdrawalCount,
+				Max:       max,
+				Attempted: row.TransferCount + 1,
+			}
+		}
+	}
+	return nil
+}
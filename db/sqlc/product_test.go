@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	"goprojects/simplebank/util"
+)
+
+func createProductAccount(t *testing.T, q *Queries, owner, product string, balance int64) Account {
+	account, err := q.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    owner,
+		Balance:  balance,
+		Currency: util.USD,
+		Product:  sql.NullString{String: product, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, product, account.Product)
+	return account
+}
+
+func TestStatedBalance(t *testing.T) {
+	require.Equal(t, int64(100), StatedBalance(NormalCredit, 100))
+	require.Equal(t, int64(250), StatedBalance(NormalDebit, -250))
+}
+
+func TestListAccountProducts(t *testing.T) {
+	q := New(dbtest.New(t))
+	products, err := q.ListAccountProducts(context.Background())
+	require.NoError(t, err)
+
+	byCode := make(map[string]AccountProduct, len(products))
+	for _, product := range products {
+		byCode[product.Code] = product
+	}
+	require.Equal(t, NormalCredit, byCode[ProductChecking].NormalBalance)
+	require.True(t, byCode[ProductChecking].SelfService())
+	require.Equal(t, NormalDebit, byCode[ProductLoan].NormalBalance)
+	require.False(t, byCode[ProductLoan].SelfService())
+	require.True(t, byCode[ProductSettlement].Internal)
+	require.False(t, byCode[ProductSettlement].SelfService())
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	account1 := createProductAccount(t, q, util.RandomOwner(), ProductChecking, 50)
+	account2 := createRandomAccount(t, q)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        51,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// The whole balance may be spent.
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        50,
+	})
+	require.NoError(t, err)
+	require.Zero(t, result.FromAccount.Balance)
+}
+
+func TestTransferTxSavingsWithdrawalLimit(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	savings := createProductAccount(t, q, util.RandomOwner(), ProductSavings, 100)
+	account := createRandomAccount(t, q)
+
+	product, err := q.GetAccountProduct(context.Background(), ProductSavings)
+	require.NoError(t, err)
+	require.True(t, product.MonthlyWithdrawalLimit.Valid)
+
+	arg := TransferTxParams{FromAccountID: savings.ID, ToAccountID: account.ID, Amount: 1}
+	for i := int32(0); i < product.MonthlyWithdrawalLimit.Int32; i++ {
+		_, err := store.TransferTx(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	_, err = store.TransferTx(context.Background(), arg)
+	var limitErr *LimitExceededError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitScopeProduct, limitErr.Scope)
+	require.Equal(t, LimitMonthlyWithdrawalCount, limitErr.Limit)
+
+	// Deposits into the savings account are not limited.
+	_, err = store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account.ID, ToAccountID: savings.ID, Amount: 1})
+	require.NoError(t, err)
+}
+
+func TestTransferTxLoanGoesNegative(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	owner := util.RandomOwner()
+	loan := createProductAccount(t, q, owner, ProductLoan, 0)
+	checking := createProductAccount(t, q, owner, ProductChecking, 0)
+
+	// Drawing the loan pays the customer; the loan account then states what they owe.
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: loan.ID,
+		ToAccountID:   checking.ID,
+		Amount:        500,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-500), result.FromAccount.Balance)
+	require.Equal(t, int64(500), StatedBalance(NormalDebit, result.FromAccount.Balance))
+	require.Equal(t, int64(500), result.ToAccount.Balance)
+}
+
+func TestInternalAccountOwner(t *testing.T) {
+	q := New(dbtest.New(t))
+	// Internal accounts belong to the bank, never to a customer, and customer accounts always have an owner.
+	_, err := q.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Currency: util.USD,
+		Product:  sql.NullString{String: ProductFeeIncome, Valid: true},
+	})
+	require.Error(t, err)
+
+	_, err = q.CreateAccount(context.Background(), CreateAccountParams{Currency: util.USD})
+	require.Error(t, err)
+
+	account := createProductAccount(t, q, "", ProductFeeIncome, 0)
+	got, err := q.GetInternalAccount(context.Background(), GetInternalAccountParams{
+		TenantID: DefaultTenant,
+		Product:  ProductFeeIncome,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account, got)
+
+	// One internal account per product and currency.
+	_, err = q.CreateAccount(context.Background(), CreateAccountParams{
+		Currency: util.USD,
+		Product:  sql.NullString{String: ProductFeeIncome, Valid: true},
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// AuditReverseTransfer is the audit_logs action recorded for a transfer reversal
+const AuditReverseTransfer = "reverse_transfer"
+
+var (
+	// ErrAlreadyReversed is returned when a transfer that was already reversed is reversed again
+	ErrAlreadyReversed = errors.New("transfer is already reversed")
+	// ErrTransferNotSettled is returned for a transfer still held for review or rejected by an analyst,
+	// whose funds never reached the recipient
+	ErrTransferNotSettled = errors.New("transfer did not reach its recipient")
+)
+
+// ReverseTransferTxParams contains the input parameters to reverse a transfer
+type ReverseTransferTxParams struct {
+	TransferID int64  `json:"transfer_id"`
+	Actor      string `json:"actor"`
+	Reason     string `json:"reason"`
+	// AllowOverdraft lets an administrator take back funds the recipient already spent,
+	// leaving it below the minimum balance of its product
+	AllowOverdraft bool `json:"allow_overdraft"`
+}
+
+// ReverseTransferTxResult is the original transfer, the transfer that undoes it and the record linking the two
+type ReverseTransferTxResult struct {
+	Original Transfer `json:"original"`
+	TransferTxResult
+	Reversal TransferReversal `json:"reversal"`
+}
+
+// ReverseTransferTx moves a settled transfer's amount back from the recipient to the sender.
+// It is an operator correction, so it skips limits and screening but is audited and allowed once per transfer.
+// The recipient must still cover the amount with its product's minimum balance unless arg.AllowOverdraft is set.
+func (store *Store) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (ReverseTransferTxResult, error) {
+	var result ReverseTransferTxResult
+
+	if arg.Reason == "" {
+		return result, errors.New("reversal reason is required")
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result = ReverseTransferTxResult{}
+
+		result.Original, err = q.GetTransfer(ctx, arg.TransferID)
+		if err != nil {
+			return fmt.Errorf("ReverseTransferTx - failed to get transfer: %w", err)
+		}
+
+		_, err = q.GetTransferReversal(ctx, arg.TransferID)
+		if err == nil {
+			return ErrAlreadyReversed
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("ReverseTransferTx - failed to get reversal: %w", err)
+		}
+
+		flag, err := q.GetTransferFlag(ctx, arg.TransferID)
+		if err == nil && flag.Status != FlagStatusCleared {
+			return ErrTransferNotSettled
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("ReverseTransferTx - failed to get flag: %w", err)
+		}
+
+		from, to := result.Original.ToAccountID, result.Original.FromAccountID
+		amount := result.Original.Amount
+
+		recipient, _, err := lockAccounts(ctx, q, from, to)
+		if err != nil {
+			return fmt.Errorf("ReverseTransferTx - failed to lock accounts: %w", err)
+		}
+
+		if !arg.AllowOverdraft {
+			product, err := q.GetAccountProduct(ctx, recipient.Product)
+			if err != nil {
+				return fmt.Errorf("ReverseTransferTx - failed to get product %s: %w", recipient.Product, err)
+			}
+			if err := checkMinBalance(recipient, product, amount); err != nil {
+				return fmt.Errorf("ReverseTransferTx - %w", err)
+			}
+		}
+
+		//the reversal keeps the original's reference, so looking it up finds both
+		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+			FromAccountID:     from,
+			ToAccountID:       to,
+			Amount:            amount,
+			Description:       fmt.Sprintf("reversal of transfer %d", arg.TransferID),
+			ExternalReference: result.Original.ExternalReference,
+			Metadata:          result.Original.Metadata,
+		})
+		if err != nil {
+			return fmt.Errorf("ReverseTransferTx - failed to create transfer: %w", err)
+		}
+
+		result.FromEntry, err = q.CreateEntry(ctx, transferEntry(result.Transfer, from, -amount))
+		if err != nil {
+			return fmt.Errorf("ReverseTransferTx - failed to create from entry: %w", err)
+		}
+		result.ToEntry, err = q.CreateEntry(ctx, transferEntry(result.Transfer, to, amount))
+		if err != nil {
+			return fmt.Errorf("ReverseTransferTx - failed to create to entry: %w", err)
+		}
+
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, from, -amount, to, amount)
+		if err != nil {
+			return fmt.Errorf("ReverseTransferTx - failed to update account balances: %w", err)
+		}
+
+		result.Reversal, err = q.CreateTransferReversal(ctx, CreateTransferReversalParams{
+			TransferID:        arg.TransferID,
+			TransferCreatedAt: result.Original.CreatedAt,
+			ReversalID:        result.Transfer.ID,
+			ReversalCreatedAt: result.Transfer.CreatedAt,
+			Actor:             arg.Actor,
+			Reason:            arg.Reason,
+		})
+		if ErrorCode(err) == UniqueViolation {
+			//a concurrent reversal committed first
+			return ErrAlreadyReversed
+		}
+		if err != nil {
+			return fmt.Errorf("ReverseTransferTx - failed to record reversal: %w", err)
+		}
+
+		_, err = writeAuditLog(ctx, q, CreateAuditLogParams{
+			Actor:     arg.Actor,
+			Action:    AuditReverseTransfer,
+			AccountID: sql.NullInt64{Int64: result.Original.FromAccountID, Valid: true},
+		}, map[string]any{
+			"transfer_id":     arg.TransferID,
+			"reversal_id":     result.Transfer.ID,
+			"amount":          amount,
+			"reason":          arg.Reason,
+			"allow_overdraft": arg.AllowOverdraft,
+		})
+		if err != nil {
+			return err
+		}
+		return store.publish(ctx, q, transferEvents(result.Transfer, result.FromAccount, result.ToAccount)...)
+	})
+
+	return result, err
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+)
+
+func TestReverseTransferTx(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+	amount := int64(10)
+
+	transfer, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: transfer.Transfer.ID, Actor: "ops"})
+	require.Error(t, err)
+
+	result, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+		Actor:      "ops",
+		Reason:     "sent to the wrong account",
+	})
+	require.NoError(t, err)
+	require.Equal(t, transfer.Transfer, result.Original)
+	require.Equal(t, account2.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, result.Transfer.ToAccountID)
+	require.Equal(t, amount, result.Transfer.Amount)
+	require.Equal(t, -amount, result.FromEntry.Amount)
+	require.Equal(t, amount, result.ToEntry.Amount)
+	require.Equal(t, result.Transfer.ID, result.Reversal.ReversalID)
+	require.WithinDuration(t, result.Original.CreatedAt, result.Reversal.TransferCreatedAt.Time, 0)
+	require.WithinDuration(t, result.Transfer.CreatedAt, result.Reversal.ReversalCreatedAt.Time, 0)
+
+	// Both balances are back where they started.
+	require.Equal(t, account1.Balance, result.ToAccount.Balance)
+	require.Equal(t, account2.Balance, result.FromAccount.Balance)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+		Actor:      "ops",
+		Reason:     "again",
+	})
+	require.ErrorIs(t, err, ErrAlreadyReversed)
+}
+
+func TestReverseHeldTransferTx(t *testing.T) {
+	conn := dbtest.New(t)
+	store := NewStore(conn)
+	held, _, _ := createHeldTransfer(t, conn)
+
+	// A held transfer never reached its recipient, so there is nothing to reverse.
+	_, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: held.Transfer.ID,
+		Actor:      "ops",
+		Reason:     "customer request",
+	})
+	require.ErrorIs(t, err, ErrTransferNotSettled)
+}
+
+func TestReverseSpentTransferTx(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+	amount := int64(10)
+
+	transfer, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        amount,
+	})
+	require.NoError(t, err)
+
+	// The recipient spends everything it holds, the transferred amount included.
+	spent, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        transfer.ToAccount.Balance,
+	})
+	require.NoError(t, err)
+	require.Zero(t, spent.FromAccount.Balance)
+
+	arg := ReverseTransferTxParams{
+		TransferID: transfer.Transfer.ID,
+		Actor:      "ops",
+		Reason:     "sent to the wrong account",
+	}
+	_, err = store.ReverseTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	account2, err = q.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Zero(t, account2.Balance)
+	_, err = q.GetTransferReversal(context.Background(), transfer.Transfer.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// An administrator may take the funds back anyway, overdrawing the recipient.
+	arg.AllowOverdraft = true
+	result, err := store.ReverseTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, account2.ID, result.FromAccount.ID)
+	require.Equal(t, -amount, result.FromAccount.Balance)
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"goprojects/simplebank/risk"
+)
+
+// statuses of a flagged_transfers row
+const (
+	FlagStatusPending  = "pending"
+	FlagStatusCleared  = "cleared"
+	FlagStatusRejected = "rejected"
+)
+
+// ErrTransferBlocked is matched with errors.Is for any transfer the risk screener blocked
+var ErrTransferBlocked = errors.New("transfer blocked by risk screening")
+
+// ErrFlagNotPending is returned when an analyst resolves a hold that was already cleared or rejected
+var ErrFlagNotPending = errors.New("flagged transfer is not pending review")
+
+// ScreeningError carries the decision that blocked a transfer
+type ScreeningError struct {
+	Decision risk.Decision
+}
+
+func (e *ScreeningError) Error() string {
+	return fmt.Sprintf("blocked by rule %s: %s", e.Decision.Rule, e.Decision.Reason)
+}
+
+func (e *ScreeningError) Unwrap() error {
+	return ErrTransferBlocked
+}
+
+// screen runs the configured screener inside the transfer's transaction.
+// A block is returned as a ScreeningError so the transaction rolls back.
+func (store *Store) screen(ctx context.Context, q *Queries, from Account, to Account, amount int64, now time.Time) (risk.Decision, error) {
+	if store.screener == nil {
+		return risk.Decision{Outcome: risk.OutcomeAllow}, nil
+	}
+
+	decision, err := store.screener.Screen(ctx, risk.Transfer{
+		FromAccountID:        from.ID,
+		ToAccountID:          to.ID,
+		Amount:               amount,
+		Currency:             from.Currency,
+		FromAccountCreatedAt: from.CreatedAt,
+		At:                   now,
+	}, transferHistory{q: q, accountID: from.ID})
+	if err != nil {
+		return decision, fmt.Errorf("risk screening failed: %w", err)
+	}
+
+	if decision.Outcome == risk.OutcomeBlock {
+		return decision, &ScreeningError{Decision: decision}
+	}
+	return decision, nil
+}
+
+// transferHistory implements risk.History on top of the queries of the running transaction
+type transferHistory struct {
+	q         *Queries
+	accountID int64
+}
+
+func (h transferHistory) Outbound(ctx context.Context, since time.Time) ([]risk.Movement, error) {
+	transfers, err := h.q.ListOutboundTransfersSince(ctx, ListOutboundTransfersSinceParams{
+		FromAccountID: h.accountID,
+		CreatedAt:     since,
+	})
+	return movements(transfers), err
+}
+
+func (h transferHistory) Inbound(ctx context.Context, since time.Time) ([]risk.Movement, error) {
+	transfers, err := h.q.ListInboundTransfersSince(ctx, ListInboundTransfersSinceParams{
+		ToAccountID: h.accountID,
+		CreatedAt:   since,
+	})
+	return movements(transfers), err
+}
+
+func movements(transfers []Transfer) []risk.Movement {
+	result := make([]risk.Movement, len(transfers))
+	for i, transfer := range transfers {
+		result[i] = risk.Movement{Amount: transfer.Amount, At: transfer.CreatedAt}
+	}
+	return result
+}
+
+// ReviewFlaggedTransferParams contains the analyst's decision on a held transfer
+type ReviewFlaggedTransferParams struct {
+	FlagID     int64  `json:"flag_id"`
+	ReviewedBy string `json:"reviewed_by"`
+	Note       string `json:"note"`
+}
+
+// ReviewFlaggedTransferResult contains the resolved hold and the entry that released its funds
+type ReviewFlaggedTransferResult struct {
+	Flag     FlaggedTransfer `json:"flag"`
+	Transfer Transfer        `json:"transfer"`
+	//the credit to the recipient on clear, or the refund to the sender on reject
+	Entry   Entry   `json:"entry"`
+	Account Account `json:"account"`
+}
+
+// ClearFlaggedTransferTx releases a held transfer to its recipient
+func (store *Store) ClearFlaggedTransferTx(ctx context.Context, arg ReviewFlaggedTransferParams) (ReviewFlaggedTransferResult, error) {
+	return store.resolveFlaggedTransferTx(ctx, arg, FlagStatusCleared)
+}
+
+// RejectFlaggedTransferTx returns a held transfer's funds to the sender
+func (store *Store) RejectFlaggedTransferTx(ctx context.Context, arg ReviewFlaggedTransferParams) (ReviewFlaggedTransferResult, error) {
+	return store.resolveFlaggedTransferTx(ctx, arg, FlagStatusRejected)
+}
+
+func (store *Store) resolveFlaggedTransferTx(ctx context.Context, arg ReviewFlaggedTransferParams, status string) (ReviewFlaggedTransferResult, error) {
+	var result ReviewFlaggedTransferResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Flag, err = q.ResolveFlaggedTransfer(ctx, ResolveFlaggedTransferParams{
+			ID:         arg.FlagID,
+			Status:     status,
+			ReviewedBy: sql.NullString{String: arg.ReviewedBy, Valid: true},
+			ReviewNote: sql.NullString{String: arg.Note, Valid: arg.Note != ""},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrFlagNotPending
+		}
+		if err != nil {
+			return fmt.Errorf("failed to resolve flagged transfer: %w", err)
+		}
+
+		result.Transfer, err = q.GetTransfer(ctx, result.Flag.TransferID)
+		if err != nil {
+			return fmt.Errorf("failed to get flagged transfer: %w", err)
+		}
+
+		accountID := result.Transfer.ToAccountID
+		if status == FlagStatusRejected {
+			accountID = result.Transfer.FromAccountID
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, transferEntry(result.Transfer, accountID, result.Transfer.Amount))
+		if err != nil {
+			return fmt.Errorf("failed to create entry: %w", err)
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     accountID,
+			Amount: result.Transfer.Amount,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update account balance: %w", err)
+		}
+
+		//TransferTx told the sender about the transfer when it was held, the recipient hears of it now
+		if status == FlagStatusCleared {
+			return store.publish(ctx, q, transferEvents(result.Transfer, result.Account)...)
+		}
+		return store.publish(ctx, q, balanceEvent(result.Account))
+	})
+
+	return result, err
+}
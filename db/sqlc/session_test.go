@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"goprojects/simplebank/db/dbtest"
+	"goprojects/simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomSession(t *testing.T, q *Queries, username string) Session {
+	arg := CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    "test-agent",
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}
+
+	session, err := q.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, session.ID)
+	require.Equal(t, arg.Username, session.Username)
+	require.Equal(t, arg.RefreshToken, session.RefreshToken)
+	require.False(t, session.IsBlocked)
+	require.WithinDuration(t, arg.ExpiresAt, session.ExpiresAt, time.Second)
+
+	return session
+}
+
+func TestGetSession(t *testing.T) {
+	q := New(dbtest.New(t))
+	user := createRandomUser(t, q)
+	session1 := createRandomSession(t, q, user.Username)
+
+	session2, err := q.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.Equal(t, session1.ID, session2.ID)
+	require.Equal(t, session1.RefreshToken, session2.RefreshToken)
+	require.Equal(t, session1.ClientIp, session2.ClientIp)
+}
+
+func TestBlockSession(t *testing.T) {
+	q := New(dbtest.New(t))
+	user := createRandomUser(t, q)
+	other := createRandomUser(t, q)
+	session := createRandomSession(t, q, user.Username)
+
+	// Another user cannot revoke the session.
+	_, err := q.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session.ID,
+		Username: other.Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	blocked, err := q.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+}
+
+func TestBlockUserSessions(t *testing.T) {
+	q := New(dbtest.New(t))
+	user := createRandomUser(t, q)
+	for i := 0; i < 3; i++ {
+		createRandomSession(t, q, user.Username)
+	}
+
+	sessions, err := q.ListUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+
+	revoked, err := q.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), revoked)
+
+	sessions, err = q.ListUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
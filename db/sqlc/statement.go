@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxStatementEntries bounds how many entries a statement lists
+const MaxStatementEntries = 1000
+
+// ErrStatementTooLarge is returned for a statement period holding more than MaxStatementEntries entries
+var ErrStatementTooLarge = fmt.Errorf("statement has more than %d entries, shorten the period", MaxStatementEntries)
+
+// GetStatementParams contains the input parameters of an account statement
+type GetStatementParams struct {
+	AccountID int64
+	// the statement covers entries posted from Since up to, but excluding, Until
+	Since time.Time
+	Until time.Time
+}
+
+// Statement lists an account's entries over a period between its balances at either end
+type Statement struct {
+	AccountID      int64     `json:"account_id"`
+	Currency       string    `json:"currency"`
+	Since          time.Time `json:"since"`
+	Until          time.Time `json:"until"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+	Entries        []Entry   `json:"entries"`
+}
+
+// GetStatement returns the account's statement for a period.
+// Only the monthly partitions the period overlaps are read, and the archived months it overlaps are read back from the archive.
+func (store *Store) GetStatement(ctx context.Context, arg GetStatementParams) (Statement, error) {
+	if !arg.Since.Before(arg.Until) {
+		return Statement{}, errors.New("statement period must end after it starts")
+	}
+
+	account, err := store.GetAccount(ctx, arg.AccountID)
+	if err != nil {
+		return Statement{}, err
+	}
+	statement := Statement{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		Since:     arg.Since,
+		Until:     arg.Until,
+	}
+
+	//the balance just before the period, or the one the account was opened with if that happened later
+	openingAt := arg.Since.Add(-time.Microsecond)
+	if account.CreatedAt.After(openingAt) {
+		openingAt = account.CreatedAt
+	}
+	opening, err := store.GetBalanceAt(ctx, arg.AccountID, openingAt)
+	if err != nil {
+		return statement, err
+	}
+	statement.OpeningBalance = opening.Balance
+
+	statement.Entries, err = store.ListAccountEntriesBetween(ctx, ListAccountEntriesBetweenParams{
+		AccountID:  arg.AccountID,
+		Since:      arg.Since,
+		Until:      arg.Until,
+		MaxEntries: MaxStatementEntries + 1,
+	})
+	if err != nil {
+		return statement, err
+	}
+	archived, err := store.archivedEntries(ctx, arg.AccountID, arg.Since, arg.Until)
+	if err != nil {
+		return statement, err
+	}
+	if len(archived) > 0 {
+		statement.Entries = append(archived, statement.Entries...)
+		sortEntries(statement.Entries)
+	}
+	if len(statement.Entries) > MaxStatementEntries {
+		return statement, ErrStatementTooLarge
+	}
+
+	statement.ClosingBalance = statement.OpeningBalance
+	for _, entry := range statement.Entries {
+		statement.ClosingBalance += entry.Amount
+	}
+	return statement, nil
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+)
+
+func TestGetStatement(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	first, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 10})
+	require.NoError(t, err)
+	second, err := store.TransferTx(context.Background(), TransferTxParams{FromAccountID: account2.ID, ToAccountID: account1.ID, Amount: 4})
+	require.NoError(t, err)
+
+	// The period starts with the second transfer, so the first one makes up the opening balance.
+	statement, err := store.GetStatement(context.Background(), GetStatementParams{
+		AccountID: account1.ID,
+		Since:     second.ToEntry.CreatedAt,
+		Until:     time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-10, statement.OpeningBalance)
+	require.Equal(t, account1.Balance-6, statement.ClosingBalance)
+	require.Len(t, statement.Entries, 1)
+	require.Equal(t, second.ToEntry.ID, statement.Entries[0].ID)
+
+	// An account opened during the period starts from its opening balance.
+	statement, err = store.GetStatement(context.Background(), GetStatementParams{
+		AccountID: account1.ID,
+		Since:     account1.CreatedAt.Add(-time.Hour),
+		Until:     time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, statement.OpeningBalance)
+	require.Equal(t, account1.Balance-6, statement.ClosingBalance)
+	require.Len(t, statement.Entries, 2)
+	require.Equal(t, first.FromEntry.ID, statement.Entries[0].ID)
+
+	_, err = store.GetStatement(context.Background(), GetStatementParams{
+		AccountID: account1.ID,
+		Since:     time.Now(),
+		Until:     time.Now().Add(-time.Hour),
+	})
+	require.Error(t, err)
+}
@@ -3,8 +3,15 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"goprojects/simplebank/risk"
 )
 
 type Store struct {
@@ -12,25 +19,103 @@ type Store struct {
 	*Queries
 	//creating a new transaction
 	db *sql.DB
+	//screens every transfer before it is committed, nil disables screening
+	screener risk.Screener
+	//records Prometheus metrics, nil disables them
+	metrics *Metrics
+	//starts a span for every transaction
+	tracer trace.Tracer
+	//wraps the DBTX of the pool and of every transaction
+	middleware []DBTXMiddleware
+	//logs transfers and retries with the attributes carried by their context
+	logger *slog.Logger
+	//reads the entries of archived months back, nil makes queries needing them fail with ErrArchiveUnavailable
+	archive ArchiveReader
+	//told about the transfers and balance changes of every transaction, nil publishes nothing
+	publisher EventPublisher
+	//set by ForTenant: the connection every query and transaction runs on, and the tenant it acts for
+	conn   *sql.Conn
+	tenant string
+}
+
+//StoreOption configures optional Store behaviour
+type StoreOption func(*Store)
+
+//WithScreener runs every TransferTx through the given risk screener
+func WithScreener(screener risk.Screener) StoreOption {
+	return func(store *Store) {
+		store.screener = screener
+	}
+}
+
+//WithLogger replaces slog.Default as the store's logger
+func WithLogger(logger *slog.Logger) StoreOption {
+	return func(store *Store) {
+		store.logger = logger
+	}
 }
 
-func NewStore(db *sql.DB) *Store {
-	return &Store{
+func NewStore(db *sql.DB, opts ...StoreOption) *Store {
+	store := &Store{
 		db: db,
-		Queries: New(db),
+		tracer: defaultTracer(),
+		logger: slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(store)
 	}
+	//the queries are built once the options have installed any middleware
+	store.Queries = New(store.wrap(db))
+	return store
 }
 
+//maxTxAttempts bounds how often execTx runs a transaction that keeps hitting serialization failures or deadlocks
+const maxTxAttempts = 3
+
 //function to execite a geeneric database transaction
+//fn may run more than once, so it must not keep state from a failed attempt
 func (store *Store) execTx(ctx context.Context, fn func(*Queries) error) error{
+	return store.execTxOptions(ctx, nil, fn)
+}
+
+// execTxOptions is execTx with the isolation level and access mode of opts, nil for the defaults
+func (store *Store) execTxOptions(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) error {
+	for attempt := 1; ; attempt++ {
+		err := store.runTx(ctx, opts, fn)
+		if err == nil || attempt == maxTxAttempts || !isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+		store.metrics.txRetried()
+		store.logger.DebugContext(ctx, "retrying transaction", "attempt", attempt, "error", err)
+		trace.SpanFromContext(ctx).AddEvent("retry transaction", trace.WithAttributes(attribute.Int("attempt", attempt)))
+	}
+}
+
+//runTx runs fn in a single database transaction
+func (store *Store) runTx(ctx context.Context, opts *sql.TxOptions, fn func(*Queries) error) (err error) {
+	ctx, span := store.tracer.Start(ctx, "db.execTx")
+	defer func() {
+		recordError(span, err)
+		span.End()
+	}()
+
 	//create a new db transaction
-	tx, err := store.db.BeginTx(ctx, nil)
+	begin := store.db.BeginTx
+	if store.conn != nil {
+		begin = store.conn.BeginTx
+	}
+	tx, err := begin(ctx, opts)
 	if err != nil {
 		return err
 	}
+	store.metrics.txBegun()
+	start := time.Now()
+	defer func() {
+		store.metrics.txFinished(start, err == nil)
+	}()
 	//call New() function with the created transaction to get back a new queries object
 	//The New() is provided by sqlc
-	q := New(tx)
+	q := New(store.wrap(tx))
 	//we have the queries that runs within the transaction so we can call the input function with that queries and get back an error
 	err = fn(q)
 	//if the error is not nil, rollback the transaction
@@ -42,11 +127,23 @@ func (store *Store) execTx(ctx context.Context, fn func(*Queries) error) error{
 	}
 	return tx.Commit()
 }
+//ExecTx runs fn in a database transaction, letting other packages such as the worker's task
+//distributor commit their own writes atomically with the queries they make through fn's Queries
+func (store *Store) ExecTx(ctx context.Context, fn func(*Queries) error) error {
+	return store.execTx(ctx, fn)
+}
+
 //the struct contains all the input parameters needed to transfer money between two accounts
 type TransferTxParams struct {
 	FromAccountID int64     `json:"from_account_id"`
 	ToAccountID   int64     `json:"to_account_id"`
 	Amount        int64     `json:"amount"`
+	//what the transfer is for, shown to both owners; at most MaxDescriptionLength characters
+	Description       string          `json:"description"`
+	//the caller's own ID of the transfer, which transfers can be looked up by; at most MaxExternalReferenceLength characters
+	ExternalReference string          `json:"external_reference"`
+	//a JSON object of up to MaxMetadataSize bytes, stored as given
+	Metadata          json.RawMessage `json:"metadata"`
 }
 
 //the struct contains the resultof the transfer transaction
@@ -56,19 +153,63 @@ type TransferTxResult struct {
 	ToAccount    Account      `json:"to_account"`
 	FromEntry    Entry        `json:"from_entry"`
 	ToEntry      Entry        `json:"to_entry"`
+	//set when screening held the transfer for review; ToEntry stays empty until it is cleared
+	Flag         *FlaggedTransfer `json:"flag,omitempty"`
 }
 
-type txKeyType string
-
-var txKey = txKeyType("txKey")
-
 
 func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
     var result TransferTxResult
 
+    ctx, span := store.tracer.Start(ctx, "TransferTx", trace.WithAttributes(
+        attribute.Int64("transfer.from_account_id", arg.FromAccountID),
+        attribute.Int64("transfer.to_account_id", arg.ToAccountID),
+    ))
+    defer span.End()
+
+    metadata, err := checkTransferDetails(arg)
+    if err != nil {
+        return result, fmt.Errorf("TransferTx - %w", err)
+    }
+    arg.Metadata = metadata
+
+    start := time.Now()
     // Start the transaction
-    err := store.execTx(ctx, func(q *Queries) error {
+    err = store.execTx(ctx, func(q *Queries) error {
         var err error
+        // execTx may retry, so every attempt starts from an empty result
+        result = TransferTxResult{}
+
+        // Lock both accounts up front so the limit checks see a stable transfer history
+        fromAccount, toAccount, err := lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+        if err != nil {
+            return fmt.Errorf("TransferTx - failed to lock accounts: %w", err)
+        }
+
+        // A store acting for a tenant cannot even see other tenants' accounts, system callers are stopped here
+        if fromAccount.TenantID != toAccount.TenantID {
+            return fmt.Errorf("TransferTx - %w", ErrCrossTenant)
+        }
+
+        if fromAccount.IsFrozen || toAccount.IsFrozen {
+            return fmt.Errorf("TransferTx - %w", ErrAccountFrozen)
+        }
+
+        now := time.Now()
+        err = checkProductRules(ctx, q, fromAccount, arg.Amount, now)
+        if err != nil {
+            return fmt.Errorf("TransferTx - %w", err)
+        }
+
+        err = checkTransferLimits(ctx, q, fromAccount, arg.Amount, now)
+        if err != nil {
+            return fmt.Errorf("TransferTx - %w", err)
+        }
+
+        decision, err := store.screen(ctx, q, fromAccount, toAccount, arg.Amount, now)
+        if err != nil {
+            return fmt.Errorf("TransferTx - %w", err)
+        }
 
         // Type conversion from TransferTxParams to CreateTransferParams
         result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams(arg))
@@ -77,25 +218,44 @@ func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (Trans
         }
 
         // Create entries for the FromAccount and ToAccount
-        result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-            AccountID: arg.FromAccountID,
-            Amount:    -arg.Amount,
-        })
+        result.FromEntry, err = q.CreateEntry(ctx, transferEntry(result.Transfer, arg.FromAccountID, -arg.Amount))
         if err != nil {
             return fmt.Errorf("TransferTx - failed to create from entry: %w", err)
         }
 
-        log.Printf("Created FromEntry: %+v", result.FromEntry)
+        store.logger.DebugContext(ctx, "created from entry", "entry_id", result.FromEntry.ID, "account_id", result.FromEntry.AccountID)
 
-        result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-            AccountID: arg.ToAccountID,
-            Amount:    arg.Amount,
-        })
+        // A transfer held for review only leaves the sender; the recipient is credited once an analyst clears it
+        if decision.Outcome == risk.OutcomeReview {
+            flag, err := q.CreateFlaggedTransfer(ctx, CreateFlaggedTransferParams{
+                TransferID:        result.Transfer.ID,
+                TransferCreatedAt: result.Transfer.CreatedAt,
+                Rule:              decision.Rule,
+                Reason:            decision.Reason,
+            })
+            if err != nil {
+                return fmt.Errorf("TransferTx - failed to flag transfer: %w", err)
+            }
+            result.Flag = &flag
+
+            result.FromAccount, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+                ID:     arg.FromAccountID,
+                Amount: -arg.Amount,
+            })
+            if err != nil {
+                return fmt.Errorf("TransferTx - failed to update account balances: %w", err)
+            }
+            result.ToAccount = toAccount
+            // The recipient only hears of the transfer once it is cleared
+            return store.publish(ctx, q, transferEvents(result.Transfer, result.FromAccount)...)
+        }
+
+        result.ToEntry, err = q.CreateEntry(ctx, transferEntry(result.Transfer, arg.ToAccountID, arg.Amount))
         if err != nil {
             return fmt.Errorf("TransferTx - failed to create to entry: %w", err)
         }
 
-        log.Printf("Created ToEntry: %+v", result.ToEntry)
+        store.logger.DebugContext(ctx, "created to entry", "entry_id", result.ToEntry.ID, "account_id", result.ToEntry.AccountID)
 
         // Update the account balances
         result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
@@ -103,13 +263,30 @@ func (store *Store) TransferTx(ctx context.Context, arg TransferTxParams) (Trans
             return fmt.Errorf("TransferTx - failed to update account balances: %w", err)
         }
 
-        return nil
+        return store.publish(ctx, q, transferEvents(result.Transfer, result.FromAccount, result.ToAccount)...)
     })
+    store.metrics.transferFinished(start, result, err)
+    recordError(span, err)
 
     if err != nil {
+        store.logger.WarnContext(ctx, "transfer failed",
+            "from_account_id", arg.FromAccountID,
+            "to_account_id", arg.ToAccountID,
+            "amount", arg.Amount,
+            "class", TransferErrorClass(err),
+            "error", err,
+        )
         return result, err
     }
 
+    store.logger.InfoContext(ctx, "transfer committed",
+        "transfer_id", result.Transfer.ID,
+        "from_account_id", arg.FromAccountID,
+        "to_account_id", arg.ToAccountID,
+        "amount", arg.Amount,
+        "held_for_review", result.Flag != nil,
+    )
+
     return result, nil
 }
 
@@ -183,3 +360,23 @@ func addMoney(
 
     return account1, account2, err
 }
+
+// lockAccounts takes row locks on both accounts, always in ascending ID order like addMoney,
+// so two transfers in opposite directions cannot deadlock each other
+func lockAccounts(ctx context.Context, q *Queries, accountID1 int64, accountID2 int64) (account1 Account, account2 Account, err error) {
+    if accountID1 < accountID2 {
+        account1, err = q.GetAccountForUpdate(ctx, accountID1)
+        if err != nil {
+            return
+        }
+        account2, err = q.GetAccountForUpdate(ctx, accountID2)
+    } else {
+        account2, err = q.GetAccountForUpdate(ctx, accountID2)
+        if err != nil {
+            return
+        }
+        account1, err = q.GetAccountForUpdate(ctx, accountID1)
+    }
+
+    return account1, account2, err
+}
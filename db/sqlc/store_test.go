@@ -6,13 +6,18 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+
+	"goprojects/simplebank/db/dbtest"
 )
 
 func TestTransferTxt(t *testing.T) {
-	store := NewStore(testDB)
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
 
 	fmt.Println(">> before: ", account1.Balance, account2.Balance )
 
@@ -25,7 +30,9 @@ func TestTransferTxt(t *testing.T) {
 	for i := 0; i < n; i++ {
 		txName := fmt.Sprintf("tx %d", i+1)
 		go func ()  {
-			ctx := context.WithValue(context.Background(), txKey, txName)
+			//each goroutine gets its own span so the concurrent transfers can be told apart in a trace
+			ctx, span := otel.Tracer("store_test").Start(context.Background(), txName)
+			defer span.End()
 
             result, err := store.TransferTx(ctx, TransferTxParams{
 				FromAccountID: account1.ID,
@@ -103,10 +110,10 @@ func TestTransferTxt(t *testing.T) {
 	}
 	//check the updated balance
 	//get the the updated account1 from the db
-	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	updatedAccount1, err := q.GetAccount(context.Background(), account1.ID)
 	require.NoError(t, err)
 
-	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	updatedAccount2, err := q.GetAccount(context.Background(), account2.ID)
 	require.NoError(t, err)
 	fmt.Println(">> after: ", updatedAccount1.Balance, updatedAccount2.Balance )
 
@@ -115,10 +122,12 @@ func TestTransferTxt(t *testing.T) {
 }
 
 func TestTransferTxtDeadlock(t *testing.T) {
-    store := NewStore(testDB)
+    conn := dbtest.New(t)
+    q := New(conn)
+    store := NewStore(conn)
 
-    account1 := createRandomAccount(t)
-    account2 := createRandomAccount(t)
+    account1 := createRandomAccount(t, q)
+    account2 := createRandomAccount(t, q)
 
     fmt.Println(">> before: ", account1.Balance, account2.Balance)
 
@@ -151,10 +160,10 @@ func TestTransferTxtDeadlock(t *testing.T) {
     }
 
     // Get the updated accounts
-    updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+    updatedAccount1, err := q.GetAccount(context.Background(), account1.ID)
     require.NoError(t, err)
 
-    updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+    updatedAccount2, err := q.GetAccount(context.Background(), account2.ID)
     require.NoError(t, err)
 
     fmt.Println(">> after: ", updatedAccount1.Balance, updatedAccount2.Balance)
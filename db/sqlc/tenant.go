@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// DefaultTenant owns everything created before tenants existed, and whatever a system process creates without choosing one
+const DefaultTenant = "default"
+
+// ErrCrossTenant is returned when money would move between accounts of two tenants
+var ErrCrossTenant = errors.New("accounts belong to different tenants")
+
+// ForTenant returns a store acting for one tenant, along with a function releasing it.
+//
+// Every query and transaction of the returned store runs on one connection whose app.tenant_id setting
+// makes the row-level security policies hide the rows of other tenants and reject writing them; rows it creates
+// belong to the tenant. The store must not be used after release, which hands the connection back to the pool.
+func (store *Store) ForTenant(ctx context.Context, tenantID string) (*Store, func(), error) {
+	if tenantID == "" {
+		return nil, nil, errors.New("tenant ID is empty")
+	}
+
+	conn, err := store.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "SELECT set_config('app.tenant_id', $1, false)", tenantID); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("cannot act for tenant %s: %w", tenantID, err)
+	}
+
+	scoped := *store
+	scoped.conn = conn
+	scoped.tenant = tenantID
+	scoped.Queries = New(store.wrap(conn))
+
+	release := func() {
+		if _, err := conn.ExecContext(context.Background(), "RESET app.tenant_id"); err != nil {
+			//a connection still acting for the tenant must never serve another request, so it is discarded
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return &scoped, release, nil
+}
+
+// Tenant returns the tenant the store acts for, or an empty string for a store seeing every tenant
+func (store *Store) Tenant() string {
+	return store.tenant
+}
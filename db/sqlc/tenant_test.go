@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	"goprojects/simplebank/util"
+)
+
+func createRandomTenant(t *testing.T, q *Queries) Tenant {
+	id := strings.ToLower(util.RandomString(8))
+	tenant, err := q.CreateTenant(context.Background(), CreateTenantParams{
+		ID:   id,
+		Name: "Bank " + id,
+		Host: sql.NullString{String: id + ".example.com", Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, id, tenant.ID)
+	return tenant
+}
+
+func TestGetTenantByHost(t *testing.T) {
+	q := New(dbtest.New(t))
+	tenant := createRandomTenant(t, q)
+
+	got, err := q.GetTenantByHost(context.Background(), tenant.Host)
+	require.NoError(t, err)
+	require.Equal(t, tenant, got)
+
+	_, err = q.GetTenantByHost(context.Background(), sql.NullString{String: "unknown.example.com", Valid: true})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestAccountsDefaultToDefaultTenant(t *testing.T) {
+	q := New(dbtest.New(t))
+	account := createRandomAccount(t, q)
+	require.Equal(t, DefaultTenant, account.TenantID)
+}
+
+func TestTransferTxCrossTenant(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	ctx := context.Background()
+	store := NewStore(conn)
+	tenant := createRandomTenant(t, q)
+
+	scoped, release, err := store.ForTenant(ctx, tenant.ID)
+	require.NoError(t, err)
+	foreign, err := scoped.CreateAccount(ctx, CreateAccountParams{Owner: util.RandomOwner(), Balance: 100, Currency: util.USD})
+	release()
+	require.NoError(t, err)
+	require.Equal(t, tenant.ID, foreign.TenantID)
+
+	local := createRandomAccount(t, q)
+	_, err = store.TransferTx(ctx, TransferTxParams{FromAccountID: local.ID, ToAccountID: foreign.ID, Amount: 10})
+	require.ErrorIs(t, err, ErrCrossTenant)
+}
+
+func TestForTenantIsolation(t *testing.T) {
+	ctx := context.Background()
+	// Superusers skip row-level security, so the store connects as an ordinary role to a database of its own.
+	store := NewStore(dbtest.NewUnprivileged(t))
+
+	tenantA := createRandomTenant(t, store.Queries)
+	tenantB := createRandomTenant(t, store.Queries)
+
+	storeA, releaseA, err := store.ForTenant(ctx, tenantA.ID)
+	require.NoError(t, err)
+	defer releaseA()
+	storeB, releaseB, err := store.ForTenant(ctx, tenantB.ID)
+	require.NoError(t, err)
+	defer releaseB()
+
+	accountA, err := storeA.CreateAccount(ctx, CreateAccountParams{Owner: util.RandomOwner(), Balance: 100, Currency: util.USD})
+	require.NoError(t, err)
+	require.Equal(t, tenantA.ID, accountA.TenantID)
+
+	_, err = storeB.GetAccount(ctx, accountA.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// A tenant cannot create rows for another one either.
+	_, err = storeB.Queries.db.ExecContext(ctx,
+		"INSERT INTO accounts (owner, balance, currency, tenant_id) VALUES ('mallory', 0, 'USD', $1)", tenantA.ID)
+	require.Error(t, err)
+
+	// The unscoped store still sees every tenant.
+	got, err := store.GetAccount(ctx, accountA.ID)
+	require.NoError(t, err)
+	require.Equal(t, accountA, got)
+}
+
+func TestForTenantIsolatesRecords(t *testing.T) {
+	ctx := context.Background()
+	// Superusers skip row-level security, so the store connects as an ordinary role to a database of its own.
+	store := NewStore(dbtest.NewUnprivileged(t))
+
+	tenantA := createRandomTenant(t, store.Queries)
+	tenantB := createRandomTenant(t, store.Queries)
+
+	storeA, releaseA, err := store.ForTenant(ctx, tenantA.ID)
+	require.NoError(t, err)
+	defer releaseA()
+	storeB, releaseB, err := store.ForTenant(ctx, tenantB.ID)
+	require.NoError(t, err)
+	defer releaseB()
+
+	user, err := storeA.CreateUser(ctx, CreateUserParams{
+		Username:       util.RandomOwner() + util.RandomString(4),
+		HashedPassword: "hashed",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+	account, err := storeA.CreateAccount(ctx, CreateAccountParams{Owner: user.Username, Balance: 100, Currency: util.USD})
+	require.NoError(t, err)
+
+	// Every record made for tenant A takes its tenant, including those written by an unscoped system process.
+	_, err = storeA.SetAccountFrozenTx(ctx, SetAccountFrozenTxParams{AccountID: account.ID, Frozen: true, Actor: "ops", Reason: "test"})
+	require.NoError(t, err)
+	_, err = store.UpdateUserRoleTx(ctx, UpdateUserRoleTxParams{Username: user.Username, Role: "banker", Actor: "bankctl"})
+	require.NoError(t, err)
+	session, err := storeA.CreateSession(ctx, CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: "token",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, tenantA.ID, session.TenantID)
+	verifyEmail, err := store.CreateVerifyEmail(ctx, CreateVerifyEmailParams{Username: user.Username, Email: user.Email, SecretCode: util.RandomString(32)})
+	require.NoError(t, err)
+	require.Equal(t, tenantA.ID, verifyEmail.TenantID)
+	limit, err := store.CreateTransferLimit(ctx, CreateTransferLimitParams{
+		Owner:           sql.NullString{String: user.Username, Valid: true},
+		Currency:        sql.NullString{String: util.USD, Valid: true},
+		MaxSingleAmount: sql.NullInt64{Int64: 10, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, tenantA.ID, limit.TenantID)
+
+	auditLogs, err := storeA.ListAuditLogs(ctx, ListAuditLogsParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, auditLogs, 2)
+	for _, auditLog := range auditLogs {
+		require.Equal(t, tenantA.ID, auditLog.TenantID)
+	}
+
+	// Tenant B sees none of them.
+	auditLogs, err = storeB.ListAuditLogs(ctx, ListAuditLogsParams{Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, auditLogs)
+	_, err = storeB.GetSession(ctx, session.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = storeB.GetOwnerTransferLimit(ctx, GetOwnerTransferLimitParams{
+		Owner:    limit.Owner,
+		Currency: limit.Currency,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = storeB.UseVerifyEmail(ctx, UseVerifyEmailParams{ID: verifyEmail.ID, SecretCode: verifyEmail.SecretCode})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// Nor can it attach records to tenant A's rows.
+	_, err = storeB.CreateSession(ctx, CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: "token",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	})
+	require.Error(t, err)
+}
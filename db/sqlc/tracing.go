@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "goprojects/simplebank/db"
+
+// WithTracerProvider creates the store's transaction spans with tp instead of the global provider
+func WithTracerProvider(tp trace.TracerProvider) StoreOption {
+	return func(store *Store) {
+		store.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// TraceQueries is a DBTXMiddleware that records a span for every query, named after its sqlc name.
+// Parameters are never recorded since they hold balances, emails and tokens.
+func TraceQueries(tp trace.TracerProvider) DBTXMiddleware {
+	tracer := tp.Tracer(tracerName)
+	return func(next DBTX) DBTX {
+		return tracingDBTX{next: next, tracer: tracer}
+	}
+}
+
+type tracingDBTX struct {
+	next   DBTX
+	tracer trace.Tracer
+}
+
+func (t tracingDBTX) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := QueryName(query)
+	return t.tracer.Start(ctx, "db."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", name),
+		),
+	)
+}
+
+func (t tracingDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	result, err := t.next.ExecContext(ctx, query, args...)
+	recordError(span, err)
+	return result, err
+}
+
+func (t tracingDBTX) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	stmt, err := t.next.PrepareContext(ctx, query)
+	recordError(span, err)
+	return stmt, err
+}
+
+// QueryContext spans the round trip that returns the first rows, not the iteration over them
+func (t tracingDBTX) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	rows, err := t.next.QueryContext(ctx, query, args...)
+	recordError(span, err)
+	return rows, err
+}
+
+// QueryRowContext records the query's error but not sql.ErrNoRows, which *sql.Row only reports on Scan
+func (t tracingDBTX) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := t.start(ctx, query)
+	defer span.End()
+
+	row := t.next.QueryRowContext(ctx, query, args...)
+	recordError(span, row.Err())
+	return row
+}
+
+func recordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// defaultTracer follows the global provider, which is a no-op until the application installs one
+func defaultTracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
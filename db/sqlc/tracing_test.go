@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"goprojects/simplebank/db/dbtest"
+)
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "GetAccount", QueryName(getAccount))
+	require.Equal(t, "ListAccounts", QueryName(listAccounts))
+	require.Equal(t, "unknown", QueryName("SELECT 1"))
+}
+
+func TestTransferTxSpans(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	store := NewStore(conn, WithTracerProvider(tp), WithDBTXMiddleware(TraceQueries(tp)))
+
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	transfer := spans["TransferTx"]
+	require.NotNil(t, transfer)
+	tx := spans["db.execTx"]
+	require.NotNil(t, tx)
+	require.Equal(t, transfer.SpanContext().SpanID(), tx.Parent().SpanID())
+
+	// Every query of the transaction is a child of the transaction's span and records no parameters.
+	for _, name := range []string{"db.GetAccountForUpdate", "db.CreateTransfer", "db.CreateEntry", "db.AddAccountBalance"} {
+		span := spans[name]
+		require.NotNil(t, span, name)
+		require.Equal(t, tx.SpanContext().SpanID(), span.Parent().SpanID(), name)
+		for _, attr := range span.Attributes() {
+			require.NotContains(t, []string{"db.statement", "db.query.text"}, string(attr.Key))
+		}
+	}
+}
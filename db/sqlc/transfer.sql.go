@@ -7,27 +7,42 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  description,
+  external_reference,
+  metadata
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 )
-RETURNING id, from_account_id, to_account_id, amount, created_at
+RETURNING id, from_account_id, to_account_id, amount, created_at, tenant_id, description, external_reference, metadata
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID     int64           `json:"from_account_id"`
+	ToAccountID       int64           `json:"to_account_id"`
+	Amount            int64           `json:"amount"`
+	Description       string          `json:"description"`
+	ExternalReference string          `json:"external_reference"`
+	Metadata          json.RawMessage `json:"metadata"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Description,
+		arg.ExternalReference,
+		arg.Metadata,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -35,6 +50,10 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TenantID,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
 	)
 	return i, err
 }
@@ -50,7 +69,7 @@ func (q *Queries) DeleteTransfer(ctx context.Context, id int64) error {
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, tenant_id, description, external_reference, metadata FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -63,12 +82,104 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TenantID,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
 	)
 	return i, err
 }
 
+const listInboundTransfersSince = `-- name: ListInboundTransfersSince :many
+SELECT id, from_account_id, to_account_id, amount, created_at, tenant_id, description, external_reference, metadata FROM transfers
+WHERE to_account_id = $1 AND created_at >= $2
+ORDER BY created_at
+`
+
+type ListInboundTransfersSinceParams struct {
+	ToAccountID int64     `json:"to_account_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListInboundTransfersSince(ctx context.Context, arg ListInboundTransfersSinceParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listInboundTransfersSince, arg.ToAccountID, arg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transfer
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TenantID,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOutboundTransfersSince = `-- name: ListOutboundTransfersSince :many
+SELECT id, from_account_id, to_account_id, amount, created_at, tenant_id, description, external_reference, metadata FROM transfers
+WHERE from_account_id = $1 AND created_at >= $2
+ORDER BY created_at
+`
+
+type ListOutboundTransfersSinceParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (q *Queries) ListOutboundTransfersSince(ctx context.Context, arg ListOutboundTransfersSinceParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listOutboundTransfersSince, arg.FromAccountID, arg.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transfer
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TenantID,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, tenant_id, description, external_reference, metadata FROM transfers
 ORDER BY amount
 LIMIT $1
 OFFSET $2
@@ -94,6 +205,61 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TenantID,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTransfersByReference = `-- name: ListTransfersByReference :many
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.tenant_id, t.description, t.external_reference, t.metadata FROM transfers t
+WHERE t.external_reference = $1
+  AND ($2::varchar = '' OR EXISTS (
+    SELECT 1 FROM accounts a
+    WHERE a.id IN (t.from_account_id, t.to_account_id) AND a.owner = $2
+  ))
+ORDER BY t.created_at, t.id
+LIMIT $3
+`
+
+type ListTransfersByReferenceParams struct {
+	ExternalReference string `json:"external_reference"`
+	Owner             string `json:"owner"`
+	MaxTransfers      int32  `json:"max_transfers"`
+}
+
+// an empty owner lists every transfer with the reference, any other only those from or to the owner's accounts
+func (q *Queries) ListTransfersByReference(ctx context.Context, arg ListTransfersByReferenceParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listTransfersByReference, arg.ExternalReference, arg.Owner, arg.MaxTransfers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Transfer
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TenantID,
+			&i.Description,
+			&i.ExternalReference,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -112,7 +278,7 @@ const updateTransfer = `-- name: UpdateTransfer :one
 UPDATE transfers
   set amount = $2
 WHERE id = $1
-RETURNING id, from_account_id, to_account_id, amount, created_at
+RETURNING id, from_account_id, to_account_id, amount, created_at, tenant_id, description, external_reference, metadata
 `
 
 type UpdateTransferParams struct {
@@ -129,6 +295,10 @@ func (q *Queries) UpdateTransfer(ctx context.Context, arg UpdateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TenantID,
+		&i.Description,
+		&i.ExternalReference,
+		&i.Metadata,
 	)
 	return i, err
 }
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// limits on the details a caller attaches to a transfer
+const (
+	//in characters, like the check constraint of the column
+	MaxDescriptionLength = 140
+	//in characters, like the check constraint of the column
+	MaxExternalReferenceLength = 64
+	//in bytes of compact JSON
+	MaxMetadataSize = 4096
+)
+
+// ErrInvalidDetails is returned when the description, external reference or metadata of a transfer break their limits
+var ErrInvalidDetails = errors.New("invalid transfer details")
+
+// noMetadata is stored for transfers and entries without metadata; the column holds a JSON object and is never NULL
+var noMetadata = json.RawMessage("{}")
+
+// checkTransferDetails checks the details of arg against their limits
+// and returns its metadata as compact JSON, an empty object when there is none
+func checkTransferDetails(arg TransferTxParams) (json.RawMessage, error) {
+	if n := utf8.RuneCountInString(arg.Description); n > MaxDescriptionLength {
+		return nil, fmt.Errorf("%w: description has %d characters, at most %d are allowed", ErrInvalidDetails, n, MaxDescriptionLength)
+	}
+	if n := utf8.RuneCountInString(arg.ExternalReference); n > MaxExternalReferenceLength {
+		return nil, fmt.Errorf("%w: external reference has %d characters, at most %d are allowed", ErrInvalidDetails, n, MaxExternalReferenceLength)
+	}
+
+	if len(bytes.TrimSpace(arg.Metadata)) == 0 {
+		return noMetadata, nil
+	}
+	var metadata bytes.Buffer
+	if err := json.Compact(&metadata, arg.Metadata); err != nil {
+		return nil, fmt.Errorf("%w: metadata is not valid JSON: %v", ErrInvalidDetails, err)
+	}
+	if metadata.Bytes()[0] != '{' {
+		return nil, fmt.Errorf("%w: metadata must be a JSON object", ErrInvalidDetails)
+	}
+	if metadata.Len() > MaxMetadataSize {
+		return nil, fmt.Errorf("%w: metadata has %d bytes, at most %d are allowed", ErrInvalidDetails, metadata.Len(), MaxMetadataSize)
+	}
+	return metadata.Bytes(), nil
+}
+
+// transferEntry returns the parameters of an entry moving amount for the account as part of transfer,
+// which carries the transfer's details onto the account's statement
+func transferEntry(transfer Transfer, accountID int64, amount int64) CreateEntryParams {
+	return CreateEntryParams{
+		AccountID:         accountID,
+		Amount:            amount,
+		Description:       transfer.Description,
+		ExternalReference: transfer.ExternalReference,
+		Metadata:          transfer.Metadata,
+	}
+}
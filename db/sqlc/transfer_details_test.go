@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	"goprojects/simplebank/util"
+)
+
+func TestCheckTransferDetails(t *testing.T) {
+	testCases := []struct {
+		name     string
+		arg      TransferTxParams
+		metadata string
+		err      bool
+	}{
+		{"NoDetails", TransferTxParams{}, `{}`, false},
+		{"Compacted", TransferTxParams{Metadata: json.RawMessage("{ \"order\" : [1, 2] }\n")}, `{"order":[1,2]}`, false},
+		{"LongestDescription", TransferTxParams{Description: strings.Repeat("é", MaxDescriptionLength)}, `{}`, false},
+		{"DescriptionTooLong", TransferTxParams{Description: strings.Repeat("a", MaxDescriptionLength+1)}, "", true},
+		{"ReferenceTooLong", TransferTxParams{ExternalReference: strings.Repeat("a", MaxExternalReferenceLength+1)}, "", true},
+		{"InvalidJSON", TransferTxParams{Metadata: json.RawMessage(`{"order":`)}, "", true},
+		{"NotAnObject", TransferTxParams{Metadata: json.RawMessage(`[1, 2]`)}, "", true},
+		{"MetadataTooLarge", TransferTxParams{Metadata: json.RawMessage(`{"a":"` + strings.Repeat("a", MaxMetadataSize) + `"}`)}, "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata, err := checkTransferDetails(tc.arg)
+			if tc.err {
+				require.ErrorIs(t, err, ErrInvalidDetails)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.metadata, string(metadata))
+		})
+	}
+}
+
+func TestTransferTxDetails(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+	reference := "order-" + util.RandomString(10)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID:     account1.ID,
+		ToAccountID:       account2.ID,
+		Amount:            10,
+		Description:       "Dinner",
+		ExternalReference: reference,
+		Metadata:          json.RawMessage(`{"split": 3}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Dinner", result.Transfer.Description)
+	require.Equal(t, reference, result.Transfer.ExternalReference)
+	require.JSONEq(t, `{"split": 3}`, string(result.Transfer.Metadata))
+
+	// Both entries carry the details onto their account's statement.
+	for _, entry := range []Entry{result.FromEntry, result.ToEntry} {
+		require.Equal(t, "Dinner", entry.Description)
+		require.Equal(t, reference, entry.ExternalReference)
+		require.JSONEq(t, `{"split": 3}`, string(entry.Metadata))
+	}
+
+	reversal, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: result.Transfer.ID,
+		Actor:      "ops",
+		Reason:     "split again",
+	})
+	require.NoError(t, err)
+	require.Equal(t, reference, reversal.Transfer.ExternalReference)
+
+	// Looking the reference up finds the transfer and its reversal, but only for the owners of their accounts.
+	transfers, err := store.ListTransfersByReference(context.Background(), ListTransfersByReferenceParams{
+		ExternalReference: reference,
+		MaxTransfers:      10,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.Equal(t, result.Transfer.ID, transfers[0].ID)
+	require.Equal(t, reversal.Transfer.ID, transfers[1].ID)
+
+	transfers, err = store.ListTransfersByReference(context.Background(), ListTransfersByReferenceParams{
+		ExternalReference: reference,
+		Owner:             account2.Owner,
+		MaxTransfers:      10,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+
+	transfers, err = store.ListTransfersByReference(context.Background(), ListTransfersByReferenceParams{
+		ExternalReference: reference,
+		Owner:             util.RandomOwner() + util.RandomString(4),
+		MaxTransfers:      10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Metadata:      json.RawMessage(`"not an object"`),
+	})
+	require.ErrorIs(t, err, ErrInvalidDetails)
+}
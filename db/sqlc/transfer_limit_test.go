@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+)
+
+func createAccountLimit(t *testing.T, q *Queries, accountID int64, arg CreateTransferLimitParams) TransferLimit {
+	arg.AccountID = sql.NullInt64{Int64: accountID, Valid: true}
+
+	limit, err := q.CreateTransferLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, limit.ID)
+	require.Equal(t, arg.AccountID, limit.AccountID)
+	require.False(t, limit.Owner.Valid)
+
+	return limit
+}
+
+func TestCreateTransferLimitRequiresScope(t *testing.T) {
+	q := New(dbtest.New(t))
+	// A limit without an account or an owner violates the scope check.
+	_, err := q.CreateTransferLimit(context.Background(), CreateTransferLimitParams{
+		MaxSingleAmount: sql.NullInt64{Int64: 10, Valid: true},
+	})
+	require.Error(t, err)
+}
+
+func TestTransferTxMaxSingleAmount(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	createAccountLimit(t, q, account1.ID, CreateTransferLimitParams{
+		MaxSingleAmount: sql.NullInt64{Int64: 10, Valid: true},
+	})
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        11,
+	})
+	require.ErrorIs(t, err, ErrLimitExceeded)
+
+	var limitErr *LimitExceededError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitScopeAccount, limitErr.Scope)
+	require.Equal(t, LimitMaxSingleAmount, limitErr.Limit)
+
+	// The rejected transfer must not have moved any money.
+	updatedAccount1, err := q.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestTransferTxDailyAndHourlyLimits(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	createAccountLimit(t, q, account1.ID, CreateTransferLimitParams{
+		DailyOutboundAmount: sql.NullInt64{Int64: 25, Valid: true},
+		HourlyTransferCount: sql.NullInt64{Int64: 3, Valid: true},
+	})
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	}
+
+	// Two transfers of 10 fit within the daily limit of 25, the third does not.
+	for i := 0; i < 2; i++ {
+		_, err := store.TransferTx(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	_, err := store.TransferTx(context.Background(), arg)
+	var limitErr *LimitExceededError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitDailyOutboundAmount, limitErr.Limit)
+	require.Equal(t, int64(30), limitErr.Attempted)
+
+	// A small third transfer passes, after which the hourly count is used up.
+	arg.Amount = 5
+	_, err = store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	arg.Amount = 1
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitHourlyTransferCount, limitErr.Limit)
+}
+
+func TestTransferTxOwnerLimit(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
+
+	_, err := q.CreateTransferLimit(context.Background(), CreateTransferLimitParams{
+		Owner:                 sql.NullString{String: account1.Owner, Valid: true},
+		Currency:              sql.NullString{String: account1.Currency, Valid: true},
+		MonthlyOutboundAmount: sql.NullInt64{Int64: 15, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        16,
+	})
+	var limitErr *LimitExceededError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, LimitScopeOwner, limitErr.Scope)
+	require.Equal(t, LimitMonthlyOutboundAmount, limitErr.Limit)
+}
@@ -0,0 +1,310 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	"goprojects/simplebank/util"
+)
+
+var (
+	propertySeed    = flag.Int64("property.seed", 0, "seed of the first TransferTx property run, 0 picks one from the clock")
+	propertyRuns    = flag.Int("property.runs", 5, "number of TransferTx property runs, each on a fresh database")
+	propertyOps     = flag.Int("property.ops", 300, "operations per TransferTx property run")
+	propertyWorkers = flag.Int("property.workers", 8, "goroutines issuing operations in each TransferTx property run")
+)
+
+// TestTransferTxProperties runs random interleavings of account creations, transfers and reversals
+// against the store and a reference model, then checks the ledger's invariants.
+// A failing run is reproduced with -property.seed=<seed> -property.runs=1.
+func TestTransferTxProperties(t *testing.T) {
+	if testing.Short() {
+		t.Skip("property runs are slow")
+	}
+
+	seed := *propertySeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	for i := 0; i < *propertyRuns; i++ {
+		runSeed := seed + int64(i)
+		t.Run(fmt.Sprintf("seed=%d", runSeed), func(t *testing.T) {
+			runTransferProperties(t, runSeed, *propertyOps, *propertyWorkers)
+		})
+	}
+}
+
+func runTransferProperties(t *testing.T, seed int64, ops int, workers int) {
+	conn := dbtest.New(t)
+	store := NewStore(conn)
+	model := newLedgerModel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	//every worker draws its operations from its own generator, the scheduler decides the interleaving
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+			for i := w; i < ops; i += workers {
+				if err := model.step(ctx, store, rng); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(rand.New(rand.NewSource(seed + int64(w))))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if t.Failed() {
+		return
+	}
+
+	model.check(t, ctx, conn, store)
+}
+
+// ledgerModel is the in-memory reference the store is compared against.
+// Funds are reserved from available when an operation is issued and only credited once it commits,
+// so operations running concurrently can never overdraw an account between them.
+type ledgerModel struct {
+	mu       sync.Mutex
+	accounts map[int64]*modelAccount
+	//account IDs per currency, transfers only move money within one currency
+	byCurrency map[string][]int64
+	settled    []Transfer
+	reversed   []Transfer
+	transfers  int
+}
+
+type modelAccount struct {
+	currency  string
+	opening   int64
+	balance   int64
+	available int64
+}
+
+func newLedgerModel() *ledgerModel {
+	return &ledgerModel{
+		accounts:   make(map[int64]*modelAccount),
+		byCurrency: make(map[string][]int64),
+	}
+}
+
+// step issues one random operation and applies its outcome to the model
+func (m *ledgerModel) step(ctx context.Context, store *Store, rng *rand.Rand) error {
+	switch p := rng.Intn(100); {
+	case p < 10 || m.size() < 4:
+		return m.createAccount(ctx, store, rng)
+	case p < 22:
+		return m.reverse(ctx, store, rng)
+	case p < 25:
+		return m.reverseAgain(ctx, store, rng)
+	default:
+		return m.transfer(ctx, store, rng)
+	}
+}
+
+func (m *ledgerModel) size() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.accounts)
+}
+
+func (m *ledgerModel) createAccount(ctx context.Context, store *Store, rng *rand.Rand) error {
+	currencies := []string{util.USD, util.EUR, util.CAD}
+	account, err := store.CreateAccount(ctx, CreateAccountParams{
+		Owner:    util.RandomOwner(),
+		Balance:  rng.Int63n(1000),
+		Currency: currencies[rng.Intn(len(currencies))],
+	})
+	if err != nil {
+		return fmt.Errorf("create account: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.accounts[account.ID] = &modelAccount{
+		currency:  account.Currency,
+		opening:   account.Balance,
+		balance:   account.Balance,
+		available: account.Balance,
+	}
+	m.byCurrency[account.Currency] = append(m.byCurrency[account.Currency], account.ID)
+	return nil
+}
+
+func (m *ledgerModel) transfer(ctx context.Context, store *Store, rng *rand.Rand) error {
+	m.mu.Lock()
+	from, to, ok := m.pickPair(rng)
+	if !ok {
+		m.mu.Unlock()
+		return nil
+	}
+	amount := 1 + rng.Int63n(m.accounts[from].available)
+	m.accounts[from].available -= amount
+	m.mu.Unlock()
+
+	result, err := store.TransferTx(ctx, TransferTxParams{FromAccountID: from, ToAccountID: to, Amount: amount})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.accounts[from].available += amount
+		return fmt.Errorf("transfer %d from %d to %d: %w", amount, from, to, err)
+	}
+	m.accounts[from].balance -= amount
+	m.accounts[to].balance += amount
+	m.accounts[to].available += amount
+	m.settled = append(m.settled, result.Transfer)
+	m.transfers++
+
+	if result.FromEntry.Amount != -amount || result.ToEntry.Amount != amount {
+		return fmt.Errorf("transfer %d has entries %d and %d", result.Transfer.ID, result.FromEntry.Amount, result.ToEntry.Amount)
+	}
+	return nil
+}
+
+// pickPair picks two accounts of one currency whose sender has funds available
+func (m *ledgerModel) pickPair(rng *rand.Rand) (from int64, to int64, ok bool) {
+	currencies := make([]string, 0, len(m.byCurrency))
+	for currency, ids := range m.byCurrency {
+		if len(ids) >= 2 {
+			currencies = append(currencies, currency)
+		}
+	}
+	if len(currencies) == 0 {
+		return 0, 0, false
+	}
+	//sorted so a seed picks the same currency whatever the map's order
+	sort.Strings(currencies)
+	ids := m.byCurrency[currencies[rng.Intn(len(currencies))]]
+
+	for attempt := 0; attempt < 5; attempt++ {
+		from = ids[rng.Intn(len(ids))]
+		to = ids[rng.Intn(len(ids))]
+		if from != to && m.accounts[from].available > 0 {
+			return from, to, true
+		}
+	}
+	return 0, 0, false
+}
+
+func (m *ledgerModel) reverse(ctx context.Context, store *Store, rng *rand.Rand) error {
+	m.mu.Lock()
+	if len(m.settled) == 0 {
+		m.mu.Unlock()
+		return nil
+	}
+	i := rng.Intn(len(m.settled))
+	original := m.settled[i]
+	//the recipient may have spent the money since, then the reversal would overdraw it
+	recipient := m.accounts[original.ToAccountID]
+	if recipient.available < original.Amount {
+		m.mu.Unlock()
+		return nil
+	}
+	m.settled = append(m.settled[:i], m.settled[i+1:]...)
+	recipient.available -= original.Amount
+	m.mu.Unlock()
+
+	result, err := store.ReverseTransferTx(ctx, ReverseTransferTxParams{
+		TransferID: original.ID,
+		Actor:      "property",
+		Reason:     "property test",
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		recipient.available += original.Amount
+		return fmt.Errorf("reverse transfer %d: %w", original.ID, err)
+	}
+	sender := m.accounts[original.FromAccountID]
+	recipient.balance -= original.Amount
+	sender.balance += original.Amount
+	sender.available += original.Amount
+	m.reversed = append(m.reversed, original)
+	m.transfers++
+
+	if result.Transfer.FromAccountID != original.ToAccountID || result.Transfer.ToAccountID != original.FromAccountID {
+		return fmt.Errorf("reversal %d of transfer %d does not swap its accounts", result.Transfer.ID, original.ID)
+	}
+	return nil
+}
+
+// reverseAgain checks that a transfer can only be reversed once
+func (m *ledgerModel) reverseAgain(ctx context.Context, store *Store, rng *rand.Rand) error {
+	m.mu.Lock()
+	if len(m.reversed) == 0 {
+		m.mu.Unlock()
+		return nil
+	}
+	original := m.reversed[rng.Intn(len(m.reversed))]
+	m.mu.Unlock()
+
+	_, err := store.ReverseTransferTx(ctx, ReverseTransferTxParams{
+		TransferID: original.ID,
+		Actor:      "property",
+		Reason:     "property test",
+	})
+	if !errors.Is(err, ErrAlreadyReversed) {
+		return fmt.Errorf("second reversal of transfer %d: want ErrAlreadyReversed, got %v", original.ID, err)
+	}
+	return nil
+}
+
+// check compares the database with the model once every operation has finished
+func (m *ledgerModel) check(t *testing.T, ctx context.Context, conn *sql.DB, store *Store) {
+	opening := make(map[string]int64)
+	for id, want := range m.accounts {
+		account, err := store.GetAccount(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, want.balance, account.Balance, "balance of account %d", id)
+		require.GreaterOrEqual(t, account.Balance, int64(0), "balance of account %d", id)
+		opening[want.currency] += want.opening
+	}
+
+	//money is only ever moved, so every currency still holds exactly what the accounts were opened with
+	totals, err := store.ListCurrencyTotals(ctx)
+	require.NoError(t, err)
+	require.Len(t, totals, len(opening))
+	for _, total := range totals {
+		require.Equal(t, opening[total.Currency], total.TotalBalance, "total %s balance", total.Currency)
+	}
+
+	//a balance differs from the sum of its entries by exactly its opening balance
+	mismatches, err := store.ListBalanceMismatches(ctx)
+	require.NoError(t, err)
+	diffs := make(map[int64]int64)
+	for _, row := range mismatches {
+		diffs[row.ID] = row.Balance - row.EntriesTotal
+	}
+	for id, want := range m.accounts {
+		require.Equal(t, want.opening, diffs[id], "balance minus entries of account %d", id)
+	}
+
+	//every transfer and reversal posted exactly two entries that cancel out
+	var transfers, entries, sum int64
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT count(*) FROM transfers").Scan(&transfers))
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT count(*), COALESCE(sum(amount), 0) FROM entries").Scan(&entries, &sum))
+	require.EqualValues(t, m.transfers, transfers)
+	require.Equal(t, 2*transfers, entries)
+	require.Zero(t, sum)
+}
@@ -3,6 +3,8 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"goprojects/simplebank/db/dbtest"
 	"goprojects/simplebank/util"
 	"testing"
 	"time"
@@ -10,22 +12,28 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func createRandomTransfer(t *testing.T) Transfer{
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+func createRandomTransfer(t *testing.T, q *Queries) Transfer{
+	account1 := createRandomAccount(t, q)
+	account2 := createRandomAccount(t, q)
 	arg := CreateTransferParams {
 		FromAccountID: account1.ID,
 		ToAccountID: account2.ID,
 		Amount: util.RandomMoney(),		
+		Description: util.RandomString(12),
+		ExternalReference: util.RandomString(8),
+		Metadata: json.RawMessage(`{"order_id":42}`),
 	}
 
-	transfer, err := testQueries.CreateTransfer(context.Background(), arg)
+	transfer, err := q.CreateTransfer(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, transfer)
 
 	require.Equal(t, arg.FromAccountID, transfer.FromAccountID)
 	require.Equal(t, arg.ToAccountID, transfer.ToAccountID)
 	require.Equal(t, arg.Amount, transfer.Amount)
+	require.Equal(t, arg.Description, transfer.Description)
+	require.Equal(t, arg.ExternalReference, transfer.ExternalReference)
+	require.JSONEq(t, string(arg.Metadata), string(transfer.Metadata))
 
 	require.NotZero(t, transfer.ID)
 	require.NotZero(t, transfer.CreatedAt)
@@ -34,12 +42,14 @@ func createRandomTransfer(t *testing.T) Transfer{
 }
 
 func TestCreateTransfer(t *testing.T) {
-	createRandomTransfer(t)
+	q := New(dbtest.New(t))
+	createRandomTransfer(t, q)
 }
 
 func TestGetTransfer(t *testing.T) {
-	transfer1 := createRandomTransfer(t)
-	transfer2, err := testQueries.GetTransfer(context.Background(), transfer1.ID)
+	q := New(dbtest.New(t))
+	transfer1 := createRandomTransfer(t, q)
+	transfer2, err := q.GetTransfer(context.Background(), transfer1.ID)
 	require.NoError(t, err)
 	require.NotEmpty(t, transfer2)
 
@@ -51,14 +61,15 @@ func TestGetTransfer(t *testing.T) {
 }
 
 func TestUpdateTransfer(t *testing.T) {
-	transfer1 := createRandomTransfer(t)
+	q := New(dbtest.New(t))
+	transfer1 := createRandomTransfer(t, q)
 
 	arg := UpdateTransferParams{
 		ID: transfer1.ID,
 		Amount: util.RandomMoney(),
 	}
 
-	transfer2, err := testQueries.UpdateTransfer(context.Background(), arg)
+	transfer2, err := q.UpdateTransfer(context.Background(), arg)
 	require.NoError(t, err)
 	require.NotEmpty(t, transfer2)
 
@@ -70,18 +81,20 @@ func TestUpdateTransfer(t *testing.T) {
 }
 
 func TestDeleteTransfer(t *testing.T) {
-	transfer1 := createRandomTransfer(t)
-	err := testQueries.DeleteTransfer(context.Background(), transfer1.ID)
+	q := New(dbtest.New(t))
+	transfer1 := createRandomTransfer(t, q)
+	err := q.DeleteTransfer(context.Background(), transfer1.ID)
 	require.NoError(t, err)
-	transfer2, err := testQueries.GetTransfer(context.Background(), transfer1.ID)
+	transfer2, err := q.GetTransfer(context.Background(), transfer1.ID)
 	require.Error(t, err)
 	require.EqualError(t, err, sql.ErrNoRows.Error())
 	require.Empty(t, transfer2)
 }
 
 func TestListTransfers(t *testing.T) {
+	q := New(dbtest.New(t))
 	for i := 0; i < 10; i++ {
-		createRandomTransfer(t)
+		createRandomTransfer(t, q)
 	} 
 
 	arg := ListTransfersParams{
@@ -89,7 +102,7 @@ func TestListTransfers(t *testing.T) {
 		Offset: 5,
 	}
 
-	transfers, err := testQueries.ListTransfers(context.Background(), arg)
+	transfers, err := q.ListTransfers(context.Background(), arg)
 	require.NoError(t, err)
 	require.Len(t, transfers, 5)
 
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrInvalidVerifyCode is returned when an email verification code is wrong, used or expired
+var ErrInvalidVerifyCode = errors.New("invalid or expired verification code")
+
+// ErrEmailAlreadyVerified is returned when a verification email is requested for a verified address
+var ErrEmailAlreadyVerified = errors.New("email is already verified")
+
+// CreateUserTxParams contains the input parameters of the create user transaction
+type CreateUserTxParams struct {
+	CreateUserParams
+	//AfterCreate runs inside the transaction, so the user is only created if it succeeds.
+	//It is used to enqueue the verification email atomically with the new user.
+	AfterCreate func(q *Queries, user User) error
+}
+
+// CreateUserTx creates a user and runs the AfterCreate hook in the same transaction
+func (store *Store) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.AfterCreate == nil {
+			return nil
+		}
+		return arg.AfterCreate(q, user)
+	})
+
+	return user, err
+}
+
+// VerifyEmailTxParams contains the link parameters of a verification email
+type VerifyEmailTxParams struct {
+	EmailID    int64  `json:"email_id"`
+	SecretCode string `json:"secret_code"`
+}
+
+// VerifyEmailTxResult is the result of a successful email verification
+type VerifyEmailTxResult struct {
+	User        User        `json:"user"`
+	VerifyEmail VerifyEmail `json:"verify_email"`
+}
+
+// VerifyEmailTx uses up a verification code and marks the user's email as verified
+func (store *Store) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
+	var result VerifyEmailTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.VerifyEmail, err = q.UseVerifyEmail(ctx, UseVerifyEmailParams{
+			ID:         arg.EmailID,
+			SecretCode: arg.SecretCode,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidVerifyCode
+		}
+		if err != nil {
+			return err
+		}
+
+		//the email must still be the user's address, so a code sent to an old address verifies nothing
+		result.User, err = q.SetUserEmailVerified(ctx, SetUserEmailVerifiedParams{
+			Username: result.VerifyEmail.Username,
+			Email:    result.VerifyEmail.Email,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidVerifyCode
+		}
+		return err
+	})
+
+	return result, err
+}
+
+// ResendVerifyEmailTxParams contains the input parameters of the resend verification email transaction
+type ResendVerifyEmailTxParams struct {
+	Username string `json:"username"`
+	//Resend runs inside the transaction once the old codes are expired, with the user locked,
+	//so concurrent requests for the same user run it one after the other.
+	//It enqueues the new email; an error rolls back the expiry as well.
+	Resend func(q *Queries, user User) error
+}
+
+// ResendVerifyEmailTx expires the user's unused verification codes and runs the Resend hook in the same transaction
+func (store *Store) ResendVerifyEmailTx(ctx context.Context, arg ResendVerifyEmailTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		user, err = q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+		if user.IsEmailVerified {
+			return ErrEmailAlreadyVerified
+		}
+
+		if _, err := q.ExpireVerifyEmails(ctx, user.Username); err != nil {
+			return err
+		}
+		return arg.Resend(q, user)
+	})
+
+	return user, err
+}
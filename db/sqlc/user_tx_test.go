@@ -0,0 +1,77 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"goprojects/simplebank/db/dbtest"
+	"goprojects/simplebank/util"
+)
+
+func TestCreateUserTxRollsBack(t *testing.T) {
+	conn := dbtest.New(t)
+	store := NewStore(conn)
+
+	arg := CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "hashed",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+
+	// A failing hook leaves no user behind.
+	_, err := store.CreateUserTx(context.Background(), CreateUserTxParams{
+		CreateUserParams: arg,
+		AfterCreate: func(q *Queries, user User) error {
+			return errors.New("enqueue failed")
+		},
+	})
+	require.Error(t, err)
+	_, err = store.GetUser(context.Background(), arg.Username)
+	require.Error(t, err)
+
+	user, err := store.CreateUserTx(context.Background(), CreateUserTxParams{CreateUserParams: arg})
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, user.Username)
+	require.False(t, user.IsEmailVerified)
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	conn := dbtest.New(t)
+	q := New(conn)
+	store := NewStore(conn)
+	user := createRandomUser(t, q)
+
+	verifyEmail, err := store.CreateVerifyEmail(context.Background(), CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      user.Email,
+		SecretCode: util.RandomString(32),
+	})
+	require.NoError(t, err)
+	require.False(t, verifyEmail.IsUsed)
+	require.True(t, verifyEmail.ExpiredAt.After(verifyEmail.CreatedAt))
+
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: "wrong",
+	})
+	require.ErrorIs(t, err, ErrInvalidVerifyCode)
+
+	result, err := store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.NoError(t, err)
+	require.True(t, result.User.IsEmailVerified)
+	require.True(t, result.VerifyEmail.IsUsed)
+
+	// A code works only once.
+	_, err = store.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailID:    verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.ErrorIs(t, err, ErrInvalidVerifyCode)
+}
@@ -0,0 +1,117 @@
+// Package logging builds the service's slog loggers and carries per-request attributes in contexts.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// output formats accepted by New
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Redacted replaces the value of every sensitive attribute
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values never reach the log, at any group depth
+var sensitiveKeys = map[string]bool{
+	"password":        true,
+	"hashed_password": true,
+	"access_token":    true,
+	"refresh_token":   true,
+	"token":           true,
+	"authorization":   true,
+	"secret_code":     true,
+	"smtp_password":   true,
+}
+
+// New creates a logger writing to w in the given format at the given level (debug, info, warn or error).
+// Records are enriched with the attributes stored in their context by With, and sensitive fields are redacted.
+func New(w io.Writer, format string, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       lvl,
+		ReplaceAttr: redact,
+	}
+
+	var handler slog.Handler
+	switch format {
+	case FormatText, "":
+		handler = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
+
+// Discard returns a logger that drops every record
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError + 1}))
+}
+
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, Redacted)
+	}
+	if attr.Value.Kind() == slog.KindDuration {
+		//durations read better as "1.5s" than as nanoseconds in JSON
+		return slog.String(attr.Key, attr.Value.Duration().Round(time.Microsecond).String())
+	}
+	return attr
+}
+
+type contextKey struct{}
+
+// With returns a copy of ctx whose log records carry the given key-value pairs, such as the request ID or user
+func With(ctx context.Context, args ...any) context.Context {
+	record := slog.NewRecord(time.Time{}, 0, "", 0)
+	record.Add(args...)
+
+	attrs := append([]slog.Attr(nil), attrsFromContext(ctx)...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler adds the attributes stored by With, and the trace ID of the current span, to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	record.AddAttrs(attrsFromContext(ctx)...)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(&out, FormatJSON, "info")
+	require.NoError(t, err)
+
+	ctx := With(context.Background(), "request_id", "req-1")
+	ctx = With(ctx, "user", "alice")
+
+	logger.DebugContext(ctx, "not logged at info level")
+	logger.InfoContext(ctx, "login",
+		"password", "hunter2",
+		"took", 1500*time.Millisecond,
+		"session", map[string]string{"id": "s1"},
+	)
+	logger.WithGroup("user").InfoContext(ctx, "renew", "refresh_token", "abc")
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &record))
+	require.Equal(t, "login", record["msg"])
+	require.Equal(t, "req-1", record["request_id"])
+	require.Equal(t, "alice", record["user"])
+	require.Equal(t, Redacted, record["password"])
+	require.Equal(t, "1.5s", record["took"])
+
+	// Sensitive keys are redacted inside groups too.
+	require.NotContains(t, string(lines[1]), "abc")
+	require.Contains(t, string(lines[1]), Redacted)
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, "xml", "info")
+	require.Error(t, err)
+
+	_, err = New(&bytes.Buffer{}, FormatText, "verbose")
+	require.Error(t, err)
+}
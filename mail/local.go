@@ -0,0 +1,67 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MemorySender keeps sent emails in memory, for tests
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemorySender creates an empty MemorySender
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (sender *MemorySender) SendEmail(_ context.Context, msg Message) error {
+	if _, err := buildMessage(mail.Address{Address: "test@localhost"}, msg); err != nil {
+		return err
+	}
+
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	sender.messages = append(sender.messages, msg)
+	return nil
+}
+
+// Messages returns the emails sent so far
+func (sender *MemorySender) Messages() []Message {
+	sender.mu.Lock()
+	defer sender.mu.Unlock()
+	return append([]Message(nil), sender.messages...)
+}
+
+// FileSender writes every email as an .eml file into a directory, for local development without an SMTP server
+type FileSender struct {
+	from mail.Address
+	dir  string
+}
+
+// NewFileSender creates a FileSender writing into dir
+func NewFileSender(fromName string, fromAddress string, dir string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create mail directory: %w", err)
+	}
+	return &FileSender{
+		from: mail.Address{Name: fromName, Address: fromAddress},
+		dir:  dir,
+	}, nil
+}
+
+func (sender *FileSender) SendEmail(_ context.Context, msg Message) error {
+	data, err := buildMessage(sender.from, msg)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d.eml", time.Now().UnixNano())
+	return os.WriteFile(filepath.Join(sender.dir, name), data, 0o644)
+}
@@ -0,0 +1,57 @@
+// Package mail sends emails to users.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Message is an HTML email
+type Message struct {
+	To      []string
+	Subject string
+	//HTML body of the email
+	Body string
+}
+
+// Sender delivers emails
+type Sender interface {
+	SendEmail(ctx context.Context, msg Message) error
+}
+
+// buildMessage renders msg as an RFC 5322 message from the given sender
+func buildMessage(from mail.Address, msg Message) ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, fmt.Errorf("email has no recipients")
+	}
+	for _, to := range msg.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", to, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/html; charset=\"utf-8\"\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	writer := quotedprintable.NewWriter(&buf)
+	if _, err := writer.Write([]byte(msg.Body)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,58 @@
+package mail
+
+import (
+	"context"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMessage(t *testing.T) {
+	data, err := buildMessage(mail.Address{Name: "Simple Bank", Address: "noreply@simplebank.com"}, Message{
+		To:      []string{"alice@email.com", "bob@email.com"},
+		Subject: "Welcome to Simple Bank",
+		Body:    "<h1>Hello</h1>",
+	})
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	require.Equal(t, `"Simple Bank" <noreply@simplebank.com>`, msg.Header.Get("From"))
+	require.Equal(t, "alice@email.com, bob@email.com", msg.Header.Get("To"))
+	require.Contains(t, msg.Header.Get("Content-Type"), "text/html")
+
+	_, err = buildMessage(mail.Address{Address: "noreply@simplebank.com"}, Message{Subject: "no recipients"})
+	require.Error(t, err)
+
+	_, err = buildMessage(mail.Address{Address: "noreply@simplebank.com"}, Message{To: []string{"not an address"}})
+	require.Error(t, err)
+}
+
+func TestMemorySender(t *testing.T) {
+	sender := NewMemorySender()
+	msg := Message{To: []string{"alice@email.com"}, Subject: "hi", Body: "<p>hi</p>"}
+
+	require.NoError(t, sender.SendEmail(context.Background(), msg))
+	require.Equal(t, []Message{msg}, sender.Messages())
+}
+
+func TestFileSender(t *testing.T) {
+	dir := t.TempDir()
+	sender, err := NewFileSender("Simple Bank", "noreply@simplebank.com", dir)
+	require.NoError(t, err)
+
+	err = sender.SendEmail(context.Background(), Message{To: []string{"alice@email.com"}, Subject: "hi", Body: "<p>hi</p>"})
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	require.Contains(t, string(data), "Subject: hi")
+}
@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+)
+
+// SMTPSender delivers emails through an SMTP server using PLAIN auth over STARTTLS
+type SMTPSender struct {
+	from     mail.Address
+	host     string
+	port     string
+	username string
+	password string
+}
+
+// NewSMTPSender creates a sender for the SMTP server at host:port
+func NewSMTPSender(fromName string, fromAddress string, host string, port string, username string, password string) *SMTPSender {
+	return &SMTPSender{
+		from:     mail.Address{Name: fromName, Address: fromAddress},
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+	}
+}
+
+func (sender *SMTPSender) SendEmail(ctx context.Context, msg Message) error {
+	data, err := buildMessage(sender.from, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if sender.username != "" {
+		auth = smtp.PlainAuth("", sender.username, sender.password, sender.host)
+	}
+
+	//net/smtp has no context support, so the send is abandoned rather than interrupted on cancellation
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(sender.host, sender.port), auth, sender.from.Address, msg.To, data)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
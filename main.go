@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"goprojects/simplebank/api"
+	"goprojects/simplebank/archive"
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/logging"
+	"goprojects/simplebank/mail"
+	"goprojects/simplebank/risk"
+	"goprojects/simplebank/telemetry"
+	"goprojects/simplebank/util"
+	"goprojects/simplebank/webhook"
+	"goprojects/simplebank/worker"
+)
+
+func main() {
+	config, err := util.LoadConfig()
+	if err != nil {
+		log.Fatal("cannot load config:", err)
+	}
+
+	logger, err := logging.New(os.Stderr, config.LogFormat, config.LogLevel)
+	if err != nil {
+		log.Fatal("cannot create logger:", err)
+	}
+	slog.SetDefault(logger)
+
+	connector, err := openConnector(config.DBDriver, config.DBSource)
+	if err != nil {
+		log.Fatal("cannot connect to db:", err)
+	}
+
+	//the pool's connections count the rows of the queries the instrumenter times
+	registry := prometheus.NewRegistry()
+	instrumenter := db.NewQueryInstrumenter(registry, logger, config.SlowQueryThreshold)
+	conn := instrumenter.OpenDB(connector)
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(conn, "simple_bank"),
+	)
+
+	tracerProvider, err := telemetry.NewTracerProvider(context.Background(), config.TracesExporter, os.Stdout)
+	if err != nil {
+		log.Fatal("cannot create tracer provider:", err)
+	}
+	telemetry.SetGlobal(tracerProvider)
+
+	blobs, err := archive.NewLocalStore(config.ArchiveDir)
+	if err != nil {
+		log.Fatal("cannot open archive:", err)
+	}
+
+	storeOpts := []db.StoreOption{
+		db.WithMetrics(db.NewMetrics(registry)),
+		db.WithDBTXMiddleware(
+			db.TraceQueries(tracerProvider),
+			instrumenter.Wrap,
+		),
+		db.WithLogger(logger),
+		db.WithArchive(archive.NewReader(blobs)),
+		db.WithEventPublisher(worker.PublishWebhookEvents),
+	}
+	screener, err := risk.DefaultEngine()
+	if config.RiskRulesFile != "" {
+		screener, err = risk.LoadEngine(config.RiskRulesFile)
+	}
+	if err != nil {
+		log.Fatal("cannot create risk screener:", err)
+	}
+	storeOpts = append(storeOpts, db.WithScreener(screener))
+	store := db.NewStore(conn, storeOpts...)
+
+	sender, err := newMailSender(config)
+	if err != nil {
+		log.Fatal("cannot create mail sender:", err)
+	}
+
+	server, err := api.NewServer(config, store, api.WithMetrics(registry), api.WithLogger(logger))
+	if err != nil {
+		log.Fatal("cannot create server:", err)
+	}
+
+	//SIGTERM is what the orchestrator sends before killing the container
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	workerDone := make(chan error, 1)
+	go func() {
+		workerDone <- runTaskProcessor(workerCtx, store, blobs, sender, config, logger)
+		//closed so shutdown does not wait for a processor that already stopped
+		close(workerDone)
+	}()
+
+	serverDone := make(chan error, 1)
+	go func() {
+		logger.Info("starting HTTP server", "address", config.HTTPServerAddress)
+		serverDone <- server.Start(config.HTTPServerAddress)
+	}()
+
+	select {
+	case <-ctx.Done():
+		logger.Info("shutting down", "timeout", config.ShutdownTimeout)
+	case err := <-serverDone:
+		logger.Error("HTTP server stopped", "error", err)
+	case err := <-workerDone:
+		logger.Error("task processor stopped", "error", err)
+	}
+
+	shutdown(server, stopWorker, workerDone, tracerProvider.Shutdown, conn, config, logger)
+}
+
+// openConnector returns a connector of the named driver, which must support them as lib/pq does
+func openConnector(driverName, dataSourceName string) (driver.Connector, error) {
+	pool, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	//sql.Open does not connect, so the pool only served to look the driver up
+	defer pool.Close()
+
+	d, ok := pool.Driver().(driver.DriverContext)
+	if !ok {
+		return nil, fmt.Errorf("driver %s does not provide connectors", driverName)
+	}
+	return d.OpenConnector(dataSourceName)
+}
+
+// shutdown drains the HTTP server first so no new transfer starts, then stops the worker,
+// flushes spans and closes the pool, all within the configured deadline
+func shutdown(server *api.Server, stopWorker context.CancelFunc, workerDone <-chan error, flushTraces func(context.Context) error, conn *sql.DB, config util.Config, logger *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Error("in-flight requests did not finish before the deadline", "error", err)
+	}
+
+	stopWorker()
+	select {
+	case err := <-workerDone:
+		if err != nil {
+			logger.Error("task processor stopped with an error", "error", err)
+		}
+	case <-ctx.Done():
+		logger.Error("running jobs did not finish before the deadline")
+	}
+
+	if err := flushTraces(ctx); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		logger.Error("cannot flush traces", "error", err)
+	}
+
+	if err := conn.Close(); err != nil {
+		logger.Error("cannot close database pool", "error", err)
+	}
+	logger.Info("shutdown complete")
+}
+
+// newMailSender sends through SMTP when a host is configured, and writes .eml files to the outbox directory otherwise
+func newMailSender(config util.Config) (mail.Sender, error) {
+	if config.SMTPHost == "" {
+		return mail.NewFileSender(config.EmailSenderName, config.EmailSenderAddress, config.MailOutboxDir)
+	}
+	return mail.NewSMTPSender(
+		config.EmailSenderName,
+		config.EmailSenderAddress,
+		config.SMTPHost,
+		config.SMTPPort,
+		config.SMTPUsername,
+		config.SMTPPassword,
+	), nil
+}
+
+// runTaskProcessor runs the background jobs until ctx is cancelled and the running jobs have finished
+func runTaskProcessor(ctx context.Context, store *db.Store, blobs archive.BlobStore, sender mail.Sender, config util.Config, logger *slog.Logger) error {
+	loc, err := time.LoadLocation(config.BusinessTimezone)
+	if err != nil {
+		return fmt.Errorf("invalid business timezone: %w", err)
+	}
+
+	processor := worker.NewProcessor(store, worker.Logger(logger))
+	worker.HandleSendVerifyEmail(processor, store, sender, config.PublicBaseURL)
+	worker.HandleCloseBusinessDay(processor, store, loc)
+	worker.HandleMaintainPartitions(processor, store, config.PartitionMonthsAhead, config.PartitionRetentionMonths)
+	worker.HandleArchiveLedger(processor, store, archive.NewArchiver(store, blobs), config.ArchiveAfterMonths)
+	worker.HandleDeliverWebhook(processor, store, webhook.NewSender(webhook.NewClient(config.WebhookTimeout)), config.WebhookDisableAfter)
+
+	if err := worker.ScheduleCloseBusinessDay(ctx, store, loc); err != nil {
+		return fmt.Errorf("cannot schedule end-of-day close: %w", err)
+	}
+	//the first maintenance runs right away, so a deployment after a long pause gets its missing partitions
+	if err := worker.ScheduleMaintainPartitions(ctx, store, time.Now()); err != nil {
+		return fmt.Errorf("cannot schedule partition maintenance: %w", err)
+	}
+	if config.ArchiveAfterMonths > 0 {
+		if err := worker.ScheduleArchiveLedger(ctx, store, time.Now()); err != nil {
+			return fmt.Errorf("cannot schedule ledger archiving: %w", err)
+		}
+	}
+
+	return processor.Start(ctx)
+}
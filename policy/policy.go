@@ -0,0 +1,101 @@
+// Package policy decides what each user role is allowed to do.
+package policy
+
+import "errors"
+
+// Role is the role attached to a user
+type Role string
+
+const (
+	//a customer holding accounts
+	RoleDepositor Role = "depositor"
+	//bank staff looking after customers' accounts
+	RoleBanker Role = "banker"
+	//read-only access to the ledger for compliance
+	RoleAuditor Role = "auditor"
+	//operates the bank and can correct the ledger
+	RoleAdmin Role = "admin"
+)
+
+// Valid reports whether role is one of the known roles
+func (role Role) Valid() bool {
+	switch role {
+	case RoleDepositor, RoleBanker, RoleAuditor, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// Permission is an action guarded by the policy
+type Permission string
+
+const (
+	PermCreateAccount   Permission = "create_account"
+	PermReadAnyAccount  Permission = "read_any_account"
+	PermFreezeAccount   Permission = "freeze_account"
+	PermTransfer        Permission = "transfer"
+	PermReadEntries     Permission = "read_entries"
+	PermReadAuditLogs   Permission = "read_audit_logs"
+	PermPostAdjustment  Permission = "post_adjustment"
+	PermAssignRole      Permission = "assign_role"
+	PermReviewTransfers Permission = "review_transfers"
+)
+
+// ErrForbidden is returned when a role lacks the permission for an action
+var ErrForbidden = errors.New("permission denied")
+
+var permissions = map[Role][]Permission{
+	RoleDepositor: {
+		PermCreateAccount,
+		PermTransfer,
+	},
+	RoleBanker: {
+		PermReadAnyAccount,
+		PermFreezeAccount,
+		PermReviewTransfers,
+	},
+	RoleAuditor: {
+		PermReadEntries,
+		PermReadAuditLogs,
+	},
+	RoleAdmin: {
+		PermCreateAccount,
+		PermReadAnyAccount,
+		PermFreezeAccount,
+		PermTransfer,
+		PermReadEntries,
+		PermReadAuditLogs,
+		PermPostAdjustment,
+		PermAssignRole,
+		PermReviewTransfers,
+	},
+}
+
+// Can reports whether role has the permission
+func Can(role Role, perm Permission) bool {
+	for _, p := range permissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize returns ErrForbidden if role does not have the permission
+func Authorize(role Role, perm Permission) error {
+	if !Can(role, perm) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// CanReadAccount reports whether a user may see an account: depositors only see their own
+func CanReadAccount(role Role, username string, owner string) bool {
+	return owner == username || Can(role, PermReadAnyAccount)
+}
+
+// CanMoveMoneyFrom reports whether a user may send money out of an account.
+// Nobody, not even an admin, transfers out of an account someone else owns; admins use adjustments instead.
+func CanMoveMoneyFrom(role Role, username string, owner string) bool {
+	return owner == username && Can(role, PermTransfer)
+}
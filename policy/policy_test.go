@@ -0,0 +1,64 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCan(t *testing.T) {
+	testCases := []struct {
+		role    Role
+		allowed []Permission
+		denied  []Permission
+	}{
+		{
+			role:    RoleDepositor,
+			allowed: []Permission{PermCreateAccount, PermTransfer},
+			denied:  []Permission{PermReadAnyAccount, PermFreezeAccount, PermReadEntries, PermPostAdjustment},
+		},
+		{
+			role:    RoleBanker,
+			allowed: []Permission{PermReadAnyAccount, PermFreezeAccount},
+			denied:  []Permission{PermTransfer, PermReadAuditLogs, PermPostAdjustment},
+		},
+		{
+			role:    RoleAuditor,
+			allowed: []Permission{PermReadEntries, PermReadAuditLogs},
+			denied:  []Permission{PermReadAnyAccount, PermFreezeAccount, PermTransfer, PermPostAdjustment},
+		},
+		{
+			role:    RoleAdmin,
+			allowed: []Permission{PermReadAnyAccount, PermFreezeAccount, PermReadAuditLogs, PermPostAdjustment, PermAssignRole},
+		},
+		{
+			role:   Role("intruder"),
+			denied: []Permission{PermCreateAccount, PermReadAnyAccount},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.role), func(t *testing.T) {
+			for _, perm := range tc.allowed {
+				require.True(t, Can(tc.role, perm), perm)
+				require.NoError(t, Authorize(tc.role, perm))
+			}
+			for _, perm := range tc.denied {
+				require.False(t, Can(tc.role, perm), perm)
+				require.ErrorIs(t, Authorize(tc.role, perm), ErrForbidden)
+			}
+		})
+	}
+}
+
+func TestAccountAccess(t *testing.T) {
+	require.True(t, CanReadAccount(RoleDepositor, "alice", "alice"))
+	require.False(t, CanReadAccount(RoleDepositor, "alice", "bob"))
+	require.True(t, CanReadAccount(RoleBanker, "carol", "bob"))
+	require.False(t, CanReadAccount(RoleAuditor, "dave", "bob"))
+
+	require.True(t, CanMoveMoneyFrom(RoleDepositor, "alice", "alice"))
+	require.False(t, CanMoveMoneyFrom(RoleDepositor, "alice", "bob"))
+	require.False(t, CanMoveMoneyFrom(RoleAdmin, "root", "bob"))
+	require.False(t, CanMoveMoneyFrom(RoleBanker, "carol", "carol"))
+}
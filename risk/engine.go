@@ -0,0 +1,189 @@
+package risk
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the YAML rule set loaded by the engine, for example
+//
+//	rules:
+//	  - name: structuring
+//	    type: structuring
+//	    outcome: review
+//	    threshold: 1000000
+//	    margin_percent: 10
+//	    min_count: 3
+//	    window: 24h
+type Config struct {
+	Rules []RuleConfig `yaml:"rules"`
+}
+
+// RuleConfig configures one rule; only the fields used by its type are read
+type RuleConfig struct {
+	Name    string        `yaml:"name"`
+	Type    string        `yaml:"type"`
+	Outcome Outcome       `yaml:"outcome"`
+	Window  time.Duration `yaml:"window"`
+
+	//structuring
+	Threshold     int64 `yaml:"threshold"`
+	MarginPercent int64 `yaml:"margin_percent"`
+	MinCount      int   `yaml:"min_count"`
+
+	//rapid_movement
+	Ratio float64 `yaml:"ratio"`
+
+	//rapid_movement and new_account_transfer
+	MinAmount int64 `yaml:"min_amount"`
+
+	//new_account_transfer
+	MaxAccountAge time.Duration `yaml:"max_account_age"`
+}
+
+// defaultRules is the rule set screening transfers unless another one is loaded from a file
+//
+//go:embed rules.yaml
+var defaultRules []byte
+
+// LoadConfig reads a rule set from a YAML file
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig decodes a YAML rule set
+func ParseConfig(data []byte) (Config, error) {
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("cannot parse risk rules: %w", err)
+	}
+	return config, nil
+}
+
+// Engine is the built-in Screener; it runs every rule and returns the strictest outcome
+type Engine struct {
+	rules []Rule
+}
+
+// NewEngine builds the rules described by config
+func NewEngine(config Config) (*Engine, error) {
+	engine := &Engine{}
+	for i, rc := range config.Rules {
+		rule, err := buildRule(rc)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i, rc.Name, err)
+		}
+		engine.rules = append(engine.rules, rule)
+	}
+	return engine, nil
+}
+
+// LoadEngine builds an engine from the rule set in the YAML file at path
+func LoadEngine(path string) (*Engine, error) {
+	config, err := LoadConfig(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load risk rules: %w", err)
+	}
+	return NewEngine(config)
+}
+
+// DefaultEngine builds an engine from the rule set built into the binary, risk/rules.yaml
+func DefaultEngine() (*Engine, error) {
+	config, err := ParseConfig(defaultRules)
+	if err != nil {
+		return nil, err
+	}
+	return NewEngine(config)
+}
+
+// NewEngineFromRules builds an engine from rules constructed in code
+func NewEngineFromRules(rules ...Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+func (e *Engine) Screen(ctx context.Context, transfer Transfer, history History) (Decision, error) {
+	decision := Decision{Outcome: OutcomeAllow}
+
+	for _, rule := range e.rules {
+		//nothing is stricter than a block, so skip the remaining rules
+		if decision.Outcome == OutcomeBlock {
+			break
+		}
+		if rule.Outcome().severity() <= decision.Outcome.severity() {
+			continue
+		}
+
+		hit, reason, err := rule.Match(ctx, transfer, history)
+		if err != nil {
+			return Decision{}, fmt.Errorf("rule %s: %w", rule.Name(), err)
+		}
+		if hit {
+			decision = Decision{
+				Outcome: rule.Outcome(),
+				Rule:    rule.Name(),
+				Reason:  reason,
+			}
+		}
+	}
+
+	return decision, nil
+}
+
+func buildRule(rc RuleConfig) (Rule, error) {
+	if rc.Name == "" {
+		rc.Name = rc.Type
+	}
+	if !rc.Outcome.valid() {
+		return nil, fmt.Errorf("unknown outcome %q", rc.Outcome)
+	}
+
+	switch rc.Type {
+	case RuleStructuring:
+		if rc.Threshold <= 0 || rc.MinCount <= 0 || rc.Window <= 0 {
+			return nil, fmt.Errorf("structuring needs threshold, min_count and window")
+		}
+		if rc.MarginPercent <= 0 || rc.MarginPercent >= 100 {
+			return nil, fmt.Errorf("margin_percent must be between 1 and 99")
+		}
+		return structuringRule{
+			name:          rc.Name,
+			outcome:       rc.Outcome,
+			threshold:     rc.Threshold,
+			marginPercent: rc.MarginPercent,
+			minCount:      rc.MinCount,
+			window:        rc.Window,
+		}, nil
+	case RuleRapidMovement:
+		if rc.Window <= 0 || rc.Ratio <= 0 {
+			return nil, fmt.Errorf("rapid_movement needs window and ratio")
+		}
+		return rapidMovementRule{
+			name:      rc.Name,
+			outcome:   rc.Outcome,
+			window:    rc.Window,
+			ratio:     rc.Ratio,
+			minAmount: rc.MinAmount,
+		}, nil
+	case RuleNewAccountTransfer:
+		if rc.MaxAccountAge <= 0 || rc.MinAmount <= 0 {
+			return nil, fmt.Errorf("new_account_transfer needs max_account_age and min_amount")
+		}
+		return newAccountTransferRule{
+			name:      rc.Name,
+			outcome:   rc.Outcome,
+			maxAge:    rc.MaxAccountAge,
+			minAmount: rc.MinAmount,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown rule type %q", rc.Type)
+	}
+}
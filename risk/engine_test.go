@@ -0,0 +1,117 @@
+package risk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHistory serves fixed movements regardless of the window asked for
+type fakeHistory struct {
+	outbound []Movement
+	inbound  []Movement
+}
+
+func (h fakeHistory) Outbound(_ context.Context, _ time.Time) ([]Movement, error) {
+	return h.outbound, nil
+}
+
+func (h fakeHistory) Inbound(_ context.Context, _ time.Time) ([]Movement, error) {
+	return h.inbound, nil
+}
+
+func loadTestEngine(t *testing.T) *Engine {
+	config, err := LoadConfig("testdata/rules.yaml")
+	require.NoError(t, err)
+	require.Len(t, config.Rules, 3)
+	require.Equal(t, 24*time.Hour, config.Rules[0].Window)
+
+	engine, err := NewEngine(config)
+	require.NoError(t, err)
+	return engine
+}
+
+func TestDefaultEngine(t *testing.T) {
+	// The built-in rule set needs no file on disk.
+	engine, err := DefaultEngine()
+	require.NoError(t, err)
+	require.NotEmpty(t, engine.rules)
+}
+
+func TestEngineAllow(t *testing.T) {
+	engine := loadTestEngine(t)
+	now := time.Now()
+
+	decision, err := engine.Screen(context.Background(), Transfer{
+		Amount:               100,
+		FromAccountCreatedAt: now.Add(-365 * 24 * time.Hour),
+		At:                   now,
+	}, fakeHistory{})
+	require.NoError(t, err)
+	require.Equal(t, OutcomeAllow, decision.Outcome)
+	require.Empty(t, decision.Rule)
+}
+
+func TestEngineStructuring(t *testing.T) {
+	engine := loadTestEngine(t)
+	now := time.Now()
+
+	// Two earlier transfers just below the threshold plus this one make a run of three.
+	history := fakeHistory{outbound: []Movement{
+		{Amount: 950, At: now.Add(-2 * time.Hour)},
+		{Amount: 990, At: now.Add(-time.Hour)},
+		{Amount: 10, At: now.Add(-time.Hour)},
+	}}
+	decision, err := engine.Screen(context.Background(), Transfer{
+		Amount:               980,
+		FromAccountCreatedAt: now.Add(-365 * 24 * time.Hour),
+		At:                   now,
+	}, history)
+	require.NoError(t, err)
+	require.Equal(t, OutcomeReview, decision.Outcome)
+	require.Equal(t, "structuring", decision.Rule)
+}
+
+func TestEngineRapidMovement(t *testing.T) {
+	engine := loadTestEngine(t)
+	now := time.Now()
+
+	history := fakeHistory{inbound: []Movement{{Amount: 800, At: now.Add(-10 * time.Minute)}}}
+	decision, err := engine.Screen(context.Background(), Transfer{
+		Amount:               750,
+		FromAccountCreatedAt: now.Add(-365 * 24 * time.Hour),
+		At:                   now,
+	}, history)
+	require.NoError(t, err)
+	require.Equal(t, OutcomeReview, decision.Outcome)
+	require.Equal(t, "rapid-in-out", decision.Rule)
+}
+
+func TestEngineBlockWins(t *testing.T) {
+	engine := loadTestEngine(t)
+	now := time.Now()
+
+	// The transfer trips rapid movement as well, but the block is stricter.
+	history := fakeHistory{inbound: []Movement{{Amount: 6000, At: now.Add(-time.Minute)}}}
+	decision, err := engine.Screen(context.Background(), Transfer{
+		Amount:               6000,
+		FromAccountCreatedAt: now.Add(-time.Hour),
+		At:                   now,
+	}, history)
+	require.NoError(t, err)
+	require.Equal(t, OutcomeBlock, decision.Outcome)
+	require.Equal(t, "new-account-large", decision.Rule)
+}
+
+func TestNewEngineInvalidConfig(t *testing.T) {
+	_, err := NewEngine(Config{Rules: []RuleConfig{{Type: "unknown", Outcome: OutcomeReview}}})
+	require.Error(t, err)
+
+	_, err = NewEngine(Config{Rules: []RuleConfig{{Type: RuleStructuring, Outcome: "hold"}}})
+	require.Error(t, err)
+
+	_, err = ParseConfig([]byte("rules: [{window: soon}]"))
+	require.Error(t, err)
+}
@@ -0,0 +1,73 @@
+// Package risk screens transfers for suspicious activity before they are committed.
+package risk
+
+import (
+	"context"
+	"time"
+)
+
+// Outcome is the verdict of a screening
+type Outcome string
+
+const (
+	//the transfer goes through untouched
+	OutcomeAllow Outcome = "allow"
+	//the transfer is recorded but the recipient's credit is held for an analyst
+	OutcomeReview Outcome = "review"
+	//the transfer is rejected and rolled back
+	OutcomeBlock Outcome = "block"
+)
+
+// severity orders outcomes so the strictest verdict of several rules wins
+func (o Outcome) severity() int {
+	switch o {
+	case OutcomeBlock:
+		return 2
+	case OutcomeReview:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (o Outcome) valid() bool {
+	return o == OutcomeAllow || o == OutcomeReview || o == OutcomeBlock
+}
+
+// Transfer is the transfer being screened
+type Transfer struct {
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        int64
+	Currency      string
+	//when the sending account was opened
+	FromAccountCreatedAt time.Time
+	//the time the transfer is being made
+	At time.Time
+}
+
+// Movement is a past transfer into or out of the sending account
+type Movement struct {
+	Amount int64
+	At     time.Time
+}
+
+// History gives rules access to the sending account's recent transfers.
+// The store implements it inside the transfer's transaction.
+type History interface {
+	Outbound(ctx context.Context, since time.Time) ([]Movement, error)
+	Inbound(ctx context.Context, since time.Time) ([]Movement, error)
+}
+
+// Decision is the result of screening a transfer
+type Decision struct {
+	Outcome Outcome `json:"outcome"`
+	//the rule that produced the outcome, empty when the transfer is allowed
+	Rule   string `json:"rule,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Screener decides whether a transfer may go ahead
+type Screener interface {
+	Screen(ctx context.Context, transfer Transfer, history History) (Decision, error)
+}
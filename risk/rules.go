@@ -0,0 +1,132 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// the rule types understood by the engine
+const (
+	RuleStructuring        = "structuring"
+	RuleRapidMovement      = "rapid_movement"
+	RuleNewAccountTransfer = "new_account_transfer"
+)
+
+// Rule is a single check run by the engine
+type Rule interface {
+	Name() string
+	Outcome() Outcome
+	//Match reports whether the transfer trips the rule and why
+	Match(ctx context.Context, transfer Transfer, history History) (bool, string, error)
+}
+
+// structuringRule catches a run of transfers kept just below a reporting threshold
+type structuringRule struct {
+	name      string
+	outcome   Outcome
+	threshold int64
+	//transfers within this many percent below the threshold count as near it
+	marginPercent int64
+	minCount      int
+	window        time.Duration
+}
+
+func (r structuringRule) Name() string     { return r.name }
+func (r structuringRule) Outcome() Outcome { return r.outcome }
+
+func (r structuringRule) near(amount int64) bool {
+	floor := r.threshold - r.threshold*r.marginPercent/100
+	return amount >= floor && amount < r.threshold
+}
+
+func (r structuringRule) Match(ctx context.Context, transfer Transfer, history History) (bool, string, error) {
+	if !r.near(transfer.Amount) {
+		return false, "", nil
+	}
+
+	outbound, err := history.Outbound(ctx, transfer.At.Add(-r.window))
+	if err != nil {
+		return false, "", err
+	}
+
+	//the transfer being screened counts towards the run
+	count := 1
+	for _, m := range outbound {
+		if r.near(m.Amount) {
+			count++
+		}
+	}
+	if count < r.minCount {
+		return false, "", nil
+	}
+
+	reason := fmt.Sprintf("%d transfers just below %d within %s", count, r.threshold, r.window)
+	return true, reason, nil
+}
+
+// rapidMovementRule catches funds that leave an account almost as soon as they arrive
+type rapidMovementRule struct {
+	name    string
+	outcome Outcome
+	window  time.Duration
+	//share of the inbound total that has to leave again within the window
+	ratio     float64
+	minAmount int64
+}
+
+func (r rapidMovementRule) Name() string     { return r.name }
+func (r rapidMovementRule) Outcome() Outcome { return r.outcome }
+
+func (r rapidMovementRule) Match(ctx context.Context, transfer Transfer, history History) (bool, string, error) {
+	since := transfer.At.Add(-r.window)
+
+	inbound, err := history.Inbound(ctx, since)
+	if err != nil {
+		return false, "", err
+	}
+	var in int64
+	for _, m := range inbound {
+		in += m.Amount
+	}
+	if in < r.minAmount {
+		return false, "", nil
+	}
+
+	outbound, err := history.Outbound(ctx, since)
+	if err != nil {
+		return false, "", err
+	}
+	out := transfer.Amount
+	for _, m := range outbound {
+		out += m.Amount
+	}
+
+	if float64(out) < r.ratio*float64(in) {
+		return false, "", nil
+	}
+
+	reason := fmt.Sprintf("%d out against %d in within %s", out, in, r.window)
+	return true, reason, nil
+}
+
+// newAccountTransferRule catches large transfers out of recently opened accounts
+type newAccountTransferRule struct {
+	name      string
+	outcome   Outcome
+	maxAge    time.Duration
+	minAmount int64
+}
+
+func (r newAccountTransferRule) Name() string     { return r.name }
+func (r newAccountTransferRule) Outcome() Outcome { return r.outcome }
+
+func (r newAccountTransferRule) Match(_ context.Context, transfer Transfer, _ History) (bool, string, error) {
+	age := transfer.At.Sub(transfer.FromAccountCreatedAt)
+	if age >= r.maxAge || transfer.Amount < r.minAmount {
+		return false, "", nil
+	}
+
+	reason := fmt.Sprintf("transfer of %d from an account opened %s ago", transfer.Amount, age.Round(time.Minute))
+	return true, reason, nil
+}
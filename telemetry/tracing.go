@@ -0,0 +1,72 @@
+// Package telemetry sets up OpenTelemetry tracing for the service.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// exporters accepted by NewTracerProvider
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// ServiceName identifies this service in traces
+const ServiceName = "simple-bank"
+
+// NewTracerProvider creates a tracer provider that sends spans to the named exporter.
+// The OTLP exporter is configured by the standard OTEL_EXPORTER_OTLP_* environment variables;
+// the stdout exporter writes pretty-printed spans to w.
+// With ExporterNone spans are still created and propagated, just never exported.
+func NewTracerProvider(ctx context.Context, exporter string, w io.Writer) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("cannot build trace resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+
+	switch exporter {
+	case ExporterNone, "":
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(w), stdouttrace.WithPrettyPrint())
+		if err != nil {
+			return nil, fmt.Errorf("cannot create stdout exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	case ExporterOTLP:
+		exp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create otlp exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+// SetGlobal installs tp as the global tracer provider and W3C trace context as the propagator,
+// so every package that uses otel.Tracer and every incoming traceparent header joins the same traces
+func SetGlobal(tp *sdktrace.TracerProvider) {
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
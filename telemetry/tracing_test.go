@@ -0,0 +1,27 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdoutTracerProvider(t *testing.T) {
+	var out bytes.Buffer
+	tp, err := NewTracerProvider(context.Background(), ExporterStdout, &out)
+	require.NoError(t, err)
+
+	_, span := tp.Tracer("test").Start(context.Background(), "transfer")
+	span.End()
+	require.NoError(t, tp.Shutdown(context.Background()))
+
+	require.Contains(t, out.String(), `"Name": "transfer"`)
+	require.Contains(t, out.String(), ServiceName)
+}
+
+func TestUnknownExporter(t *testing.T) {
+	_, err := NewTracerProvider(context.Background(), "zipkin", nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,14 @@
+package token
+
+import (
+	"time"
+)
+
+// Maker is an interface for managing tokens
+type Maker interface {
+	// CreateToken creates a new token of a specific type for a specific username, role, tenant and duration
+	CreateToken(tokenType TokenType, username string, role string, tenant string, duration time.Duration) (string, *Payload, error)
+
+	// VerifyToken checks if the token is valid or not
+	VerifyToken(token string) (*Payload, error)
+}
@@ -0,0 +1,81 @@
+package token
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// Different types of error returned by the VerifyToken function
+var (
+	ErrInvalidToken = errors.New("token is invalid")
+	ErrExpiredToken = errors.New("token has expired")
+)
+
+// TokenType tells access tokens, sent with every request, from refresh tokens, only good for renewing an access token
+type TokenType string
+
+// kinds of token handed out at login
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
+)
+
+// Payload contains the payload data of the token.
+// Tenant is the bank the user belongs to; the token is only accepted on requests for that tenant.
+type Payload struct {
+	ID        uuid.UUID `json:"id"`
+	Type      TokenType `json:"token_type"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Tenant    string    `json:"tenant"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// NewPayload creates a new token payload of a specific type, username, role, tenant and duration
+func NewPayload(tokenType TokenType, username string, role string, tenant string, duration time.Duration) (*Payload, error) {
+	tokenID, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	payload := &Payload{
+		ID:        tokenID,
+		Type:      tokenType,
+		Username:  username,
+		Role:      role,
+		Tenant:    tenant,
+		IssuedAt:  time.Now(),
+		ExpiredAt: time.Now().Add(duration),
+	}
+	return payload, nil
+}
+
+// the methods below let the payload be used as jwt.Claims
+
+func (payload *Payload) GetExpirationTime() (*jwt.NumericDate, error) {
+	return jwt.NewNumericDate(payload.ExpiredAt), nil
+}
+
+func (payload *Payload) GetIssuedAt() (*jwt.NumericDate, error) {
+	return jwt.NewNumericDate(payload.IssuedAt), nil
+}
+
+func (payload *Payload) GetNotBefore() (*jwt.NumericDate, error) {
+	return jwt.NewNumericDate(payload.IssuedAt), nil
+}
+
+func (payload *Payload) GetIssuer() (string, error) {
+	return "", nil
+}
+
+func (payload *Payload) GetSubject() (string, error) {
+	return payload.Username, nil
+}
+
+func (payload *Payload) GetAudience() (jwt.ClaimStrings, error) {
+	return nil, nil
+}
@@ -38,7 +38,11 @@ func RandomMoney() int64 {
 }
 
 func RandomCurrency() string {
-	currencies := []string{"USD", "EUR", "CAD"}
+	currencies := []string{USD, EUR, CAD}
 	n := len(currencies) 
 	return currencies[rand.Intn(n)]
-}
\ No newline at end of file
+}
+
+func RandomEmail() string {
+	return RandomString(6) + "@email.com"
+}
@@ -0,0 +1,16 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// RandomSecret returns a URL-safe secret built from n bytes of crypto/rand,
+// for codes that must not be guessable such as email verification links
+func RandomSecret(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
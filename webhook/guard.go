@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for a webhook URL or connection that reaches the bank's own network:
+// loopback, private, link-local and unspecified addresses. Letting users post there would turn the worker
+// into a scanner of the services behind the firewall, cloud metadata endpoints included.
+var ErrForbiddenAddress = errors.New("webhook address is not allowed")
+
+// forbidden reports whether ip belongs to a range webhooks may not reach
+func forbidden(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !ip.IsValid() ||
+		ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified()
+}
+
+// CheckURL parses the URL of a new subscription and rejects one that is not absolute http(s), or https when
+// requireHTTPS is set, and one whose host is or resolves to a forbidden address.
+// The check is repeated on every connection, since the host may resolve elsewhere by the time it is called.
+func CheckURL(ctx context.Context, rawURL string, requireHTTPS bool) (*url.URL, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return nil, errors.New("url must be an absolute http or https URL")
+	}
+	if requireHTTPS && target.Scheme != "https" {
+		return nil, errors.New("url must use https")
+	}
+	if target.User != nil {
+		return nil, errors.New("url must not contain credentials")
+	}
+
+	host := target.Hostname()
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if forbidden(ip) {
+			return nil, fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+		}
+		return target, nil
+	}
+
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve %s: %w", host, err)
+	}
+	for _, ip := range ips {
+		if forbidden(ip) {
+			return nil, fmt.Errorf("%w: %s resolves to %s", ErrForbiddenAddress, host, ip)
+		}
+	}
+	return target, nil
+}
+
+// checkDial is the net.Dialer Control of NewClient: it runs after the host was resolved,
+// so a name that resolved to a public address when the subscription was created cannot be pointed inside later
+func checkDial(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if forbidden(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// NewClient returns the HTTP client to send webhooks with. It only connects to addresses CheckURL allows,
+// ignores the environment's proxy, which would make that check meaningless, and does not follow redirects:
+// a redirect is reported as the delivery's failed response instead.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		Control:   checkDial,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
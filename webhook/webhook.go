@@ -0,0 +1,124 @@
+// Package webhook signs events and sends them to the URLs users subscribed with.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// headers of every webhook request
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// signatureVersion prefixes the signature, so the scheme can change without breaking receivers that check it
+const signatureVersion = "v1"
+
+// DefaultTolerance is how far a request's timestamp may be from the receiver's clock before Verify rejects it as a replay
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	// ErrInvalidSignature is returned by Verify when the signature does not match the body
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrStaleTimestamp is returned by Verify when the timestamp is outside the tolerance
+	ErrStaleTimestamp = errors.New("webhook timestamp is outside the tolerance")
+)
+
+// Message is the JSON body of a webhook request
+type Message struct {
+	//the event's ID, the same for every delivery of the event so receivers can discard duplicates
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	AccountID int64           `json:"account_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Sign returns the hex HMAC-SHA256 of "timestamp.body" keyed with secret.
+// Signing the timestamp with the body stops a captured request from being replayed later with a fresh timestamp.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the timestamp and signature headers of a webhook request against its body, the way a receiver should
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	seconds, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp %q", ErrInvalidSignature, header.Get(HeaderTimestamp))
+	}
+	timestamp := time.Unix(seconds, 0)
+	if now.Sub(timestamp).Abs() > tolerance {
+		return ErrStaleTimestamp
+	}
+
+	signature, ok := strings.CutPrefix(header.Get(HeaderSignature), signatureVersion+"=")
+	if !ok {
+		return fmt.Errorf("%w: unsupported scheme", ErrInvalidSignature)
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Sender posts signed messages
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a Sender using client, normally NewClient's; tests may pass a client that reaches local servers
+func NewSender(client *http.Client) *Sender {
+	return &Sender{client: client}
+}
+
+// Send posts msg to url signed with secret and returns the response status.
+// Any status outside 2xx is an error; a status of 0 means no response was received.
+func (sender *Sender) Send(ctx context.Context, url string, secret string, msg Message) (int, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "SimpleBank-Webhook/1.0")
+	req.Header.Set(HeaderID, msg.ID)
+	req.Header.Set(HeaderEvent, msg.Type)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, signatureVersion+"="+Sign(secret, now, body))
+
+	resp, err := sender.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	//draining a little of the body lets the connection be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	timestamp := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"1"}`)
+
+	signature := Sign("secret", timestamp, body)
+	require.Len(t, signature, 64)
+	require.Equal(t, signature, Sign("secret", timestamp, body))
+	require.NotEqual(t, signature, Sign("other", timestamp, body))
+	require.NotEqual(t, signature, Sign("secret", timestamp.Add(time.Second), body))
+	require.NotEqual(t, signature, Sign("secret", timestamp, []byte(`{"id":"2"}`)))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":"1"}`)
+	header := func(timestamp time.Time, signature string) http.Header {
+		h := http.Header{}
+		h.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+		h.Set(HeaderSignature, signature)
+		return h
+	}
+
+	testCases := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		err    error
+	}{
+		{"OK", header(now, "v1="+Sign("secret", now, body)), body, nil},
+		{"WithinTolerance", header(now.Add(-time.Minute), "v1="+Sign("secret", now.Add(-time.Minute), body)), body, nil},
+		{"Stale", header(now.Add(-time.Hour), "v1="+Sign("secret", now.Add(-time.Hour), body)), body, ErrStaleTimestamp},
+		{"WrongSecret", header(now, "v1="+Sign("other", now, body)), body, ErrInvalidSignature},
+		{"TamperedBody", header(now, "v1="+Sign("secret", now, body)), []byte(`{"id":"2"}`), ErrInvalidSignature},
+		{"UnknownScheme", header(now, "v0="+Sign("secret", now, body)), body, ErrInvalidSignature},
+		{"NoTimestamp", http.Header{HeaderSignature: {"v1=" + Sign("secret", now, body)}}, body, ErrInvalidSignature},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify("secret", tc.header, tc.body, DefaultTolerance, now)
+			if tc.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	msg := Message{
+		ID:        "3f0b7c1e-8d6a-4a53-9c1e-2b8f6f1d0a11",
+		Type:      "transfer.created",
+		AccountID: 7,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Data:      json.RawMessage(`{"amount":10}`),
+	}
+
+	var received Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, Verify("secret", r.Header, body, DefaultTolerance, time.Now()))
+		require.Equal(t, msg.ID, r.Header.Get(HeaderID))
+		require.Equal(t, msg.Type, r.Header.Get(HeaderEvent))
+		require.NoError(t, json.Unmarshal(body, &received))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	status, err := NewSender(server.Client()).Send(context.Background(), server.URL, "secret", msg)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, status)
+	require.Equal(t, msg.ID, received.ID)
+	require.Equal(t, msg.AccountID, received.AccountID)
+	require.True(t, msg.CreatedAt.Equal(received.CreatedAt))
+	require.JSONEq(t, string(msg.Data), string(received.Data))
+}
+
+func TestSendFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+
+	sender := NewSender(server.Client())
+	status, err := sender.Send(context.Background(), server.URL, "secret", Message{ID: "1"})
+	require.Error(t, err)
+	require.Equal(t, http.StatusInternalServerError, status)
+
+	// Nothing is listening once the server is closed.
+	server.Close()
+	status, err = sender.Send(context.Background(), server.URL, "secret", Message{ID: "1"})
+	require.Error(t, err)
+	require.Zero(t, status)
+}
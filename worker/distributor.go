@@ -0,0 +1,135 @@
+// Package worker runs background tasks from a Postgres-backed job queue.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+// defaults applied to every enqueued job unless overridden by an Option
+const (
+	DefaultPriority    = 0
+	DefaultMaxAttempts = 5
+)
+
+// Task names a type of job and fixes the Go type of its payload,
+// so the code that enqueues a job and the handler that runs it cannot disagree on the payload
+type Task[T any] struct {
+	Name string
+}
+
+// NewTask declares a task type
+func NewTask[T any](name string) Task[T] {
+	return Task[T]{Name: name}
+}
+
+// Option adjusts how a job is enqueued
+type Option func(*db.EnqueueJobParams)
+
+// Priority sets the job's priority; higher priorities are claimed first
+func Priority(priority int32) Option {
+	return func(arg *db.EnqueueJobParams) {
+		arg.Priority = priority
+	}
+}
+
+// MaxAttempts sets how many times the job runs before it is moved to the dead state
+func MaxAttempts(attempts int32) Option {
+	return func(arg *db.EnqueueJobParams) {
+		arg.MaxAttempts = attempts
+	}
+}
+
+// Delay makes the job eligible to run only after d has passed
+func Delay(d time.Duration) Option {
+	return func(arg *db.EnqueueJobParams) {
+		arg.RunAt = arg.RunAt.Add(d)
+	}
+}
+
+// RunAt makes the job eligible to run only from t
+func RunAt(t time.Time) Option {
+	return func(arg *db.EnqueueJobParams) {
+		arg.RunAt = t
+	}
+}
+
+// Enqueue adds a job for the task to the queue.
+// Pass the Queries of a running Store.ExecTx transaction to enqueue atomically with the caller's own writes:
+// the job only becomes visible to workers once that transaction commits.
+func (task Task[T]) Enqueue(ctx context.Context, q *db.Queries, payload T, opts ...Option) (db.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return db.Job{}, fmt.Errorf("failed to marshal %s payload: %w", task.Name, err)
+	}
+
+	arg := db.EnqueueJobParams{
+		Type:        task.Name,
+		Payload:     data,
+		Priority:    DefaultPriority,
+		MaxAttempts: DefaultMaxAttempts,
+		RunAt:       time.Now(),
+	}
+	for _, opt := range opts {
+		opt(&arg)
+	}
+
+	job, err := q.EnqueueJob(ctx, arg)
+	if err != nil {
+		return job, fmt.Errorf("failed to enqueue %s: %w", task.Name, err)
+	}
+	return job, nil
+}
+
+// EnqueueOnce adds a job for the task unless one is already pending, and reports whether it did.
+// q must belong to a Store.ExecTx transaction: callers are serialized on a lock per task held until it commits,
+// so concurrent callers cannot both find no pending job.
+func (task Task[T]) EnqueueOnce(ctx context.Context, q *db.Queries, payload T, opts ...Option) (db.Job, bool, error) {
+	if err := q.LockJobType(ctx, task.Name); err != nil {
+		return db.Job{}, false, fmt.Errorf("failed to lock %s: %w", task.Name, err)
+	}
+
+	pending, err := q.HasPendingJob(ctx, task.Name)
+	if err != nil {
+		return db.Job{}, false, fmt.Errorf("failed to look up pending %s: %w", task.Name, err)
+	}
+	if pending {
+		return db.Job{}, false, nil
+	}
+
+	job, err := task.Enqueue(ctx, q, payload, opts...)
+	return job, err == nil, err
+}
+
+// CountSince counts the jobs of the task enqueued since since, whatever their status, whose payload holds
+// the fields of payload; zero fields are left out of the match when the payload's JSON omits them.
+// Callers use it to limit how often a task is enqueued for the same subject.
+func (task Task[T]) CountSince(ctx context.Context, q *db.Queries, payload T, since time.Time) (int64, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal %s payload: %w", task.Name, err)
+	}
+
+	count, err := q.CountJobsSince(ctx, db.CountJobsSinceParams{
+		Type:    task.Name,
+		Payload: data,
+		Since:   since,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", task.Name, err)
+	}
+	return count, nil
+}
+
+// ScheduleOnce enqueues a job for the task to run at runAt in a transaction of its own, unless one is already pending.
+// Recurring tasks are scheduled with it at startup and again by their handler once a run succeeds.
+func (task Task[T]) ScheduleOnce(ctx context.Context, store *db.Store, payload T, runAt time.Time, opts ...Option) error {
+	return store.ExecTx(ctx, func(q *db.Queries) error {
+		_, _, err := task.EnqueueOnce(ctx, q, payload, append(opts, RunAt(runAt))...)
+		return err
+	})
+}
@@ -0,0 +1,22 @@
+package worker
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+
+	"goprojects/simplebank/db/dbtest"
+	db "goprojects/simplebank/db/sqlc"
+)
+
+var testDB *sql.DB
+var testStore *db.Store
+
+func TestMain(m *testing.M) {
+	os.Exit(dbtest.Main(m, func(conn *sql.DB) {
+		testDB = conn
+		testStore = db.NewStore(conn)
+	}))
+}
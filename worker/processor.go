@@ -0,0 +1,250 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/logging"
+)
+
+// ErrSkipRetry marks a handler error as permanent: the job goes straight to the dead state
+var ErrSkipRetry = errors.New("skip retry")
+
+// leaseMargin is how long before its lease expires a handler's context is cancelled, at most,
+// leaving time to record the outcome while the job is still held
+const leaseMargin = 10 * time.Second
+
+// handlerFunc runs a job with its raw JSON payload
+type handlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// Processor claims due jobs of the task types it has handlers for and runs them
+type Processor struct {
+	store    *db.Store
+	handlers map[string]handlerFunc
+
+	name         string
+	concurrency  int
+	pollInterval time.Duration
+	lease        time.Duration
+	backoff      func(attempt int32) time.Duration
+	logger       *slog.Logger
+}
+
+// ProcessorOption configures a Processor
+type ProcessorOption func(*Processor)
+
+// Concurrency sets how many jobs run at the same time
+func Concurrency(n int) ProcessorOption {
+	return func(p *Processor) {
+		p.concurrency = n
+	}
+}
+
+// PollInterval sets how long the processor sleeps when the queue is empty
+func PollInterval(d time.Duration) ProcessorOption {
+	return func(p *Processor) {
+		p.pollInterval = d
+	}
+}
+
+// Lease sets how long a job may stay running before another worker assumes its worker died;
+// the handler's context is cancelled shortly before
+func Lease(d time.Duration) ProcessorOption {
+	return func(p *Processor) {
+		p.lease = d
+	}
+}
+
+// Backoff replaces the delay before a failed job is retried
+func Backoff(backoff func(attempt int32) time.Duration) ProcessorOption {
+	return func(p *Processor) {
+		p.backoff = backoff
+	}
+}
+
+// Logger replaces slog.Default as the processor's logger
+func Logger(logger *slog.Logger) ProcessorOption {
+	return func(p *Processor) {
+		p.logger = logger
+	}
+}
+
+// NewProcessor creates a processor; register handlers with Handle before calling Start
+func NewProcessor(store *db.Store, opts ...ProcessorOption) *Processor {
+	hostname, _ := os.Hostname()
+	processor := &Processor{
+		store:        store,
+		handlers:     make(map[string]handlerFunc),
+		name:         fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		concurrency:  4,
+		pollInterval: time.Second,
+		lease:        5 * time.Minute,
+		backoff:      ExponentialBackoff,
+		logger:       slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(processor)
+	}
+	return processor
+}
+
+// Handle registers the handler that runs jobs of the task
+func Handle[T any](p *Processor, task Task[T], handler func(ctx context.Context, payload T) error) {
+	p.handlers[task.Name] = func(ctx context.Context, data json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("failed to unmarshal %s payload: %v: %w", task.Name, err, ErrSkipRetry)
+		}
+		return handler(ctx, payload)
+	}
+}
+
+// ExponentialBackoff waits 2^attempt seconds, capped at an hour, with up to 20% jitter
+// so jobs that failed together do not all retry at the same instant
+func ExponentialBackoff(attempt int32) time.Duration {
+	if attempt > 12 {
+		attempt = 12
+	}
+	delay := time.Duration(1<<attempt) * time.Second
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	return delay + jitter
+}
+
+// Start polls the queue until ctx is cancelled, then waits for running jobs to finish
+func (p *Processor) Start(ctx context.Context) error {
+	jobs := make(chan db.Job)
+	var wg sync.WaitGroup
+
+	for i := 0; i < p.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				//a job already claimed is finished even during shutdown, so it is not left running until its lease expires;
+				//its handler still stops when the lease is about to expire
+				p.run(context.WithoutCancel(ctx), job)
+			}
+		}()
+	}
+
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	for {
+		claimed, err := p.poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			p.logger.ErrorContext(ctx, "worker: failed to claim jobs", "error", err)
+		}
+
+		for _, job := range claimed {
+			jobs <- job
+		}
+
+		//keep draining while the queue is busy, sleep when it is empty
+		if len(claimed) > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(p.pollInterval):
+		}
+	}
+}
+
+func (p *Processor) poll(ctx context.Context) ([]db.Job, error) {
+	_, err := p.store.RequeueStuckJobs(ctx, sql.NullTime{Time: time.Now().Add(-p.lease), Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	types := make([]string, 0, len(p.handlers))
+	for name := range p.handlers {
+		types = append(types, name)
+	}
+
+	return p.store.ClaimJobs(ctx, db.ClaimJobsParams{
+		Worker:    p.name,
+		Types:     types,
+		BatchSize: int32(p.concurrency),
+	})
+}
+
+// run executes a claimed job and records the outcome
+func (p *Processor) run(ctx context.Context, job db.Job) {
+	ctx = logging.With(ctx, "job_id", job.ID, "task", job.Type, "attempt", job.Attempts)
+
+	var recorded int64
+	err := p.execute(ctx, job)
+	switch {
+	case err == nil:
+		recorded, err = p.store.CompleteJob(ctx, db.CompleteJobParams{ID: job.ID, Worker: p.name, Attempts: job.Attempts})
+	case errors.Is(err, ErrSkipRetry) || job.Attempts >= job.MaxAttempts:
+		p.logger.ErrorContext(ctx, "worker: job is dead", "error", err)
+		recorded, err = p.store.BuryJob(ctx, db.BuryJobParams{
+			LastError: sql.NullString{String: err.Error(), Valid: true},
+			ID:        job.ID,
+			Worker:    p.name,
+			Attempts:  job.Attempts,
+		})
+	default:
+		p.logger.WarnContext(ctx, "worker: job failed, will retry", "error", err)
+		recorded, err = p.store.RetryJob(ctx, db.RetryJobParams{
+			RunAt:     time.Now().Add(p.backoff(job.Attempts)),
+			LastError: sql.NullString{String: err.Error(), Valid: true},
+			ID:        job.ID,
+			Worker:    p.name,
+			Attempts:  job.Attempts,
+		})
+	}
+	if err != nil {
+		p.logger.ErrorContext(ctx, "worker: failed to record job outcome", "error", err)
+		return
+	}
+	//the job ran past its lease and was requeued, and maybe claimed again: whoever holds it now records the outcome
+	if recorded == 0 {
+		p.logger.WarnContext(ctx, "worker: lost the lease of the job, its outcome is not recorded", "lease", p.lease)
+	}
+}
+
+func (p *Processor) execute(ctx context.Context, job db.Job) (err error) {
+	handler, ok := p.handlers[job.Type]
+	if !ok {
+		return fmt.Errorf("no handler for task %s: %w", job.Type, ErrSkipRetry)
+	}
+
+	//a panicking handler fails its job instead of taking the worker down
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+
+	//the handler stops before the lease expires, so the job is not run again while it is still running
+	ctx, cancel := context.WithDeadline(ctx, p.leaseDeadline(job))
+	defer cancel()
+	return handler(ctx, job.Payload)
+}
+
+// leaseDeadline is when the handler of a job must stop: shortly before the lease taken when the job was claimed expires
+func (p *Processor) leaseDeadline(job db.Job) time.Time {
+	lockedAt := time.Now()
+	if job.LockedAt.Valid {
+		lockedAt = job.LockedAt.Time
+	}
+	return lockedAt.Add(p.lease - min(p.lease/10, leaseMargin))
+}
@@ -0,0 +1,238 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/util"
+)
+
+type testPayload struct {
+	Value string `json:"value"`
+}
+
+// runProcessor runs p until the condition holds or the deadline passes
+func runProcessor(t *testing.T, p *Processor, condition func() bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- p.Start(ctx)
+	}()
+
+	require.Eventually(t, condition, 10*time.Second, 50*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestEnqueueInTransaction(t *testing.T) {
+	task := NewTask[testPayload]("test:" + util.RandomString(8))
+
+	// A job enqueued in a rolled back transaction never reaches the queue.
+	var jobID int64
+	err := testStore.ExecTx(context.Background(), func(q *db.Queries) error {
+		job, err := task.Enqueue(context.Background(), q, testPayload{Value: "a"})
+		require.NoError(t, err)
+		jobID = job.ID
+		return errors.New("rollback")
+	})
+	require.Error(t, err)
+
+	_, err = testStore.GetJob(context.Background(), jobID)
+	require.Error(t, err)
+
+	runAt := time.Now().Add(time.Hour)
+	job, err := task.Enqueue(context.Background(), testStore.Queries, testPayload{Value: "b"},
+		Priority(7), MaxAttempts(2), RunAt(runAt))
+	require.NoError(t, err)
+	require.Equal(t, task.Name, job.Type)
+	require.Equal(t, int32(7), job.Priority)
+	require.Equal(t, int32(2), job.MaxAttempts)
+	require.Equal(t, "pending", job.Status)
+	require.WithinDuration(t, runAt, job.RunAt, time.Second)
+	require.JSONEq(t, `{"value":"b"}`, string(job.Payload))
+}
+
+func TestProcessorRunsJob(t *testing.T) {
+	task := NewTask[testPayload]("test:" + util.RandomString(8))
+	job, err := task.Enqueue(context.Background(), testStore.Queries, testPayload{Value: "hello"}, Priority(100))
+	require.NoError(t, err)
+
+	var received atomic.Value
+	p := NewProcessor(testStore, PollInterval(10*time.Millisecond))
+	Handle(p, task, func(ctx context.Context, payload testPayload) error {
+		received.Store(payload.Value)
+		return nil
+	})
+
+	runProcessor(t, p, func() bool {
+		job, err := testStore.GetJob(context.Background(), job.ID)
+		return err == nil && job.Status == "done"
+	})
+	require.Equal(t, "hello", received.Load())
+}
+
+func TestProcessorRetriesThenBuries(t *testing.T) {
+	task := NewTask[testPayload]("test:" + util.RandomString(8))
+	job, err := task.Enqueue(context.Background(), testStore.Queries, testPayload{}, Priority(100), MaxAttempts(3))
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+	p := NewProcessor(testStore,
+		PollInterval(10*time.Millisecond),
+		Backoff(func(int32) time.Duration { return 0 }),
+	)
+	Handle(p, task, func(ctx context.Context, payload testPayload) error {
+		calls.Add(1)
+		return errors.New("boom")
+	})
+
+	runProcessor(t, p, func() bool {
+		job, err := testStore.GetJob(context.Background(), job.ID)
+		return err == nil && job.Status == "dead"
+	})
+
+	job, err = testStore.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), calls.Load())
+	require.Equal(t, int32(3), job.Attempts)
+	require.Equal(t, "boom", job.LastError.String)
+
+	// A dead job can be put back on the queue by an operator.
+	job, err = testStore.ResurrectJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, "pending", job.Status)
+	require.Zero(t, job.Attempts)
+}
+
+func TestJobLease(t *testing.T) {
+	ctx := context.Background()
+	task := NewTask[testPayload]("test:" + util.RandomString(8))
+	job, err := task.Enqueue(ctx, testStore.Queries, testPayload{}, MaxAttempts(2))
+	require.NoError(t, err)
+
+	claim := func(worker string) db.Job {
+		claimed, err := testStore.ClaimJobs(ctx, db.ClaimJobsParams{Worker: worker, Types: []string{task.Name}, BatchSize: 1})
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		return claimed[0]
+	}
+	expireLeases := func() {
+		_, err := testStore.RequeueStuckJobs(ctx, sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true})
+		require.NoError(t, err)
+	}
+
+	first := claim("first")
+	require.Equal(t, int32(1), first.Attempts)
+
+	// Only the run holding the lease records an outcome.
+	recorded, err := testStore.CompleteJob(ctx, db.CompleteJobParams{ID: job.ID, Worker: "other", Attempts: first.Attempts})
+	require.NoError(t, err)
+	require.Zero(t, recorded)
+
+	// Once the lease expires the job goes back to the queue, and the late run cannot finish it.
+	expireLeases()
+	job, err = testStore.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, "pending", job.Status)
+	require.Equal(t, "lease expired", job.LastError.String)
+
+	recorded, err = testStore.CompleteJob(ctx, db.CompleteJobParams{ID: job.ID, Worker: "first", Attempts: first.Attempts})
+	require.NoError(t, err)
+	require.Zero(t, recorded)
+
+	// A later claim by the same worker is a different run.
+	second := claim("first")
+	require.Equal(t, int32(2), second.Attempts)
+	recorded, err = testStore.RetryJob(ctx, db.RetryJobParams{RunAt: time.Now(), ID: job.ID, Worker: "first", Attempts: first.Attempts})
+	require.NoError(t, err)
+	require.Zero(t, recorded)
+
+	// A lost run uses up its attempt: out of attempts, the job is buried rather than requeued.
+	expireLeases()
+	job, err = testStore.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, "dead", job.Status)
+	require.Equal(t, int32(2), job.Attempts)
+
+	// The run that holds the lease records its outcome.
+	job, err = testStore.ResurrectJob(ctx, job.ID)
+	require.NoError(t, err)
+	third := claim("first")
+	recorded, err = testStore.CompleteJob(ctx, db.CompleteJobParams{ID: job.ID, Worker: "first", Attempts: third.Attempts})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), recorded)
+}
+
+func TestProcessorStopsHandlerBeforeLeaseExpires(t *testing.T) {
+	task := NewTask[testPayload]("test:" + util.RandomString(8))
+	job, err := task.Enqueue(context.Background(), testStore.Queries, testPayload{}, Priority(100))
+	require.NoError(t, err)
+
+	p := NewProcessor(testStore,
+		PollInterval(10*time.Millisecond),
+		Lease(2*time.Second),
+		Backoff(func(int32) time.Duration { return time.Hour }),
+	)
+	Handle(p, task, func(ctx context.Context, payload testPayload) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	// The handler times out while the job is still held, so its failure is recorded rather than the lease expiring.
+	runProcessor(t, p, func() bool {
+		job, err := testStore.GetJob(context.Background(), job.ID)
+		return err == nil && job.Status == "pending" && job.LastError.Valid
+	})
+	job, err = testStore.GetJob(context.Background(), job.ID)
+	require.NoError(t, err)
+	require.Equal(t, context.DeadlineExceeded.Error(), job.LastError.String)
+	require.Equal(t, int32(1), job.Attempts)
+}
+
+func TestProcessorSkipRetry(t *testing.T) {
+	task := NewTask[testPayload]("test:" + util.RandomString(8))
+	job, err := task.Enqueue(context.Background(), testStore.Queries, testPayload{}, Priority(100))
+	require.NoError(t, err)
+
+	p := NewProcessor(testStore, PollInterval(10*time.Millisecond))
+	Handle(p, task, func(ctx context.Context, payload testPayload) error {
+		return ErrSkipRetry
+	})
+
+	runProcessor(t, p, func() bool {
+		job, err := testStore.GetJob(context.Background(), job.ID)
+		return err == nil && job.Status == "dead" && job.Attempts == 1
+	})
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	require.GreaterOrEqual(t, ExponentialBackoff(1), 2*time.Second)
+	require.Less(t, ExponentialBackoff(1), 3*time.Second)
+	require.GreaterOrEqual(t, ExponentialBackoff(3), 8*time.Second)
+	require.LessOrEqual(t, ExponentialBackoff(30), time.Hour+time.Hour/5)
+}
+
+func TestEnqueueOnce(t *testing.T) {
+	task := NewTask[testPayload]("test:" + util.RandomString(8))
+
+	enqueue := func() bool {
+		var enqueued bool
+		err := testStore.ExecTx(context.Background(), func(q *db.Queries) error {
+			var err error
+			_, enqueued, err = task.EnqueueOnce(context.Background(), q, testPayload{}, Delay(time.Hour))
+			return err
+		})
+		require.NoError(t, err)
+		return enqueued
+	}
+
+	require.True(t, enqueue())
+	require.False(t, enqueue())
+}
@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"goprojects/simplebank/archive"
+	db "goprojects/simplebank/db/sqlc"
+)
+
+// PayloadArchiveLedger is the payload of TaskArchiveLedger
+type PayloadArchiveLedger struct{}
+
+// TaskArchiveLedger moves closed months of entries and transfers to the archive
+var TaskArchiveLedger = NewTask[PayloadArchiveLedger]("task:archive_ledger")
+
+// archiveLedgerInterval is how often due months are looked for; a month becomes due once a month, so a daily run
+// archives it soon after without having to know when its last business day closes
+const archiveLedgerInterval = 24 * time.Hour
+
+// HandleArchiveLedger registers the TaskArchiveLedger handler, which archives every closed month
+// that started at least afterMonths months before the current one; zero disables it
+func HandleArchiveLedger(p *Processor, store *db.Store, archiver *archive.Archiver, afterMonths int) {
+	Handle(p, TaskArchiveLedger, func(ctx context.Context, _ PayloadArchiveLedger) error {
+		//a run left pending by a deployment that had archiving enabled must not archive every closed month
+		if afterMonths == 0 {
+			return nil
+		}
+		if _, err := archiver.ArchiveDue(ctx, time.Now(), afterMonths); err != nil {
+			return fmt.Errorf("failed to archive ledger: %w", err)
+		}
+		return ScheduleArchiveLedger(ctx, store, time.Now().Add(archiveLedgerInterval))
+	})
+}
+
+// ScheduleArchiveLedger enqueues TaskArchiveLedger to run at runAt, unless a run is already pending
+func ScheduleArchiveLedger(ctx context.Context, store *db.Store, runAt time.Time) error {
+	return TaskArchiveLedger.ScheduleOnce(ctx, store, PayloadArchiveLedger{}, runAt, Priority(10))
+}
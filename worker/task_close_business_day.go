@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+// PayloadCloseBusinessDay is the payload of TaskCloseBusinessDay; the job works out which days to close itself
+type PayloadCloseBusinessDay struct{}
+
+// TaskCloseBusinessDay closes every business day that has ended, then schedules itself for the end of the current one
+var TaskCloseBusinessDay = NewTask[PayloadCloseBusinessDay]("task:close_business_day")
+
+// closeBusinessDayActor is recorded as closed_by for the days the worker closes
+const closeBusinessDayActor = "worker"
+
+// HandleCloseBusinessDay registers the TaskCloseBusinessDay handler; business days end at midnight in loc
+func HandleCloseBusinessDay(p *Processor, store *db.Store, loc *time.Location) {
+	Handle(p, TaskCloseBusinessDay, func(ctx context.Context, _ PayloadCloseBusinessDay) error {
+		if err := CloseBusinessDays(ctx, store, loc, time.Now()); err != nil {
+			return err
+		}
+		return ScheduleCloseBusinessDay(ctx, store, loc)
+	})
+}
+
+// ScheduleCloseBusinessDay enqueues TaskCloseBusinessDay to run when the current business day ends,
+// unless a run is already pending. Call it at startup so the chain of daily runs survives a job going dead.
+func ScheduleCloseBusinessDay(ctx context.Context, store *db.Store, loc *time.Location) error {
+	end := db.BusinessDayEnd(db.BusinessDate(time.Now(), loc), loc)
+	return TaskCloseBusinessDay.ScheduleOnce(ctx, store, PayloadCloseBusinessDay{}, end, Priority(10))
+}
+
+// CloseBusinessDays closes, in order, every business day that had ended by now and is still open.
+// The first time it runs it only closes yesterday: that snapshot covers the whole history before it.
+func CloseBusinessDays(ctx context.Context, store *db.Store, loc *time.Location, now time.Time) error {
+	var date time.Time
+	latest, err := store.GetLatestBusinessDay(ctx)
+	switch {
+	case err == nil:
+		date = latest.BusinessDate.AddDate(0, 0, 1)
+	case errors.Is(err, sql.ErrNoRows):
+		date = db.BusinessDate(now, loc).AddDate(0, 0, -1)
+	default:
+		return fmt.Errorf("failed to get latest business day: %w", err)
+	}
+
+	for ; !db.BusinessDayEnd(date, loc).After(now); date = date.AddDate(0, 0, 1) {
+		_, err := store.CloseBusinessDayTx(ctx, db.CloseBusinessDayTxParams{
+			Date:     date,
+			Location: loc,
+			Actor:    closeBusinessDayActor,
+		})
+		//another worker closed it first
+		if errors.Is(err, db.ErrBusinessDayClosed) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to close business day %s: %w", date.Format(time.DateOnly), err)
+		}
+	}
+	return nil
+}
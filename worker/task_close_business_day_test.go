@@ -0,0 +1,31 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+func TestCloseBusinessDays(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	// The first run only closes yesterday, a second run finds nothing left to close.
+	require.NoError(t, CloseBusinessDays(ctx, testStore, time.UTC, now))
+	require.NoError(t, CloseBusinessDays(ctx, testStore, time.UTC, now))
+
+	latest, err := testStore.GetLatestBusinessDay(ctx)
+	require.NoError(t, err)
+	require.Equal(t, db.BusinessDate(now, time.UTC).AddDate(0, 0, -1), latest.BusinessDate.UTC())
+
+	require.NoError(t, ScheduleCloseBusinessDay(ctx, testStore, time.UTC))
+	require.NoError(t, ScheduleCloseBusinessDay(ctx, testStore, time.UTC))
+
+	pending, err := testStore.HasPendingJob(ctx, TaskCloseBusinessDay.Name)
+	require.NoError(t, err)
+	require.True(t, pending)
+}
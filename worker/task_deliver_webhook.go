@@ -0,0 +1,177 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/webhook"
+)
+
+// PayloadDeliverWebhook is the payload of TaskDeliverWebhook
+type PayloadDeliverWebhook struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// TaskDeliverWebhook posts a webhook delivery to its subscription's URL
+var TaskDeliverWebhook = NewTask[PayloadDeliverWebhook]("task:deliver_webhook")
+
+// WebhookMaxAttempts is how many times a delivery is attempted; with ExponentialBackoff the last attempt
+// comes a little over four minutes after the first
+const WebhookMaxAttempts = 8
+
+// PublishWebhookEvents is the db.EventPublisher of webhooks: it records a delivery of every event
+// to each active subscription of the account's owner that asked for its type, and enqueues it in the same transaction
+func PublishWebhookEvents(ctx context.Context, q *db.Queries, events []db.Event) error {
+	var owners []string
+	for _, event := range events {
+		if !slices.Contains(owners, event.Account.Owner) {
+			owners = append(owners, event.Account.Owner)
+		}
+	}
+	subscriptions, err := q.ListActiveWebhookSubscriptions(ctx, owners)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	for _, event := range events {
+		var payload []byte
+		eventID := uuid.New()
+		for _, subscription := range subscriptions {
+			if subscription.Owner != event.Account.Owner || !slices.Contains(subscription.EventTypes, event.Type) {
+				continue
+			}
+			if payload == nil {
+				data, err := json.Marshal(event.Data)
+				if err != nil {
+					return fmt.Errorf("failed to marshal %s event: %w", event.Type, err)
+				}
+				payload, err = json.Marshal(webhook.Message{
+					ID:        eventID.String(),
+					Type:      event.Type,
+					AccountID: event.Account.ID,
+					CreatedAt: now,
+					Data:      data,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to marshal %s event: %w", event.Type, err)
+				}
+			}
+
+			_, err := EnqueueWebhookDelivery(ctx, q, db.CreateWebhookDeliveryParams{
+				SubscriptionID: subscription.ID,
+				EventID:        eventID,
+				EventType:      event.Type,
+				AccountID:      event.Account.ID,
+				Payload:        payload,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EnqueueWebhookDelivery records a delivery and enqueues TaskDeliverWebhook to send it
+func EnqueueWebhookDelivery(ctx context.Context, q *db.Queries, arg db.CreateWebhookDeliveryParams) (db.WebhookDelivery, error) {
+	delivery, err := q.CreateWebhookDelivery(ctx, arg)
+	if err != nil {
+		return delivery, fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+	_, err = TaskDeliverWebhook.Enqueue(ctx, q, PayloadDeliverWebhook{DeliveryID: delivery.ID}, MaxAttempts(WebhookMaxAttempts))
+	return delivery, err
+}
+
+// HandleDeliverWebhook registers the TaskDeliverWebhook handler. A failed attempt is retried with the job's backoff
+// until WebhookMaxAttempts; disableAfter failed attempts in a row, across all of a subscription's deliveries,
+// disable the subscription and fail its pending deliveries until its owner enables it again.
+func HandleDeliverWebhook(p *Processor, store *db.Store, sender *webhook.Sender, disableAfter int) {
+	Handle(p, TaskDeliverWebhook, func(ctx context.Context, payload PayloadDeliverWebhook) error {
+		delivery, err := store.GetWebhookDelivery(ctx, payload.DeliveryID)
+		if errors.Is(err, sql.ErrNoRows) {
+			//deleting a subscription deletes its deliveries
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get webhook delivery: %w", err)
+		}
+		if delivery.Status != db.WebhookDeliveryPending {
+			return nil
+		}
+
+		subscription, err := store.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+		if err != nil {
+			return fmt.Errorf("failed to get webhook subscription: %w", err)
+		}
+		if subscription.DisabledAt.Valid {
+			_, err := store.UpdateWebhookDelivery(ctx, db.UpdateWebhookDeliveryParams{
+				ID:             delivery.ID,
+				Status:         db.WebhookDeliveryFailed,
+				Attempts:       delivery.Attempts,
+				ResponseStatus: delivery.ResponseStatus,
+				LastError:      sql.NullString{String: "subscription is disabled", Valid: true},
+			})
+			return err
+		}
+
+		var msg webhook.Message
+		if err := json.Unmarshal(delivery.Payload, &msg); err != nil {
+			return fmt.Errorf("invalid webhook payload: %v: %w", err, ErrSkipRetry)
+		}
+
+		attempts := delivery.Attempts + 1
+		status, sendErr := sender.Send(ctx, subscription.Url, subscription.Secret, msg)
+		responseStatus := sql.NullInt32{Int32: int32(status), Valid: status != 0}
+		if sendErr == nil {
+			_, err := store.UpdateWebhookDelivery(ctx, db.UpdateWebhookDeliveryParams{
+				ID:             delivery.ID,
+				Status:         db.WebhookDeliverySucceeded,
+				Attempts:       attempts,
+				ResponseStatus: responseStatus,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to record webhook delivery: %w", err)
+			}
+			return store.RecordWebhookSuccess(ctx, subscription.ID)
+		}
+
+		subscription, err = store.RecordWebhookFailure(ctx, db.RecordWebhookFailureParams{
+			MaxFailures: int32(disableAfter),
+			ID:          subscription.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record webhook failure: %w", err)
+		}
+
+		outcome := db.WebhookDeliveryPending
+		if subscription.DisabledAt.Valid || attempts >= WebhookMaxAttempts {
+			outcome = db.WebhookDeliveryFailed
+		}
+		_, err = store.UpdateWebhookDelivery(ctx, db.UpdateWebhookDeliveryParams{
+			ID:             delivery.ID,
+			Status:         outcome,
+			Attempts:       attempts,
+			ResponseStatus: responseStatus,
+			LastError:      sql.NullString{String: sendErr.Error(), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record webhook delivery: %w", err)
+		}
+		if outcome == db.WebhookDeliveryFailed {
+			return fmt.Errorf("webhook delivery gave up: %v: %w", sendErr, ErrSkipRetry)
+		}
+		return fmt.Errorf("webhook delivery failed: %w", sendErr)
+	})
+}
@@ -0,0 +1,174 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/util"
+	"goprojects/simplebank/webhook"
+)
+
+// createWebhookOwner creates a user with two accounts and a webhook subscription to url for every event type
+func createWebhookOwner(t *testing.T, url string) (db.WebhookSubscription, db.Account, db.Account) {
+	ctx := context.Background()
+	user, err := testStore.CreateUser(ctx, db.CreateUserParams{
+		Username:       util.RandomOwner() + util.RandomString(4),
+		HashedPassword: "hashed",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	})
+	require.NoError(t, err)
+
+	var accounts [2]db.Account
+	for i := range accounts {
+		accounts[i], err = testStore.CreateAccount(ctx, db.CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  100,
+			Currency: util.USD,
+		})
+		require.NoError(t, err)
+	}
+
+	subscription, err := testStore.CreateWebhookSubscription(ctx, db.CreateWebhookSubscriptionParams{
+		Owner:      user.Username,
+		Url:        url,
+		EventTypes: db.EventTypes,
+		Secret:     "secret",
+	})
+	require.NoError(t, err)
+	return subscription, accounts[0], accounts[1]
+}
+
+func TestDeliverWebhook(t *testing.T) {
+	var mu sync.Mutex
+	var received []webhook.Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, webhook.Verify("secret", r.Header, body, webhook.DefaultTolerance, time.Now()))
+
+		var msg webhook.Message
+		require.NoError(t, json.Unmarshal(body, &msg))
+		mu.Lock()
+		received = append(received, msg)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	subscription, from, to := createWebhookOwner(t, server.URL)
+	store := db.NewStore(testDB, db.WithEventPublisher(PublishWebhookEvents))
+	result, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	p := NewProcessor(testStore, PollInterval(10*time.Millisecond))
+	HandleDeliverWebhook(p, testStore, webhook.NewSender(server.Client()), 3)
+
+	// A transfer.created and a balance.updated event for each account
+	runProcessor(t, p, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 4
+	})
+
+	balances := map[int64]int64{}
+	for _, msg := range received {
+		switch msg.Type {
+		case db.EventTransferCreated:
+			var transfer db.Transfer
+			require.NoError(t, json.Unmarshal(msg.Data, &transfer))
+			require.Equal(t, result.Transfer.ID, transfer.ID)
+		case db.EventBalanceUpdated:
+			var account db.Account
+			require.NoError(t, json.Unmarshal(msg.Data, &account))
+			require.Equal(t, msg.AccountID, account.ID)
+			balances[account.ID] = account.Balance
+		}
+	}
+	require.Equal(t, map[int64]int64{from.ID: 90, to.ID: 110}, balances)
+
+	deliveries, err := testStore.ListWebhookDeliveries(context.Background(), db.ListWebhookDeliveriesParams{
+		SubscriptionID: subscription.ID,
+		Limit:          10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 4)
+	for _, delivery := range deliveries {
+		require.Equal(t, db.WebhookDeliverySucceeded, delivery.Status)
+		require.EqualValues(t, 1, delivery.Attempts)
+		require.EqualValues(t, http.StatusOK, delivery.ResponseStatus.Int32)
+	}
+}
+
+func TestDeliverWebhookDisablesSubscription(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	subscription, from, to := createWebhookOwner(t, server.URL)
+	store := db.NewStore(testDB, db.WithEventPublisher(PublishWebhookEvents))
+	_, err := store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// The first failed attempt disables the subscription, which fails every other delivery without sending it.
+	p := NewProcessor(testStore, PollInterval(10*time.Millisecond), Concurrency(1))
+	HandleDeliverWebhook(p, testStore, webhook.NewSender(server.Client()), 1)
+
+	var deliveries []db.WebhookDelivery
+	runProcessor(t, p, func() bool {
+		deliveries, err = testStore.ListWebhookDeliveries(context.Background(), db.ListWebhookDeliveriesParams{
+			SubscriptionID: subscription.ID,
+			Limit:          10,
+		})
+		require.NoError(t, err)
+		for _, delivery := range deliveries {
+			if delivery.Status != db.WebhookDeliveryFailed {
+				return false
+			}
+		}
+		return len(deliveries) == 4
+	})
+
+	var attempts int32
+	for _, delivery := range deliveries {
+		attempts += delivery.Attempts
+		require.True(t, delivery.LastError.Valid)
+	}
+	require.EqualValues(t, 1, attempts)
+
+	subscription, err = testStore.GetWebhookSubscription(context.Background(), subscription.ID)
+	require.NoError(t, err)
+	require.True(t, subscription.DisabledAt.Valid)
+	require.EqualValues(t, 1, subscription.FailureCount)
+
+	// Once disabled, the owner's transfers are not delivered at all.
+	_, err = store.TransferTx(context.Background(), db.TransferTxParams{
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	deliveries, err = testStore.ListWebhookDeliveries(context.Background(), db.ListWebhookDeliveriesParams{
+		SubscriptionID: subscription.ID,
+		Limit:          10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 4)
+}
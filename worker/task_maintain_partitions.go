@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "goprojects/simplebank/db/sqlc"
+)
+
+// PayloadMaintainPartitions is the payload of TaskMaintainPartitions
+type PayloadMaintainPartitions struct{}
+
+// TaskMaintainPartitions creates the monthly partitions of entries and transfers ahead of time and detaches old ones
+var TaskMaintainPartitions = NewTask[PayloadMaintainPartitions]("task:maintain_partitions")
+
+// maintainPartitionsInterval is how often the maintenance runs; daily is far more often than a month needs,
+// so a few failed runs never leave the next month without a partition
+const maintainPartitionsInterval = 24 * time.Hour
+
+// HandleMaintainPartitions registers the TaskMaintainPartitions handler.
+// monthsAhead months after the current one get partitions, months older than retentionMonths are detached unless it is zero.
+func HandleMaintainPartitions(p *Processor, store *db.Store, monthsAhead int, retentionMonths int) {
+	Handle(p, TaskMaintainPartitions, func(ctx context.Context, _ PayloadMaintainPartitions) error {
+		_, err := store.MaintainPartitions(ctx, db.MaintainPartitionsParams{
+			Now:             time.Now(),
+			MonthsAhead:     monthsAhead,
+			RetentionMonths: retentionMonths,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to maintain partitions: %w", err)
+		}
+		return ScheduleMaintainPartitions(ctx, store, time.Now().Add(maintainPartitionsInterval))
+	})
+}
+
+// ScheduleMaintainPartitions enqueues TaskMaintainPartitions to run at runAt, unless a run is already pending
+func ScheduleMaintainPartitions(ctx context.Context, store *db.Store, runAt time.Time) error {
+	return TaskMaintainPartitions.ScheduleOnce(ctx, store, PayloadMaintainPartitions{}, runAt, Priority(10))
+}
@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/mail"
+	"goprojects/simplebank/util"
+)
+
+// PayloadSendVerifyEmail is the payload of TaskSendVerifyEmail
+type PayloadSendVerifyEmail struct {
+	Username string `json:"username"`
+}
+
+// TaskSendVerifyEmail sends a new user the link that verifies their email address
+var TaskSendVerifyEmail = NewTask[PayloadSendVerifyEmail]("task:send_verify_email")
+
+// HandleSendVerifyEmail registers the TaskSendVerifyEmail handler.
+// The link points at baseURL/verify_email, the API endpoint that uses up the code,
+// and names the user's tenant, whose row-level security would otherwise hide the code.
+func HandleSendVerifyEmail(p *Processor, store *db.Store, sender mail.Sender, baseURL string) {
+	Handle(p, TaskSendVerifyEmail, func(ctx context.Context, payload PayloadSendVerifyEmail) error {
+		user, err := store.GetUser(ctx, payload.Username)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("user %s does not exist: %w", payload.Username, ErrSkipRetry)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		if user.IsEmailVerified {
+			return nil
+		}
+
+		secretCode, err := util.RandomSecret(32)
+		if err != nil {
+			return err
+		}
+
+		//each attempt issues a fresh code, so a retry after a failed send never reuses a code that may have leaked
+		verifyEmail, err := store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
+			Username:   user.Username,
+			Email:      user.Email,
+			SecretCode: secretCode,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create verify email: %w", err)
+		}
+
+		link := fmt.Sprintf("%s/verify_email?%s", baseURL, url.Values{
+			"email_id":    {fmt.Sprint(verifyEmail.ID)},
+			"secret_code": {verifyEmail.SecretCode},
+			"tenant_id":   {user.TenantID},
+		}.Encode())
+
+		err = sender.SendEmail(ctx, mail.Message{
+			To:      []string{user.Email},
+			Subject: "Welcome to Simple Bank",
+			Body: fmt.Sprintf(`Hello %s,<br/>
+Thank you for registering with us!<br/>
+Please <a href="%s">click here</a> to verify your email address.<br/>
+The link expires in 15 minutes.`, html.EscapeString(user.FullName), html.EscapeString(link)),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to send verify email: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	db "goprojects/simplebank/db/sqlc"
+	"goprojects/simplebank/mail"
+	"goprojects/simplebank/util"
+)
+
+func TestSendVerifyEmail(t *testing.T) {
+	user, err := testStore.CreateUserTx(context.Background(), db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       util.RandomOwner() + util.RandomString(4),
+			HashedPassword: "hashed",
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		},
+		AfterCreate: func(q *db.Queries, user db.User) error {
+			_, err := TaskSendVerifyEmail.Enqueue(context.Background(), q, PayloadSendVerifyEmail{Username: user.Username}, Priority(100))
+			return err
+		},
+	})
+	require.NoError(t, err)
+
+	sender := mail.NewMemorySender()
+	p := NewProcessor(testStore, PollInterval(10*time.Millisecond))
+	HandleSendVerifyEmail(p, testStore, sender, "http://bank.test")
+
+	runProcessor(t, p, func() bool {
+		for _, msg := range sender.Messages() {
+			if msg.To[0] == user.Email {
+				return true
+			}
+		}
+		return false
+	})
+
+	var body string
+	for _, msg := range sender.Messages() {
+		if msg.To[0] == user.Email {
+			body = msg.Body
+		}
+	}
+
+	// The link in the email verifies the user.
+	match := regexp.MustCompile(`href="http://bank\.test/verify_email\?([^"]+)"`).FindStringSubmatch(body)
+	require.Len(t, match, 2)
+	query, err := url.ParseQuery(html.UnescapeString(match[1]))
+	require.NoError(t, err)
+	require.Equal(t, db.DefaultTenant, query.Get("tenant_id"))
+	emailID, err := strconv.ParseInt(query.Get("email_id"), 10, 64)
+	require.NoError(t, err)
+
+	result, err := testStore.VerifyEmailTx(context.Background(), db.VerifyEmailTxParams{
+		EmailID:    emailID,
+		SecretCode: query.Get("secret_code"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.User.Username)
+	require.True(t, result.User.IsEmailVerified)
+}